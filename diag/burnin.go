@@ -0,0 +1,488 @@
+package diag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// Default burn-in settings
+	defaultBurnInPhaseTime      = 5 * time.Minute
+	defaultBurnInSampleInterval = 10 * time.Second
+	defaultBurnInToggleInterval = 100 * time.Millisecond
+	defaultBurnInMaxTemp        = 85.0
+)
+
+// BurnInConfig controls a burn-in run
+type BurnInConfig struct {
+	Duration       time.Duration // Total stress time, excluding time spent rebooted
+	PhaseTime      time.Duration // Time in each stress phase, default 5m
+	SampleInterval time.Duration // Telemetry sample interval, default 10s
+	ToggleInterval time.Duration // GPIO toggle period, default 100ms
+	TogglePins     []string      // Output pins toggled and read back, in addition to GPIOLoopbacks
+	MaxTemp        float64       // Fails if CPU or GPU temperature exceeds this, default 85°C
+	MinVoltage     float64       // Fails if the supply drops below this, default Config.MinVoltage
+	MaxErrors      int           // Fails if more errors occur
+	CheckpointPath string        // Progress file for resuming after a reboot, empty to disable
+}
+
+// Range is the observed span of a measurement
+type Range struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+	Count int     `json:"count"`
+}
+
+// add folds a sample into the range
+func (r *Range) add(v float64) {
+	if r.Count == 0 || v < r.Min {
+		r.Min = v
+	}
+	if r.Count == 0 || v > r.Max {
+		r.Max = v
+	}
+	r.Count++
+	r.Mean += (v - r.Mean) / float64(r.Count)
+}
+
+// BurnInReport is the outcome of a burn-in run
+type BurnInReport struct {
+	Start       time.Time      `json:"start"`
+	End         time.Time      `json:"end,omitempty"`
+	Elapsed     time.Duration  `json:"elapsed"`           // Stress time completed
+	Cycles      int            `json:"cycles"`            // Complete passes through every phase
+	Resumes     int            `json:"resumes"`           // Times resumed from a checkpoint
+	Resets      int            `json:"resets"`            // Resumes after an unexpected reset, each a failure
+	Aborted     bool           `json:"aborted,omitempty"` // Stopped early on a temperature or supply limit
+	Temperature Range          `json:"temperature"`
+	Voltage     Range          `json:"voltage"`
+	Errors      map[string]int `json:"errors"` // Error counts by phase
+	LastErrors  []string       `json:"last_errors,omitempty"`
+	Verdict     TestStatus     `json:"verdict,omitempty"`
+	Failures    []string       `json:"failures,omitempty"` // Criteria that were not met
+}
+
+// burnInCheckpoint is the persisted progress of a burn-in run
+type burnInCheckpoint struct {
+	Phase    int  `json:"phase"` // Next phase to run
+	Complete bool `json:"complete"`
+	// Set when the run was stopped through its context; an incomplete
+	// checkpoint without it was left by an unexpected reset
+	Suspended bool         `json:"suspended"`
+	Report    BurnInReport `json:"report"`
+}
+
+// Most recent error messages kept in a burn-in report
+const maxBurnInErrors = 20
+
+// burnIn tracks a running burn-in
+type burnIn struct {
+	m   *Manager
+	cfg BurnInConfig
+
+	mux   sync.Mutex
+	state burnInCheckpoint
+
+	// Stops the stress phases when a limit is crossed
+	abort context.CancelFunc
+}
+
+// burnInPhase is one stress phase, run until its context expires
+type burnInPhase struct {
+	name string
+	run  func(ctx context.Context, fail func(error))
+}
+
+// BurnIn stresses the hardware for Duration, cycling CPU load, fan sweeps and
+// GPIO toggling while sampling temperatures and supply voltage. Progress is
+// checkpointed after each phase, so a run stopped by cancelling ctx, e.g. for
+// a planned reboot, resumes from its last completed phase when called again
+// with the same CheckpointPath. A run resumed after an unexpected reset
+// continues but fails. Crossing MaxTemp or MinVoltage stops the load and
+// fails the run at once; it also fails if more than MaxErrors errors occur.
+// Burn-in holds the hardware exclusively; diagnostic runs wait until it
+// finishes.
+func (m *Manager) BurnIn(ctx context.Context, cfg BurnInConfig) (*BurnInReport, error) {
+	if cfg.Duration <= 0 {
+		return nil, fmt.Errorf("burn-in duration required")
+	}
+	if cfg.PhaseTime == 0 {
+		cfg.PhaseTime = defaultBurnInPhaseTime
+	}
+	if cfg.SampleInterval == 0 {
+		cfg.SampleInterval = defaultBurnInSampleInterval
+	}
+	if cfg.ToggleInterval == 0 {
+		cfg.ToggleInterval = defaultBurnInToggleInterval
+	}
+	if cfg.MaxTemp == 0 {
+		cfg.MaxTemp = defaultBurnInMaxTemp
+	}
+	if cfg.MinVoltage == 0 {
+		cfg.MinVoltage = m.cfg.MinVoltage
+	}
+
+	m.exclusive.Lock()
+	defer m.exclusive.Unlock()
+
+	b := &burnIn{m: m, cfg: cfg}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	runCtx, abort := context.WithCancel(ctx)
+	defer abort()
+	b.abort = abort
+
+	phases := []burnInPhase{
+		{"cpu_load", b.cpuLoad},
+		{"fan_sweep", b.fanSweep},
+		{"gpio_toggle", b.gpioToggle},
+	}
+
+	samplerCtx, stopSampler := context.WithCancel(runCtx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b.sample(samplerCtx)
+	}()
+	defer func() {
+		stopSampler()
+		wg.Wait()
+	}()
+
+	for b.remaining() > 0 {
+		b.mux.Lock()
+		phase := phases[b.state.Phase%len(phases)]
+		b.mux.Unlock()
+
+		started := time.Now()
+		phaseCtx, cancel := context.WithTimeout(runCtx, min(cfg.PhaseTime, b.remaining()))
+		phase.run(phaseCtx, func(err error) {
+			// Expiry of the phase itself is not a fault
+			if phaseCtx.Err() != nil && errors.Is(err, phaseCtx.Err()) {
+				return
+			}
+			b.recordError(phase.name, err)
+		})
+		cancel()
+
+		// An interrupted phase is repeated on resume
+		if err := ctx.Err(); err != nil {
+			b.mux.Lock()
+			b.state.Suspended = true
+			b.mux.Unlock()
+			return nil, errors.Join(err, b.save())
+		}
+
+		// A phase cut short by a crossed limit ends the run
+		b.mux.Lock()
+		b.state.Report.Elapsed += time.Since(started)
+		aborted := b.state.Report.Aborted
+		if !aborted {
+			b.state.Phase++
+			if b.state.Phase%len(phases) == 0 {
+				b.state.Report.Cycles++
+			}
+		}
+		b.mux.Unlock()
+		if aborted {
+			break
+		}
+		if err := b.save(); err != nil {
+			return nil, err
+		}
+	}
+
+	stopSampler()
+	wg.Wait()
+	report := b.finish()
+	if err := b.save(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// load resumes from an unfinished checkpoint or starts a new run
+func (b *burnIn) load() error {
+	b.state = burnInCheckpoint{Report: BurnInReport{Start: time.Now(), Errors: make(map[string]int)}}
+	if b.cfg.CheckpointPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(b.cfg.CheckpointPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return b.save()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read burn-in checkpoint: %w", err)
+	}
+	var saved burnInCheckpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode burn-in checkpoint: %w", err)
+	}
+	if saved.Complete {
+		return b.save()
+	}
+
+	b.state = saved
+	b.state.Report.Resumes++
+	if !saved.Suspended {
+		b.state.Report.Resets++
+	}
+	b.state.Suspended = false
+	if b.state.Report.Errors == nil {
+		b.state.Report.Errors = make(map[string]int)
+	}
+	return b.save()
+}
+
+// save checkpoints progress, replacing the previous checkpoint atomically
+func (b *burnIn) save() error {
+	if b.cfg.CheckpointPath == "" {
+		return nil
+	}
+
+	b.mux.Lock()
+	data, err := json.Marshal(b.state)
+	b.mux.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode burn-in checkpoint: %w", err)
+	}
+
+	dir := filepath.Dir(b.cfg.CheckpointPath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(b.cfg.CheckpointPath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create burn-in checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write burn-in checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync burn-in checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close burn-in checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.cfg.CheckpointPath); err != nil {
+		return fmt.Errorf("failed to replace burn-in checkpoint: %w", err)
+	}
+	return nil
+}
+
+// remaining returns the stress time still to run
+func (b *burnIn) remaining() time.Duration {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.cfg.Duration - b.state.Report.Elapsed
+}
+
+// recordError counts an error against a phase
+func (b *burnIn) recordError(phase string, err error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.state.Report.Errors[phase]++
+	b.state.Report.LastErrors = append(b.state.Report.LastErrors, fmt.Sprintf("%s: %v", phase, err))
+	if excess := len(b.state.Report.LastErrors) - maxBurnInErrors; excess > 0 {
+		b.state.Report.LastErrors = b.state.Report.LastErrors[excess:]
+	}
+}
+
+// sample records temperatures and supply voltage until ctx is cancelled,
+// aborting the run when a limit is crossed
+func (b *burnIn) sample(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		if !b.sampleOnce() {
+			b.mux.Lock()
+			b.state.Report.Aborted = true
+			b.mux.Unlock()
+			b.abort()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleOnce takes one telemetry sample, reporting whether it is within limits
+func (b *burnIn) sampleOnce() bool {
+	var temps []float64
+	if b.m.cfg.Thermal != nil {
+		state := b.m.cfg.Thermal.GetState()
+		temps = append(temps, state.CPUTemp, state.GPUTemp)
+	}
+
+	// Boards without a supply ADC have no voltage to sample
+	var voltages []float64
+	if b.m.cfg.Power != nil {
+		if sample, err := b.m.cfg.Power.Sample(); err == nil {
+			voltages = append(voltages, sample.Voltage)
+		}
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	ok := true
+	for _, t := range temps {
+		b.state.Report.Temperature.add(t)
+		ok = ok && t <= b.cfg.MaxTemp
+	}
+	for _, v := range voltages {
+		b.state.Report.Voltage.add(v)
+		ok = ok && v >= b.cfg.MinVoltage
+	}
+	return ok
+}
+
+// cpuLoad holds the system under load for the phase
+func (b *burnIn) cpuLoad(ctx context.Context, fail func(error)) {
+	load := b.m.cfg.LoadGenerator
+	if load == nil {
+		load = NewStressLoad(0, 0)
+	}
+	if err := load.Start(ctx); err != nil {
+		fail(fmt.Errorf("failed to start load: %w", err))
+		return
+	}
+	<-ctx.Done()
+	if err := load.Stop(); err != nil {
+		fail(fmt.Errorf("failed to stop load: %w", err))
+	}
+}
+
+// fanSweep repeats fan response sweeps for the phase
+func (b *burnIn) fanSweep(ctx context.Context, fail func(error)) {
+	if b.m.cfg.Thermal == nil {
+		return
+	}
+	// Without a tachometer a sweep only checks fan control, so run it once
+	if _, err := b.m.cfg.Thermal.FanRPM(); err != nil {
+		if err := b.m.TestFanResponse(ctx); err != nil {
+			fail(err)
+		}
+		<-ctx.Done()
+		return
+	}
+	for ctx.Err() == nil {
+		if err := b.m.TestFanResponse(ctx); err != nil {
+			fail(err)
+		}
+	}
+}
+
+// gpioToggle toggles output pins and verifies their read back, and repeats
+// loopback tests, for the phase. Pins are restored afterwards.
+func (b *burnIn) gpioToggle(ctx context.Context, fail func(error)) {
+	if len(b.cfg.TogglePins) == 0 && len(b.m.cfg.GPIOLoopbacks) == 0 {
+		return
+	}
+
+	var saved []pinSnapshot
+	for _, pin := range b.cfg.TogglePins {
+		s, err := b.m.savePin(pin)
+		if err != nil {
+			fail(fmt.Errorf("failed to save pin %s: %w", pin, err))
+			continue
+		}
+		saved = append(saved, s)
+	}
+	defer func() {
+		for i := len(saved) - 1; i >= 0; i-- {
+			if err := b.m.restorePin(saved[i]); err != nil {
+				fail(fmt.Errorf("failed to restore pin %s: %w", saved[i].name, err))
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(b.cfg.ToggleInterval)
+	defer ticker.Stop()
+	level := true
+	for {
+		for _, s := range saved {
+			if err := b.m.cfg.GPIO.SetPinState(s.name, level); err != nil {
+				fail(fmt.Errorf("failed to drive pin %s: %w", s.name, err))
+				continue
+			}
+			if got, err := b.m.cfg.GPIO.GetPinState(s.name); err != nil {
+				fail(fmt.Errorf("failed to read pin %s: %w", s.name, err))
+			} else if got != level {
+				fail(fmt.Errorf("pin %s read %v after driving %v", s.name, got, level))
+			}
+		}
+		level = !level
+
+		if len(b.m.cfg.GPIOLoopbacks) > 0 {
+			if err := b.m.testLoopbacks(ctx); err != nil {
+				fail(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// finish applies the pass/fail criteria and marks the run complete
+func (b *burnIn) finish() *BurnInReport {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	r := &b.state.Report
+	r.End = time.Now()
+	r.Failures = nil
+	if r.Temperature.Count > 0 && r.Temperature.Max > b.cfg.MaxTemp {
+		r.Failures = append(r.Failures, fmt.Sprintf("temperature reached %.1f°C, limit %.1f°C", r.Temperature.Max, b.cfg.MaxTemp))
+	}
+	if r.Voltage.Count > 0 && r.Voltage.Min < b.cfg.MinVoltage {
+		r.Failures = append(r.Failures, fmt.Sprintf("supply dropped to %.2fV, minimum %.2fV", r.Voltage.Min, b.cfg.MinVoltage))
+	}
+	total := 0
+	for _, n := range r.Errors {
+		total += n
+	}
+	if total > b.cfg.MaxErrors {
+		r.Failures = append(r.Failures, fmt.Sprintf("%d errors, limit %d", total, b.cfg.MaxErrors))
+	}
+	if r.Resets > 0 {
+		r.Failures = append(r.Failures, fmt.Sprintf("%d unexpected resets", r.Resets))
+	}
+	if r.Aborted {
+		r.Failures = append(r.Failures, fmt.Sprintf("aborted after %s of %s", r.Elapsed.Round(time.Millisecond), b.cfg.Duration))
+	}
+
+	r.Verdict = StatusPass
+	if len(r.Failures) > 0 {
+		r.Verdict = StatusFail
+	}
+	b.state.Complete = true
+
+	report := *r
+	report.Errors = make(map[string]int, len(r.Errors))
+	for k, v := range r.Errors {
+		report.Errors[k] = v
+	}
+	report.Failures = append([]string(nil), r.Failures...)
+	report.LastErrors = append([]string(nil), r.LastErrors...)
+	return &report
+}
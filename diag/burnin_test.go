@@ -0,0 +1,228 @@
+package diag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+	"periph.io/x/conn/v3/gpio/gpiotest"
+)
+
+// countingLoad counts how often load is applied and removed
+type countingLoad struct {
+	starts atomic.Int32
+	stops  atomic.Int32
+}
+
+func (l *countingLoad) Start(ctx context.Context) error {
+	l.starts.Add(1)
+	return nil
+}
+
+func (l *countingLoad) Stop() error {
+	l.stops.Add(1)
+	return nil
+}
+
+func TestBurnIn(t *testing.T) {
+	// newBurnInManager builds a manager with the supply at millivolts and
+	// the fan tach at rpmPerDuty, and a pin to toggle
+	newBurnInManager := func(t *testing.T, millivolts string, rpmPerDuty float64) (*Manager, *countingLoad) {
+		gpioCtrl, err := NewMockGPIO()
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		if err := gpioCtrl.ConfigurePin("burn_pin", &gpiotest.Pin{N: "burn_pin"}, gpio.PullNone); err != nil {
+			t.Fatalf("Failed to configure pin: %v", err)
+		}
+		powerMgr, _ := newTestPower(t, gpioCtrl, millivolts, "")
+
+		load := &countingLoad{}
+		return newTestManager(t, Config{
+			GPIO:          gpioCtrl,
+			Power:         powerMgr,
+			Thermal:       newTestThermal(t, gpioCtrl, rpmPerDuty, false),
+			LoadGenerator: load,
+			FanSettleTime: time.Millisecond,
+		}), load
+	}
+
+	cfg := BurnInConfig{
+		Duration:       90 * time.Millisecond,
+		PhaseTime:      15 * time.Millisecond,
+		SampleInterval: 5 * time.Millisecond,
+		ToggleInterval: time.Millisecond,
+		TogglePins:     []string{"burn_pin"},
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		mgr, load := newBurnInManager(t, "5000", 30)
+		report, err := mgr.BurnIn(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Failed to run burn-in: %v", err)
+		}
+		if report.Verdict != StatusPass {
+			t.Errorf("Expected pass, got %s: %v %v", report.Verdict, report.Failures, report.LastErrors)
+		}
+		if report.Cycles < 1 || load.starts.Load() < 1 || report.Elapsed < cfg.Duration {
+			t.Errorf("Expected complete cycles, got %d cycles in %v", report.Cycles, report.Elapsed)
+		}
+		if report.Temperature.Count == 0 || report.Voltage.Min != 5.0 {
+			t.Errorf("Telemetry not recorded: %+v %+v", report.Temperature, report.Voltage)
+		}
+		if state, _ := mgr.cfg.GPIO.IsPinOutput("burn_pin"); state {
+			t.Error("Toggled pin not restored to input")
+		}
+	})
+
+	t.Run("Criteria", func(t *testing.T) {
+		mgr, _ := newBurnInManager(t, "5000", 0)
+		report, err := mgr.BurnIn(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Failed to run burn-in: %v", err)
+		}
+		if report.Verdict != StatusFail {
+			t.Fatal("Expected burn-in failure")
+		}
+		if report.Errors["fan_sweep"] == 0 {
+			t.Error("Dead fan not counted as an error")
+		}
+		if failures := strings.Join(report.Failures, "; "); !strings.Contains(failures, "errors") {
+			t.Errorf("Unexpected failures: %s", failures)
+		}
+	})
+
+	t.Run("Limit Abort", func(t *testing.T) {
+		mgr, load := newBurnInManager(t, "4500", 30)
+		long := cfg
+		long.Duration = 10 * time.Second
+		report, err := mgr.BurnIn(context.Background(), long)
+		if err != nil {
+			t.Fatalf("Failed to run burn-in: %v", err)
+		}
+		if report.Verdict != StatusFail || !report.Aborted || report.Elapsed >= time.Second {
+			t.Fatalf("Expected early abort, got %s after %v", report.Verdict, report.Elapsed)
+		}
+		if load.starts.Load() != load.stops.Load() {
+			t.Error("Load left running after abort")
+		}
+		failures := strings.Join(report.Failures, "; ")
+		if !strings.Contains(failures, "supply dropped") || !strings.Contains(failures, "aborted") {
+			t.Errorf("Unexpected failures: %s", failures)
+		}
+	})
+
+	t.Run("No Supply ADC", func(t *testing.T) {
+		mgr, _ := newBurnInManager(t, "5000", 30)
+		powerMgr, err := power.New(power.Config{GPIO: mgr.cfg.GPIO})
+		if err != nil {
+			t.Fatalf("Failed to create power manager: %v", err)
+		}
+		mgr.cfg.Power = powerMgr
+
+		report, err := mgr.BurnIn(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("Failed to run burn-in: %v", err)
+		}
+		if report.Voltage.Count != 0 || report.Verdict != StatusPass {
+			t.Errorf("Expected no voltage samples and a pass, got %+v %s: %v", report.Voltage, report.Verdict, report.Failures)
+		}
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		mgr, _ := newBurnInManager(t, "5000", 30)
+		resumable := cfg
+		resumable.Duration = 150 * time.Millisecond
+		resumable.CheckpointPath = filepath.Join(t.TempDir(), "burnin.json")
+
+		// Interrupted as if by a reboot
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := mgr.BurnIn(ctx, resumable); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected interrupted burn-in, got %v", err)
+		}
+		if _, err := os.Stat(resumable.CheckpointPath); err != nil {
+			t.Fatalf("Checkpoint not written: %v", err)
+		}
+
+		report, err := mgr.BurnIn(context.Background(), resumable)
+		if err != nil {
+			t.Fatalf("Failed to resume burn-in: %v", err)
+		}
+		if report.Resumes != 1 || report.Resets != 0 || report.Elapsed < resumable.Duration {
+			t.Errorf("Expected resumed run to finish, got %d resumes after %v", report.Resumes, report.Elapsed)
+		}
+		if time.Since(report.Start) < 50*time.Millisecond {
+			t.Error("Resumed run lost its original start time")
+		}
+
+		// A finished run is not resumed
+		report, _ = mgr.BurnIn(context.Background(), resumable)
+		if report.Resumes != 0 {
+			t.Error("Completed burn-in resumed")
+		}
+	})
+
+	t.Run("Unexpected Reset", func(t *testing.T) {
+		mgr, _ := newBurnInManager(t, "5000", 30)
+		resumable := cfg
+		resumable.CheckpointPath = filepath.Join(t.TempDir(), "burnin.json")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		mgr.BurnIn(ctx, resumable)
+
+		// Lose the suspend marker as if power had been cut mid-phase
+		data, err := os.ReadFile(resumable.CheckpointPath)
+		if err != nil {
+			t.Fatalf("Failed to read checkpoint: %v", err)
+		}
+		var saved burnInCheckpoint
+		if err := json.Unmarshal(data, &saved); err != nil {
+			t.Fatalf("Failed to decode checkpoint: %v", err)
+		}
+		saved.Suspended = false
+		data, _ = json.Marshal(saved)
+		writeTestFile(t, resumable.CheckpointPath, string(data))
+
+		report, err := mgr.BurnIn(context.Background(), resumable)
+		if err != nil {
+			t.Fatalf("Failed to resume burn-in: %v", err)
+		}
+		if report.Resets != 1 || report.Verdict != StatusFail {
+			t.Errorf("Expected reset to fail the run, got %d resets and %s", report.Resets, report.Verdict)
+		}
+	})
+	t.Run("Excludes Runs", func(t *testing.T) {
+		mgr, _ := newBurnInManager(t, "5000", 30)
+		var started atomic.Int64
+		mgr.Register(NewTest(TestInfo{Name: "probe"}, func(ctx context.Context, report Reporter) error {
+			started.Store(time.Now().UnixNano())
+			report(TestResult{Component: "probe", Status: StatusPass})
+			return nil
+		}))
+
+		start := time.Now()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			mgr.BurnIn(context.Background(), cfg)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		if _, err := mgr.Run(context.Background(), Selection{Names: []string{"probe"}}); err != nil {
+			t.Fatalf("Failed to run probe: %v", err)
+		}
+		<-done
+		if time.Unix(0, started.Load()).Before(start.Add(cfg.Duration)) {
+			t.Error("Test ran while burn-in held the hardware")
+		}
+	})
+}
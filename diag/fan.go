@@ -0,0 +1,208 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Default fan characterization settings
+	defaultFanSettleTime    = 3 * time.Second
+	defaultFanStallRPM      = 300
+	defaultFanDegradedRatio = 0.7
+	// Curve fits below this coefficient of determination are erratic
+	minFanCurveFit = 0.9
+)
+
+// Default fan sweep duty cycles
+var defaultFanSweep = []uint32{25, 50, 75, 100}
+
+// fanPoint is one sweep measurement
+type fanPoint struct {
+	duty float64
+	rpm  float64
+	temp float64
+}
+
+// fitLine fits y = slope*x + intercept by least squares and returns the
+// coefficient of determination
+func fitLine(x, y []float64) (slope, intercept, r2 float64) {
+	n := float64(len(x))
+	var sx, sy, sxx, sxy float64
+	for i := range x {
+		sx += x[i]
+		sy += y[i]
+		sxx += x[i] * x[i]
+		sxy += x[i] * y[i]
+	}
+	denom := n*sxx - sx*sx
+	if denom == 0 {
+		return 0, sy / n, 0
+	}
+	slope = (n*sxy - sx*sy) / denom
+	intercept = (sy - slope*sx) / n
+
+	mean := sy / n
+	var ssRes, ssTot float64
+	for i := range x {
+		fit := slope*x[i] + intercept
+		ssRes += (y[i] - fit) * (y[i] - fit)
+		ssTot += (y[i] - mean) * (y[i] - mean)
+	}
+	if ssTot == 0 {
+		return slope, intercept, 0
+	}
+	return slope, intercept, 1 - ssRes/ssTot
+}
+
+// TestFanResponse sweeps the fan through FanSweep duty cycles, measuring
+// tach speed and CPU temperature at each point, fits a duty-to-RPM curve and
+// flags dead or degraded fans. Automatic cooling is restored afterwards.
+func (m *Manager) TestFanResponse(ctx context.Context) error {
+	if m.cfg.Thermal == nil {
+		return fmt.Errorf("thermal monitor not configured")
+	}
+	defer m.cfg.Thermal.ReleaseFan()
+
+	// Without a tachometer only fan control can be verified
+	if _, err := m.cfg.Thermal.FanRPM(); err != nil {
+		if err := m.cfg.Thermal.OverrideFan(m.cfg.FanSweep[0]); err != nil {
+			m.recordResult(ctx, TestResult{
+				Type:        TestThermal,
+				Component:   "fan",
+				Status:      StatusFail,
+				Description: "Failed to control fan speed",
+				Error:       err,
+				Timestamp:   time.Now(),
+			})
+			return fmt.Errorf("failed to control fan: %w", err)
+		}
+		m.recordResult(ctx, TestResult{
+			Type:        TestThermal,
+			Component:   "fan_curve",
+			Status:      StatusSkipped,
+			Description: "Fan response test requires a tachometer",
+			Error:       err,
+			Timestamp:   time.Now(),
+		})
+		return nil
+	}
+
+	var points []fanPoint
+	for _, duty := range m.cfg.FanSweep {
+		if err := m.cfg.Thermal.OverrideFan(duty); err != nil {
+			m.recordResult(ctx, TestResult{
+				Type:        TestThermal,
+				Component:   "fan",
+				Status:      StatusFail,
+				Description: "Failed to control fan speed",
+				Error:       err,
+				Timestamp:   time.Now(),
+			})
+			return fmt.Errorf("failed to control fan: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.cfg.FanSettleTime):
+		}
+
+		rpm, err := m.cfg.Thermal.FanRPM()
+		if err != nil {
+			m.recordResult(ctx, TestResult{
+				Type:        TestThermal,
+				Component:   fmt.Sprintf("fan_%d", duty),
+				Status:      StatusFail,
+				Description: "Failed to read fan tachometer",
+				Error:       err,
+				Timestamp:   time.Now(),
+			})
+			return fmt.Errorf("failed to read fan tachometer: %w", err)
+		}
+		point := fanPoint{duty: float64(duty), rpm: rpm, temp: m.cfg.Thermal.GetState().CPUTemp}
+		points = append(points, point)
+
+		// Sweep points are readings; many fans stop at low duty by design,
+		// so stalls are judged at the top duty below
+		m.recordResult(ctx, TestResult{
+			Type:        TestThermal,
+			Component:   fmt.Sprintf("fan_%d", duty),
+			Status:      StatusPass,
+			Reading:     rpm,
+			Description: fmt.Sprintf("Fan at %d%%: %.0f RPM, CPU %.1f°C", duty, rpm, point.temp),
+			Timestamp:   time.Now(),
+		})
+	}
+
+	var errs []error
+	duties := make([]float64, len(points))
+	speeds := make([]float64, len(points))
+	for i, p := range points {
+		duties[i], speeds[i] = p.duty, p.rpm
+	}
+	slope, intercept, r2 := fitLine(duties, speeds)
+	status := StatusPass
+	description := fmt.Sprintf("Fan curve %.1f RPM/%% + %.0f RPM (R² %.2f)", slope, intercept, r2)
+	switch {
+	case len(points) > 1 && slope <= 0:
+		status = StatusFail
+		errs = append(errs, fmt.Errorf("fan speed does not increase with duty cycle"))
+	case len(points) > 2 && r2 < minFanCurveFit:
+		status = StatusWarning
+	}
+	m.recordResult(ctx, TestResult{
+		Type:        TestThermal,
+		Component:   "fan_curve",
+		Status:      status,
+		Reading:     slope,
+		Description: description,
+		Timestamp:   time.Now(),
+	})
+
+	// Judge fan health at the highest tested duty cycle
+	top := points[0]
+	for _, p := range points {
+		if p.duty > top.duty {
+			top = p
+		}
+	}
+	status, description = StatusPass, "Fan healthy"
+	expected := m.cfg.FanStallRPM
+	switch {
+	case top.rpm < m.cfg.FanStallRPM:
+		status, description = StatusFail, "Fan dead or stalled"
+		errs = append(errs, fmt.Errorf("fan stalled at %.0f%% duty (%.0f RPM)", top.duty, top.rpm))
+	case m.cfg.FanRatedRPM > 0:
+		expected = m.cfg.FanRatedRPM * m.cfg.FanDegradedRatio
+		if top.rpm < expected {
+			status, description = StatusFail, "Fan degraded below rated speed"
+			errs = append(errs, fmt.Errorf("fan reached %.0f RPM, expected at least %.0f", top.rpm, expected))
+		}
+	}
+	m.recordResult(ctx, TestResult{
+		Type:        TestThermal,
+		Component:   "fan_health",
+		Status:      status,
+		Reading:     top.rpm,
+		Expected:    expected,
+		Description: description,
+		Timestamp:   time.Now(),
+	})
+
+	// Temperature response from the first to the last sweep point
+	first, last := points[0], points[len(points)-1]
+	m.recordResult(ctx, TestResult{
+		Type:        TestThermal,
+		Component:   "fan_temp_response",
+		Status:      StatusPass,
+		Reading:     last.temp - first.temp,
+		Description: fmt.Sprintf("CPU temperature changed %.1f°C over sweep", math.Round((last.temp-first.temp)*10)/10),
+		Timestamp:   time.Now(),
+	})
+
+	return errors.Join(errs...)
+}
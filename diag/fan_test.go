@@ -0,0 +1,106 @@
+package diag
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/thermal"
+)
+
+func TestFanResponse(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		rpmPerDuty float64
+		noTach     bool
+		failed     string
+	}{
+		{"Healthy Fan", 30, false, ""},
+		{"Degraded Fan", 15, false, "fan_health"},
+		{"Dead Fan", 0, false, "fan_health"},
+		{"No Tachometer", 0, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gpioCtrl, err := NewMockGPIO()
+			if err != nil {
+				t.Fatalf("Failed to create GPIO controller: %v", err)
+			}
+			monitor := newTestThermal(t, gpioCtrl, tt.rpmPerDuty, tt.noTach)
+			mgr := newTestManager(t, Config{
+				GPIO:          gpioCtrl,
+				Thermal:       monitor,
+				FanSettleTime: time.Millisecond,
+				FanRatedRPM:   3000,
+			})
+
+			err = mgr.TestFanResponse(ctx)
+			if (err == nil) != (tt.failed == "") {
+				t.Fatalf("Unexpected fan test result: %v", err)
+			}
+			if state := monitor.GetState(); state.FanOverride {
+				t.Error("Automatic cooling not restored")
+			}
+
+			results := resultsByComponent(mgr.GetResults())
+			if tt.noTach {
+				if results["fan_curve"].Status != StatusSkipped {
+					t.Errorf("Expected skipped fan curve, got %s", results["fan_curve"].Status)
+				}
+				return
+			}
+
+			for _, duty := range []string{"fan_25", "fan_50", "fan_75", "fan_100"} {
+				if _, ok := results[duty]; !ok {
+					t.Errorf("Missing sweep point %s", duty)
+				}
+			}
+			if tt.failed != "" && results[tt.failed].Status != StatusFail {
+				t.Errorf("Expected %s to fail, got %s", tt.failed, results[tt.failed].Status)
+			}
+			if curve := results["fan_curve"]; tt.rpmPerDuty > 0 && math.Abs(curve.Reading-tt.rpmPerDuty) > 0.01 {
+				t.Errorf("Expected fitted slope %.1f, got %.2f", tt.rpmPerDuty, curve.Reading)
+			}
+		})
+	}
+	t.Run("Stops At Low Duty", func(t *testing.T) {
+		gpioCtrl, err := NewMockGPIO()
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		tach := &stoppingTach{fakeTach: fakeTach{rpmPerDuty: 30}, stopBelow: 50}
+		monitor, err := thermal.New(thermal.Config{GPIO: gpioCtrl, FanControlPin: "fan", FanTach: tach})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+		tach.monitor = monitor
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Thermal: monitor, FanSettleTime: time.Millisecond})
+
+		if err := mgr.TestFanResponse(ctx); err != nil {
+			t.Fatalf("Expected a fan stopping at low duty to pass: %v", err)
+		}
+		results := resultsByComponent(mgr.GetResults())
+		if r := results["fan_25"]; r.Status != StatusPass || r.Reading != 0 {
+			t.Errorf("Expected low duty point recorded as a reading, got %s %.0f", r.Status, r.Reading)
+		}
+		if results["fan_health"].Status != StatusPass {
+			t.Errorf("Expected healthy fan, got %s", results["fan_health"].Status)
+		}
+	})
+}
+
+// stoppingTach reports a fan that stops below a minimum duty cycle
+type stoppingTach struct {
+	fakeTach
+	stopBelow uint32
+}
+
+func (s *stoppingTach) ReadRPM() (float64, error) {
+	if s.monitor.GetState().FanSpeed < s.stopBelow {
+		return 0, nil
+	}
+	return s.fakeTach.ReadRPM()
+}
@@ -0,0 +1,287 @@
+package diag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+	"github.com/wrale/wrale-fleet-metal-hw/secure"
+	"github.com/wrale/wrale-fleet-metal-hw/thermal"
+)
+
+// HealthStatus is the coarse health of a component or device
+type HealthStatus string
+
+const (
+	HealthHealthy  HealthStatus = "HEALTHY"
+	HealthDegraded HealthStatus = "DEGRADED"
+	HealthFailing  HealthStatus = "FAILING"
+)
+
+// Score penalties for health factors
+const (
+	penaltyTestFail      = 40.0
+	penaltyTestWarning   = 10.0
+	penaltyCritical      = 40.0
+	penaltyWarning       = 15.0
+	penaltyThrottled     = 10.0
+	penaltyOnBattery     = 10.0
+	penaltyLowBattery    = 30.0
+	penaltyAlarm         = 60.0
+	penaltySensorFault   = 15.0
+	penaltyZeroized      = 40.0
+	penaltyVoltageSensor = 20.0
+
+	// Battery level below which running on battery is failing
+	lowBatteryLevel = 20.0
+	// Scores below this are degraded even without a degrading factor
+	degradedScore = 80.0
+)
+
+// HealthFactor is one contribution to a component's health
+type HealthFactor struct {
+	Source  string       // diagnostics, thermal, power or security
+	Name    string       // Test component or condition
+	Status  HealthStatus // Severity this factor alone implies
+	Penalty float64      // Points deducted from 100
+	Reason  string
+}
+
+// ComponentHealth is the health of one subsystem
+type ComponentHealth struct {
+	Component TestType
+	Status    HealthStatus
+	Score     float64 // 0-100
+	Factors   []HealthFactor
+}
+
+// Health is the overall device health
+type Health struct {
+	Status     HealthStatus // Worst component status
+	Score      float64      // Lowest component score
+	Components []ComponentHealth
+	Timestamp  time.Time
+}
+
+// healthRank orders health statuses from best to worst
+func healthRank(s HealthStatus) int {
+	switch s {
+	case HealthDegraded:
+		return 1
+	case HealthFailing:
+		return 2
+	}
+	return 0
+}
+
+// healthBuilder accumulates factors by component
+type healthBuilder struct {
+	order      []TestType
+	components map[TestType][]HealthFactor
+}
+
+func (b *healthBuilder) ensure(component TestType) {
+	if _, ok := b.components[component]; !ok {
+		b.order = append(b.order, component)
+		b.components[component] = nil
+	}
+}
+
+func (b *healthBuilder) add(component TestType, f HealthFactor) {
+	b.ensure(component)
+	b.components[component] = append(b.components[component], f)
+}
+
+// Health scores each subsystem from the latest diagnostic result of every
+// test component, active thermal and power warnings, and tamper state. Each
+// component starts at 100 and loses points per factor; its status is the
+// worst status implied by any factor, and degraded at minimum once the score
+// drops below 80. Factors explain every deduction.
+func (m *Manager) Health() Health {
+	b := &healthBuilder{components: make(map[TestType][]HealthFactor)}
+	b.ensure(TestGPIO)
+	if m.cfg.Power != nil {
+		b.ensure(TestPower)
+	}
+	if m.cfg.Thermal != nil {
+		b.ensure(TestThermal)
+	}
+	if m.cfg.Security != nil {
+		b.ensure(TestSecurity)
+	}
+
+	m.diagnosticFactors(b)
+	if m.cfg.Thermal != nil {
+		thermalFactors(b, m.cfg.Thermal.GetState())
+	}
+	if m.cfg.Power != nil {
+		m.powerFactors(b, m.cfg.Power.GetState())
+	}
+	if m.cfg.Security != nil {
+		securityFactors(b, m.cfg.Security.GetState())
+	}
+
+	health := Health{Status: HealthHealthy, Score: 100, Timestamp: time.Now()}
+	for _, component := range b.order {
+		c := ComponentHealth{
+			Component: component,
+			Status:    HealthHealthy,
+			Score:     100,
+			Factors:   b.components[component],
+		}
+		for _, f := range c.Factors {
+			c.Score -= f.Penalty
+			if healthRank(f.Status) > healthRank(c.Status) {
+				c.Status = f.Status
+			}
+		}
+		if c.Score < 0 {
+			c.Score = 0
+		}
+		if c.Score < degradedScore && c.Status == HealthHealthy {
+			c.Status = HealthDegraded
+		}
+
+		if healthRank(c.Status) > healthRank(health.Status) {
+			health.Status = c.Status
+		}
+		if c.Score < health.Score {
+			health.Score = c.Score
+		}
+		health.Components = append(health.Components, c)
+	}
+	return health
+}
+
+// diagnosticFactors penalizes the latest failing and warning results
+func (m *Manager) diagnosticFactors(b *healthBuilder) {
+	type key struct{ test, component string }
+	latest := make(map[key]TestResult)
+	var order []key
+	for _, r := range m.GetResults() {
+		k := key{r.Test, r.Component}
+		if _, seen := latest[k]; !seen {
+			order = append(order, k)
+		}
+		latest[k] = r
+	}
+
+	for _, k := range order {
+		r := latest[k]
+		f := HealthFactor{Source: "diagnostics", Name: r.Component}
+		switch r.Status {
+		case StatusFail:
+			f.Status, f.Penalty = HealthFailing, penaltyTestFail
+		case StatusWarning:
+			f.Status, f.Penalty = HealthDegraded, penaltyTestWarning
+		default:
+			b.ensure(r.Type)
+			continue
+		}
+		f.Reason = fmt.Sprintf("%s %s at %s", r.Description, strings.ToLower(string(r.Status)),
+			r.Timestamp.Format(time.RFC3339))
+		if r.Error != nil {
+			f.Reason += ": " + r.Error.Error()
+		}
+		b.add(r.Type, f)
+	}
+}
+
+// thermalFactors penalizes active thermal warnings and protective actions.
+// Warnings mirroring runaway and condensation state are left to the structured
+// factors so each condition counts once.
+func thermalFactors(b *healthBuilder, state thermal.ThermalState) {
+	runaway := state.Runaway != thermal.RunawayNone && state.Runaway != ""
+	for _, w := range state.Warnings {
+		if (runaway && w == thermal.WarningRunaway) ||
+			(state.Condensation && strings.HasPrefix(w, thermal.WarningCondensation)) {
+			continue
+		}
+		f := HealthFactor{Source: "thermal", Name: w, Status: HealthDegraded, Penalty: penaltyWarning, Reason: w}
+		if strings.Contains(strings.ToLower(w), "critical") {
+			f.Status, f.Penalty = HealthFailing, penaltyCritical
+		}
+		b.add(TestThermal, f)
+	}
+
+	switch state.Runaway {
+	case thermal.RunawayNone, "":
+	case thermal.RunawayThrottle:
+		b.add(TestThermal, HealthFactor{Source: "thermal", Name: "runaway", Status: HealthDegraded,
+			Penalty: penaltyWarning, Reason: "Thermal runaway protection throttling"})
+	default:
+		b.add(TestThermal, HealthFactor{Source: "thermal", Name: "runaway", Status: HealthFailing,
+			Penalty: penaltyCritical, Reason: fmt.Sprintf("Thermal runaway escalated to %s", state.Runaway)})
+	}
+
+	// Runaway protection forces throttling, which the runaway factor covers
+	if state.Throttled && !runaway {
+		b.add(TestThermal, HealthFactor{Source: "thermal", Name: "throttled", Status: HealthDegraded,
+			Penalty: penaltyThrottled, Reason: fmt.Sprintf("CPU throttled at level %d", state.ThrottleLevel)})
+	}
+	if state.Condensation {
+		b.add(TestThermal, HealthFactor{Source: "thermal", Name: "condensation", Status: HealthDegraded,
+			Penalty: penaltyWarning, Reason: fmt.Sprintf("Surface near dew point %.1f°C", state.DewPoint)})
+	}
+}
+
+// powerFactors penalizes low supply voltage, power quality warnings and
+// running on battery
+func (m *Manager) powerFactors(b *healthBuilder, state power.PowerState) {
+	if state.Voltage > 0 && state.Voltage < m.cfg.MinVoltage {
+		b.add(TestPower, HealthFactor{Source: "power", Name: "voltage", Status: HealthFailing,
+			Penalty: penaltyCritical, Reason: fmt.Sprintf("Supply %.2fV below minimum %.2fV", state.Voltage, m.cfg.MinVoltage)})
+	}
+	if metrics := state.StabilityMetrics; metrics != nil {
+		for _, w := range metrics.Warnings {
+			b.add(TestPower, HealthFactor{Source: "power", Name: w, Status: HealthDegraded,
+				Penalty: penaltyWarning, Reason: w})
+		}
+	}
+	if state.CurrentSource == power.BatteryPower {
+		f := HealthFactor{Source: "power", Name: "battery", Status: HealthDegraded, Penalty: penaltyOnBattery,
+			Reason: fmt.Sprintf("Running on battery at %.0f%%", state.BatteryLevel)}
+		if state.BatteryLevel < lowBatteryLevel {
+			f.Status, f.Penalty = HealthFailing, penaltyLowBattery
+		}
+		b.add(TestPower, f)
+	}
+}
+
+// securityFactors penalizes alarms, zeroization and faulty tamper sensors
+func securityFactors(b *healthBuilder, state secure.TamperState) {
+	switch state.Mode {
+	case secure.ModeAlarm:
+		b.add(TestSecurity, HealthFactor{Source: "security", Name: "alarm", Status: HealthFailing,
+			Penalty: penaltyAlarm, Reason: fmt.Sprintf("Tamper alarm raised by %s", state.LatchedBy)})
+	case secure.ModeAcknowledged:
+		b.add(TestSecurity, HealthFactor{Source: "security", Name: "alarm", Status: HealthDegraded,
+			Penalty: penaltyWarning, Reason: "Tamper alarm acknowledged but not re-armed"})
+	case secure.ModeMaintenance:
+		b.add(TestSecurity, HealthFactor{Source: "security", Name: "maintenance", Status: HealthHealthy,
+			Reason: fmt.Sprintf("Maintenance by %s until %s", state.MaintenanceBy,
+				state.MaintenanceUntil.Format(time.RFC3339))})
+	}
+	if state.Zeroized {
+		b.add(TestSecurity, HealthFactor{Source: "security", Name: "zeroized", Status: HealthFailing,
+			Penalty: penaltyZeroized, Reason: "Secrets zeroized after tamper"})
+	}
+	if !state.VoltageNormal && !state.LastCheck.IsZero() {
+		b.add(TestSecurity, HealthFactor{Source: "security", Name: "voltage_monitor", Status: HealthDegraded,
+			Penalty: penaltyVoltageSensor, Reason: "Security voltage monitor abnormal"})
+	}
+
+	names := make([]string, 0, len(state.Sensors))
+	for name := range state.Sensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fault := state.Sensors[name].Fault; fault != "" {
+			b.add(TestSecurity, HealthFactor{Source: "security", Name: name, Status: HealthDegraded,
+				Penalty: penaltySensorFault, Reason: fault})
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+	"github.com/wrale/wrale-fleet-metal-hw/secure"
+	"github.com/wrale/wrale-fleet-metal-hw/thermal"
+)
+
+func TestHealth(t *testing.T) {
+	gpioCtrl, err := NewMockGPIO()
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+	mgr, err := New(Config{GPIO: gpioCtrl})
+	if err != nil {
+		t.Fatalf("Failed to create diagnostic manager: %v", err)
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		health := mgr.Health()
+		if health.Status != HealthHealthy || health.Score != 100 {
+			t.Errorf("Expected healthy device, got %s %.0f", health.Status, health.Score)
+		}
+		if len(health.Components) != 1 || health.Components[0].Component != TestGPIO {
+			t.Errorf("Expected GPIO component only, got %+v", health.Components)
+		}
+	})
+
+	t.Run("Latest Diagnostics", func(t *testing.T) {
+		ctx := context.Background()
+		mgr.recordResult(ctx, TestResult{Type: TestGPIO, Test: "gpio", Component: "pin1",
+			Status: StatusFail, Error: errors.New("stuck high"), Timestamp: time.Now()})
+		mgr.recordResult(ctx, TestResult{Type: TestGPIO, Test: "gpio", Component: "pin2",
+			Status: StatusWarning, Timestamp: time.Now()})
+
+		health := mgr.Health()
+		gpio := health.Components[0]
+		if gpio.Status != HealthFailing || gpio.Score != 50 || len(gpio.Factors) != 2 {
+			t.Errorf("Expected failing GPIO, got %+v", gpio)
+		}
+		if health.Status != HealthFailing || health.Score != 50 {
+			t.Errorf("Overall health does not reflect GPIO, got %s %.0f", health.Status, health.Score)
+		}
+
+		// A later pass supersedes the failure
+		mgr.recordResult(ctx, TestResult{Type: TestGPIO, Test: "gpio", Component: "pin1",
+			Status: StatusPass, Timestamp: time.Now()})
+		gpio = mgr.Health().Components[0]
+		if gpio.Status != HealthDegraded || gpio.Score != 90 {
+			t.Errorf("Expected degraded GPIO, got %+v", gpio)
+		}
+	})
+
+	t.Run("Thermal", func(t *testing.T) {
+		b := &healthBuilder{components: make(map[TestType][]HealthFactor)}
+		thermalFactors(b, thermal.ThermalState{
+			Warnings:  []string{"CPU temperature critical", thermal.WarningRunaway},
+			Runaway:   thermal.RunawayThrottle,
+			Throttled: true,
+		})
+		factors := b.components[TestThermal]
+		if len(factors) != 2 || factors[0].Status != HealthFailing || factors[1].Name != "runaway" {
+			t.Errorf("Unexpected thermal factors: %+v", factors)
+		}
+
+		// Throttling outside runaway still counts
+		b = &healthBuilder{components: make(map[TestType][]HealthFactor)}
+		thermalFactors(b, thermal.ThermalState{Throttled: true, ThrottleLevel: 2})
+		if factors := b.components[TestThermal]; len(factors) != 1 || factors[0].Name != "throttled" {
+			t.Errorf("Expected throttled factor, got %+v", factors)
+		}
+	})
+
+	t.Run("Condensation", func(t *testing.T) {
+		b := &healthBuilder{components: make(map[TestType][]HealthFactor)}
+		thermalFactors(b, thermal.ThermalState{
+			Warnings:     []string{thermal.WarningCondensation + ": surface 4.0°C near dew point 3.5°C"},
+			Condensation: true,
+			DewPoint:     3.5,
+		})
+		factors := b.components[TestThermal]
+		if len(factors) != 1 || factors[0].Name != "condensation" {
+			t.Errorf("Expected exactly one condensation factor, got %+v", factors)
+		}
+	})
+
+	t.Run("Power", func(t *testing.T) {
+		b := &healthBuilder{components: make(map[TestType][]HealthFactor)}
+		mgr.powerFactors(b, power.PowerState{
+			CurrentSource:    power.BatteryPower,
+			BatteryLevel:     10,
+			Voltage:          4.5,
+			StabilityMetrics: &power.StabilityMetrics{Warnings: []string{"voltage ripple high"}},
+		})
+		factors := b.components[TestPower]
+		if len(factors) != 3 {
+			t.Fatalf("Expected 3 power factors, got %+v", factors)
+		}
+		if factors[0].Name != "voltage" || factors[2].Status != HealthFailing {
+			t.Errorf("Unexpected power factors: %+v", factors)
+		}
+	})
+
+	t.Run("Security", func(t *testing.T) {
+		b := &healthBuilder{components: make(map[TestType][]HealthFactor)}
+		securityFactors(b, secure.TamperState{
+			Mode:          secure.ModeAlarm,
+			LatchedBy:     secure.SensorCase,
+			VoltageNormal: true,
+			Sensors: map[string]secure.SensorStatus{
+				"light": {Fault: "no reading"},
+			},
+		})
+		factors := b.components[TestSecurity]
+		if len(factors) != 2 || factors[0].Status != HealthFailing || factors[1].Reason != "no reading" {
+			t.Errorf("Unexpected security factors: %+v", factors)
+		}
+	})
+}
@@ -0,0 +1,338 @@
+package diag
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// Default number of runs kept in history
+	defaultHistoryRuns = 500
+	// Default relative reading change flagged as a regression
+	defaultRegressionTolerance = 0.1
+)
+
+// Bolt bucket names
+var (
+	historyRunsBucket      = []byte("runs")
+	historyBaselinesBucket = []byte("baselines")
+)
+
+// History persists diagnostic reports in an embedded bbolt database, keeping
+// the most recent runs for trending and named baselines for comparison
+type History struct {
+	db      *bolt.DB
+	maxRuns int
+}
+
+// OpenHistory opens or creates a history database at path. Only the latest
+// maxRuns reports are kept, default 500.
+func OpenHistory(path string, maxRuns int) (*History, error) {
+	if maxRuns <= 0 {
+		maxRuns = defaultHistoryRuns
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(historyRunsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(historyBaselinesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history: %w", err)
+	}
+
+	return &History{db: db, maxRuns: maxRuns}, nil
+}
+
+// runKey orders runs by start time, with a sequence number to keep keys unique
+func runKey(at time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(at.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// Record stores a report, discarding the oldest runs beyond the limit
+func (h *History) Record(ctx context.Context, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	return h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyRunsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(runKey(report.Start, seq), data); err != nil {
+			return err
+		}
+
+		c := bucket.Cursor()
+		runs := 0
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			runs++
+		}
+		for ; runs > h.maxRuns; runs-- {
+			c.First()
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Reports returns stored runs started at or after since in chronological
+// order. A zero since returns every run.
+func (h *History) Reports(ctx context.Context, since time.Time) ([]*Report, error) {
+	var reports []*Report
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(historyRunsBucket).Cursor()
+		k, v := c.First()
+		if !since.IsZero() {
+			k, v = c.Seek(runKey(since, 0))
+		}
+		for ; k != nil; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			report, err := ReadReport(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			reports = append(reports, report)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return reports, nil
+}
+
+// Latest returns the most recent run
+func (h *History) Latest(ctx context.Context) (*Report, error) {
+	var report *Report
+	err := h.db.View(func(tx *bolt.Tx) error {
+		_, v := tx.Bucket(historyRunsBucket).Cursor().Last()
+		if v == nil {
+			return fmt.Errorf("no runs recorded")
+		}
+		var err error
+		report, err = ReadReport(bytes.NewReader(v))
+		return err
+	})
+	return report, err
+}
+
+// SetBaseline stores a report as a named baseline, e.g. the acceptance run
+// at commissioning
+func (h *History) SetBaseline(ctx context.Context, name string, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(historyBaselinesBucket).Put([]byte(name), data)
+	})
+}
+
+// Baseline retrieves a named baseline
+func (h *History) Baseline(ctx context.Context, name string) (*Report, error) {
+	var report *Report
+	err := h.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(historyBaselinesBucket).Get([]byte(name))
+		if data == nil {
+			return fmt.Errorf("no baseline %s", name)
+		}
+		var err error
+		report, err = ReadReport(bytes.NewReader(data))
+		return err
+	})
+	return report, err
+}
+
+// CompareLatest compares the most recent run with a named baseline
+func (h *History) CompareLatest(ctx context.Context, baseline string, tolerance float64) ([]Regression, error) {
+	base, err := h.Baseline(ctx, baseline)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := h.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return CompareReports(base, latest, tolerance), nil
+}
+
+// Close closes the database
+func (h *History) Close() error {
+	return h.db.Close()
+}
+
+// TrendPoint is one historical reading of a component
+type TrendPoint struct {
+	Timestamp time.Time
+	Reading   float64
+	Status    TestStatus
+}
+
+// Trend summarizes how a component's reading has moved across runs
+type Trend struct {
+	Test      string
+	Component string
+	Points    []TrendPoint // Chronological
+	PerDay    float64      // Fitted reading change per day
+	Change    float64      // Latest reading minus the first
+}
+
+// Trend returns the reading history of a test component over runs started
+// at or after since, with a fitted daily rate of change
+func (h *History) Trend(ctx context.Context, test, component string, since time.Time) (Trend, error) {
+	trend := Trend{Test: test, Component: component}
+	reports, err := h.Reports(ctx, since)
+	if err != nil {
+		return trend, err
+	}
+
+	for _, report := range reports {
+		for _, t := range report.Tests {
+			if t.Name != test {
+				continue
+			}
+			for _, r := range t.Results {
+				if r.Component == component && r.Status != StatusSkipped {
+					trend.Points = append(trend.Points, TrendPoint{
+						Timestamp: r.Timestamp,
+						Reading:   r.Reading,
+						Status:    r.Status,
+					})
+				}
+			}
+		}
+	}
+	if len(trend.Points) < 2 {
+		return trend, nil
+	}
+
+	first := trend.Points[0]
+	days := make([]float64, len(trend.Points))
+	readings := make([]float64, len(trend.Points))
+	for i, p := range trend.Points {
+		days[i] = p.Timestamp.Sub(first.Timestamp).Hours() / 24
+		readings[i] = p.Reading
+	}
+	trend.PerDay, _, _ = fitLine(days, readings)
+	trend.Change = trend.Points[len(trend.Points)-1].Reading - first.Reading
+	return trend, nil
+}
+
+// RegressionKind classifies a difference from the baseline
+type RegressionKind string
+
+const (
+	RegressionStatus  RegressionKind = "STATUS"  // Status worse than the baseline
+	RegressionDrift   RegressionKind = "DRIFT"   // Reading moved beyond tolerance
+	RegressionMissing RegressionKind = "MISSING" // Component no longer measured
+)
+
+// Regression is a component that got worse relative to a baseline
+type Regression struct {
+	Kind      RegressionKind
+	Test      string
+	Component string
+	Baseline  TestResult
+	Latest    TestResult // Zero when missing
+	Change    float64    // Relative reading change, e.g. -0.15 for a 15% drop
+}
+
+// statusRank orders statuses from best to worst
+func statusRank(s TestStatus) int {
+	switch s {
+	case StatusWarning:
+		return 1
+	case StatusFail:
+		return 2
+	}
+	return 0
+}
+
+// resultKey identifies a measurement across runs
+type resultKey struct {
+	test      string
+	component string
+}
+
+// indexResults maps each measured component to its last result
+func indexResults(report *Report) (map[resultKey]TestResult, []resultKey) {
+	index := make(map[resultKey]TestResult)
+	var order []resultKey
+	for _, t := range report.Tests {
+		for _, r := range t.Results {
+			key := resultKey{t.Name, r.Component}
+			if _, seen := index[key]; !seen {
+				order = append(order, key)
+			}
+			index[key] = r
+		}
+	}
+	return index, order
+}
+
+// CompareReports flags components in latest that regressed from baseline:
+// a worse status, a reading that moved by more than tolerance relative to the
+// baseline (default 10%), or a measurement that is no longer taken. Readings
+// are compared in both directions since whether higher is better depends on
+// the component.
+func CompareReports(baseline, latest *Report, tolerance float64) []Regression {
+	if tolerance <= 0 {
+		tolerance = defaultRegressionTolerance
+	}
+
+	before, order := indexResults(baseline)
+	after, _ := indexResults(latest)
+
+	var regressions []Regression
+	for _, key := range order {
+		base := before[key]
+		if base.Status == StatusSkipped {
+			continue
+		}
+		reg := Regression{Test: key.test, Component: key.component, Baseline: base}
+
+		cur, ok := after[key]
+		switch {
+		case !ok || cur.Status == StatusSkipped:
+			reg.Kind = RegressionMissing
+			reg.Latest = cur
+		case statusRank(cur.Status) > statusRank(base.Status):
+			reg.Kind, reg.Latest = RegressionStatus, cur
+		case base.Reading != 0 && math.Abs(cur.Reading-base.Reading)/math.Abs(base.Reading) > tolerance:
+			reg.Kind, reg.Latest = RegressionDrift, cur
+		default:
+			continue
+		}
+		if reg.Kind != RegressionMissing && base.Reading != 0 {
+			reg.Change = (cur.Reading - base.Reading) / math.Abs(base.Reading)
+		}
+		regressions = append(regressions, reg)
+	}
+	return regressions
+}
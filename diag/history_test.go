@@ -0,0 +1,156 @@
+package diag
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fanReport builds a run measuring the fan at full duty
+func fanReport(at time.Time, rpm float64, status TestStatus) *Report {
+	return &Report{
+		Start:   at,
+		Verdict: status,
+		Tests: []TestReport{{
+			Name: "thermal", Category: TestThermal, Status: status, Start: at,
+			Results: []TestResult{
+				{Test: "thermal", Component: "fan_100", Status: status, Reading: rpm, Timestamp: at},
+				{Test: "thermal", Component: "thermal_system", Status: StatusPass, Timestamp: at},
+			},
+		}},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	openHistory := func(t *testing.T, maxRuns int) *History {
+		h, err := OpenHistory(filepath.Join(t.TempDir(), "history.db"), maxRuns)
+		if err != nil {
+			t.Fatalf("Failed to open history: %v", err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+
+	t.Run("Bounded", func(t *testing.T) {
+		h := openHistory(t, 3)
+		for day := 0; day < 5; day++ {
+			if err := h.Record(ctx, fanReport(start.AddDate(0, 0, day), 3000, StatusPass)); err != nil {
+				t.Fatalf("Failed to record report: %v", err)
+			}
+		}
+		reports, err := h.Reports(ctx, time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to read history: %v", err)
+		}
+		if len(reports) != 3 || !reports[0].Start.Equal(start.AddDate(0, 0, 2)) {
+			t.Errorf("Expected the latest 3 runs, got %d", len(reports))
+		}
+		latest, err := h.Latest(ctx)
+		if err != nil || !latest.Start.Equal(start.AddDate(0, 0, 4)) {
+			t.Errorf("Unexpected latest run: %v", err)
+		}
+	})
+
+	t.Run("Trend", func(t *testing.T) {
+		h := openHistory(t, 0)
+		// Fan loses 10 RPM a day over three months
+		for day := 0; day <= 90; day += 10 {
+			h.Record(ctx, fanReport(start.AddDate(0, 0, day), 3000-10*float64(day), StatusPass))
+		}
+
+		trend, err := h.Trend(ctx, "thermal", "fan_100", time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to compute trend: %v", err)
+		}
+		if len(trend.Points) != 10 {
+			t.Fatalf("Expected 10 points, got %d", len(trend.Points))
+		}
+		if math.Abs(trend.PerDay+10) > 0.01 || trend.Change != -900 {
+			t.Errorf("Expected -10 RPM/day and -900 RPM, got %.2f and %.0f", trend.PerDay, trend.Change)
+		}
+
+		recent, _ := h.Trend(ctx, "thermal", "fan_100", start.AddDate(0, 0, 60))
+		if len(recent.Points) != 4 {
+			t.Errorf("Expected 4 recent points, got %d", len(recent.Points))
+		}
+	})
+
+	t.Run("Baseline Regression", func(t *testing.T) {
+		h := openHistory(t, 0)
+		if _, err := h.CompareLatest(ctx, "commissioning", 0); err == nil {
+			t.Error("Expected error without baseline")
+		}
+
+		if err := h.SetBaseline(ctx, "commissioning", fanReport(start, 3000, StatusPass)); err != nil {
+			t.Fatalf("Failed to set baseline: %v", err)
+		}
+		h.Record(ctx, fanReport(start.AddDate(0, 6, 0), 2950, StatusPass))
+		regressions, err := h.CompareLatest(ctx, "commissioning", 0)
+		if err != nil {
+			t.Fatalf("Failed to compare: %v", err)
+		}
+		if len(regressions) != 0 {
+			t.Errorf("Expected no regressions within tolerance, got %+v", regressions)
+		}
+
+		h.Record(ctx, fanReport(start.AddDate(1, 0, 0), 2400, StatusPass))
+		regressions, _ = h.CompareLatest(ctx, "commissioning", 0)
+		if len(regressions) != 1 || regressions[0].Kind != RegressionDrift {
+			t.Fatalf("Expected fan drift, got %+v", regressions)
+		}
+		if math.Abs(regressions[0].Change+0.2) > 1e-9 {
+			t.Errorf("Expected 20%% drop, got %.3f", regressions[0].Change)
+		}
+	})
+
+	t.Run("Compare Reports", func(t *testing.T) {
+		baseline := fanReport(start, 3000, StatusPass)
+
+		failing := fanReport(start, 3000, StatusFail)
+		if r := CompareReports(baseline, failing, 0); len(r) != 1 || r[0].Kind != RegressionStatus {
+			t.Errorf("Expected status regression, got %+v", r)
+		}
+
+		missing := fanReport(start, 3000, StatusPass)
+		missing.Tests[0].Results = missing.Tests[0].Results[1:]
+		if r := CompareReports(baseline, missing, 0); len(r) != 1 || r[0].Kind != RegressionMissing {
+			t.Errorf("Expected missing measurement, got %+v", r)
+		}
+	})
+	t.Run("Manager", func(t *testing.T) {
+		gpioCtrl, err := NewMockGPIO()
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		h := openHistory(t, 0)
+		mgr, err := New(Config{GPIO: gpioCtrl, History: h, MaxResults: 2})
+		if err != nil {
+			t.Fatalf("Failed to create diagnostic manager: %v", err)
+		}
+		mgr.Register(NewTest(TestInfo{Name: "modem"}, func(ctx context.Context, report Reporter) error {
+			for _, c := range []string{"sim", "signal", "registration"} {
+				report(TestResult{Component: c, Status: StatusPass})
+			}
+			return nil
+		}))
+
+		if _, err := mgr.Run(ctx, Selection{Names: []string{"modem"}}); err != nil {
+			t.Fatalf("Failed to run tests: %v", err)
+		}
+		if results := mgr.GetResults(); len(results) != 2 || results[1].Component != "registration" {
+			t.Errorf("Expected the latest 2 results in memory, got %d", len(results))
+		}
+		latest, err := h.Latest(ctx)
+		if err != nil {
+			t.Fatalf("Run not recorded: %v", err)
+		}
+		if len(latest.Tests) != 1 || len(latest.Tests[0].Results) != 3 {
+			t.Error("Recorded run missing results")
+		}
+	})
+}
@@ -0,0 +1,311 @@
+package diag
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+)
+
+const (
+	// Default power load test thresholds
+	defaultLoadSampleInterval = 100 * time.Millisecond
+	defaultMaxVoltageSag      = 0.25 // Volts below the idle baseline
+	defaultMaxRipple          = 0.1  // Volts peak-to-peak under steady load
+	defaultMaxRecoveryTime    = 2 * time.Second
+
+	// Idle samples averaged for the load test baseline
+	baselineSamples = 5
+)
+
+// LoadGenerator applies and removes system load for power testing
+type LoadGenerator interface {
+	Start(ctx context.Context) error
+	Stop() error
+}
+
+// StressLoad generates in-process CPU and memory load
+type StressLoad struct {
+	workers  int
+	memoryMB int
+
+	mux    sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	memory [][]byte
+}
+
+// NewStressLoad creates a load generator running workers busy goroutines,
+// one per CPU when zero, and holding memoryMB of touched memory
+func NewStressLoad(workers, memoryMB int) *StressLoad {
+	if workers == 0 {
+		workers = runtime.NumCPU()
+	}
+	return &StressLoad{workers: workers, memoryMB: memoryMB}
+}
+
+// Start begins generating load
+func (s *StressLoad) Start(ctx context.Context) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.cancel != nil {
+		return fmt.Errorf("load already running")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	// Touch every page so the memory is actually committed
+	for i := 0; i < s.memoryMB; i++ {
+		block := make([]byte, 1<<20)
+		for j := 0; j < len(block); j += 4096 {
+			block[j] = byte(j)
+		}
+		s.memory = append(s.memory, block)
+	}
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			buf := make([]byte, 64*1024)
+			for ctx.Err() == nil {
+				sum := sha256.Sum256(buf)
+				buf[0] = sum[0]
+			}
+		}()
+	}
+	return nil
+}
+
+// Stop ends the load and returns its memory to the system
+func (s *StressLoad) Stop() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.cancel = nil
+	s.memory = nil
+	debug.FreeOSMemory()
+	return nil
+}
+
+// loadMetrics summarizes supply behaviour during a load test
+type loadMetrics struct {
+	baseline    float64
+	minVoltage  float64
+	sag         float64
+	ripple      float64
+	peakCurrent float64
+	recovery    time.Duration
+	recovered   bool
+}
+
+// TestPowerLoad applies load for LoadTestTime while sampling the supply, and
+// reports voltage sag, steady-state ripple and recovery time after the load
+// is removed. The load is always stopped before returning.
+func (m *Manager) TestPowerLoad(ctx context.Context) (err error) {
+	if m.cfg.Power == nil {
+		return fmt.Errorf("power manager not configured")
+	}
+
+	interval := m.cfg.LoadSampleInterval
+	if interval == 0 {
+		interval = defaultLoadSampleInterval
+	}
+	load := m.cfg.LoadGenerator
+	if load == nil {
+		load = NewStressLoad(0, 0)
+	}
+
+	// wait sleeps one sample interval unless ctx is cancelled
+	wait := func() error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+			return nil
+		}
+	}
+
+	// Idle baseline
+	var metrics loadMetrics
+	for i := 0; i < baselineSamples; i++ {
+		sample, serr := m.cfg.Power.Sample()
+		if serr != nil {
+			return m.loadFailure(ctx, "Failed to sample supply", serr)
+		}
+		metrics.baseline += sample.Voltage / baselineSamples
+		if i < baselineSamples-1 {
+			if err := wait(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if serr := load.Start(ctx); serr != nil {
+		return m.loadFailure(ctx, "Failed to start load", serr)
+	}
+	stopped := false
+	defer func() {
+		if !stopped {
+			err = errors.Join(err, load.Stop())
+		}
+	}()
+
+	var loaded []power.PowerSample
+	deadline := time.Now().Add(m.cfg.LoadTestTime)
+	for time.Now().Before(deadline) {
+		if err := wait(); err != nil {
+			return err
+		}
+		sample, serr := m.cfg.Power.Sample()
+		if serr != nil {
+			return m.loadFailure(ctx, "Failed to sample supply under load", serr)
+		}
+		loaded = append(loaded, sample)
+	}
+
+	stopped = true
+	if serr := load.Stop(); serr != nil {
+		return m.loadFailure(ctx, "Failed to stop load", serr)
+	}
+
+	// Recovered once back within half the allowed ripple of the baseline
+	released := time.Now()
+	for time.Since(released) <= m.cfg.MaxRecoveryTime {
+		if err := wait(); err != nil {
+			return err
+		}
+		sample, serr := m.cfg.Power.Sample()
+		if serr != nil {
+			return m.loadFailure(ctx, "Failed to sample supply after load", serr)
+		}
+		if math.Abs(sample.Voltage-metrics.baseline) <= m.cfg.MaxRipple/2 {
+			metrics.recovery = sample.Timestamp.Sub(released)
+			metrics.recovered = true
+			break
+		}
+	}
+
+	metrics.summarize(loaded)
+	return m.recordLoadResults(ctx, metrics)
+}
+
+// summarize computes sag, ripple and peak current from loaded samples
+func (l *loadMetrics) summarize(samples []power.PowerSample) {
+	l.minVoltage = l.baseline
+	for _, s := range samples {
+		l.minVoltage = math.Min(l.minVoltage, s.Voltage)
+		l.peakCurrent = math.Max(l.peakCurrent, s.Current)
+	}
+	l.sag = l.baseline - l.minVoltage
+
+	// Ripple is measured once the initial sag has settled
+	steady := samples[len(samples)/2:]
+	if len(steady) == 0 {
+		return
+	}
+	low, high := steady[0].Voltage, steady[0].Voltage
+	for _, s := range steady {
+		low = math.Min(low, s.Voltage)
+		high = math.Max(high, s.Voltage)
+	}
+	l.ripple = high - low
+}
+
+// recordLoadResults records each load metric against its threshold
+func (m *Manager) recordLoadResults(ctx context.Context, l loadMetrics) error {
+	var errs []error
+	now := time.Now()
+
+	status := StatusPass
+	description := "Voltage sag within limits"
+	switch {
+	case l.minVoltage < m.cfg.MinVoltage:
+		status = StatusFail
+		description = "Voltage fell below minimum under load"
+		errs = append(errs, fmt.Errorf("voltage %.3fV below minimum %.3fV under load", l.minVoltage, m.cfg.MinVoltage))
+	case l.sag > m.cfg.MaxVoltageSag:
+		status = StatusFail
+		description = "Excessive voltage sag under load"
+		errs = append(errs, fmt.Errorf("voltage sag %.3fV exceeds %.3fV", l.sag, m.cfg.MaxVoltageSag))
+	}
+	m.recordResult(ctx, TestResult{
+		Type:        TestPower,
+		Component:   "load_sag",
+		Status:      status,
+		Reading:     l.sag,
+		Expected:    m.cfg.MaxVoltageSag,
+		Description: description,
+		Timestamp:   now,
+	})
+
+	status, description = StatusPass, "Ripple within limits"
+	if l.ripple > m.cfg.MaxRipple {
+		status, description = StatusFail, "Excessive ripple under load"
+		errs = append(errs, fmt.Errorf("ripple %.3fV exceeds %.3fV", l.ripple, m.cfg.MaxRipple))
+	}
+	m.recordResult(ctx, TestResult{
+		Type:        TestPower,
+		Component:   "load_ripple",
+		Status:      status,
+		Reading:     l.ripple,
+		Expected:    m.cfg.MaxRipple,
+		Description: description,
+		Timestamp:   now,
+	})
+
+	status, description = StatusPass, "Voltage recovered after load"
+	reading := l.recovery.Seconds()
+	if !l.recovered {
+		status, description = StatusFail, "Voltage did not recover after load"
+		reading = m.cfg.MaxRecoveryTime.Seconds()
+		errs = append(errs, fmt.Errorf("voltage did not recover within %s", m.cfg.MaxRecoveryTime))
+	}
+	m.recordResult(ctx, TestResult{
+		Type:        TestPower,
+		Component:   "load_recovery",
+		Status:      status,
+		Reading:     reading,
+		Expected:    m.cfg.MaxRecoveryTime.Seconds(),
+		Description: description,
+		Timestamp:   now,
+	})
+
+	if l.peakCurrent > 0 {
+		m.recordResult(ctx, TestResult{
+			Type:        TestPower,
+			Component:   "load_current",
+			Status:      StatusPass,
+			Reading:     l.peakCurrent,
+			Description: "Peak current under load",
+			Timestamp:   now,
+		})
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadFailure records a load test that could not complete
+func (m *Manager) loadFailure(ctx context.Context, description string, err error) error {
+	m.recordResult(ctx, TestResult{
+		Type:        TestPower,
+		Component:   "power_load",
+		Status:      StatusFail,
+		Description: description,
+		Error:       err,
+		Timestamp:   time.Now(),
+	})
+	return fmt.Errorf("power load test failed: %w", err)
+}
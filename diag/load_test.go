@@ -0,0 +1,133 @@
+package diag
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedLoad writes supply readings to the ADC as load starts and stops
+type scriptedLoad struct {
+	path    string
+	loaded  string
+	idle    string
+	started bool
+	stopped bool
+}
+
+func (l *scriptedLoad) Start(ctx context.Context) error {
+	l.started = true
+	return os.WriteFile(l.path, []byte(l.loaded), 0o600)
+}
+
+func (l *scriptedLoad) Stop() error {
+	l.stopped = true
+	return os.WriteFile(l.path, []byte(l.idle), 0o600)
+}
+
+func TestPowerLoad(t *testing.T) {
+	ctx := context.Background()
+	gpioCtrl, err := NewMockGPIO()
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		loaded string // Millivolts under load
+		idle   string // Millivolts after the load is removed
+		failed string
+	}{
+		{"Healthy Supply", "4900", "5000", ""},
+		{"Excessive Sag", "4780", "5000", "load_sag"},
+		{"No Recovery", "4900", "4900", "load_recovery"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			powerMgr, voltagePath := newTestPower(t, gpioCtrl, "5000", "1250")
+			load := &scriptedLoad{path: voltagePath, loaded: tt.loaded, idle: tt.idle}
+			mgr := newTestManager(t, Config{
+				GPIO:               gpioCtrl,
+				Power:              powerMgr,
+				LoadGenerator:      load,
+				LoadTestTime:       50 * time.Millisecond,
+				LoadSampleInterval: 5 * time.Millisecond,
+				MaxRecoveryTime:    50 * time.Millisecond,
+			})
+
+			err := mgr.TestPowerLoad(ctx)
+			if !load.started || !load.stopped {
+				t.Error("Load not applied and removed")
+			}
+			if (err == nil) != (tt.failed == "") {
+				t.Fatalf("Unexpected load test result: %v", err)
+			}
+
+			results := resultsByComponent(mgr.GetResults())
+			for _, component := range []string{"load_sag", "load_ripple", "load_recovery", "load_current"} {
+				r, ok := results[component]
+				if !ok {
+					t.Errorf("Missing %s result", component)
+					continue
+				}
+				if want := component == tt.failed; (r.Status == StatusFail) != want {
+					t.Errorf("%s: unexpected status %s", component, r.Status)
+				}
+			}
+			if sag := results["load_sag"].Reading; strings.HasPrefix(tt.name, "Healthy") && (sag < 0.099 || sag > 0.101) {
+				t.Errorf("Expected 0.1V sag, got %.3f", sag)
+			}
+			if current := results["load_current"].Reading; current != 1.25 {
+				t.Errorf("Expected 1.25A peak current, got %v", current)
+			}
+		})
+	}
+
+	t.Run("Supply Dependency", func(t *testing.T) {
+		for _, tt := range []struct {
+			millivolts string
+			loadRuns   bool
+		}{
+			{"5000", true},
+			{"4500", false},
+		} {
+			powerMgr, voltagePath := newTestPower(t, gpioCtrl, tt.millivolts, "")
+			load := &scriptedLoad{path: voltagePath, loaded: tt.millivolts, idle: tt.millivolts}
+			mgr := newTestManager(t, Config{
+				GPIO:               gpioCtrl,
+				Power:              powerMgr,
+				LoadGenerator:      load,
+				LoadTestTime:       20 * time.Millisecond,
+				LoadSampleInterval: 5 * time.Millisecond,
+				MaxRecoveryTime:    20 * time.Millisecond,
+			})
+
+			report, err := mgr.Run(ctx, Selection{Names: []string{"power_load"}})
+			if err != nil && tt.loadRuns {
+				t.Fatalf("Failed to run load test: %v", err)
+			}
+			if (report.Verdict == StatusPass) != tt.loadRuns || load.started != tt.loadRuns {
+				t.Errorf("%smV: expected load run %v, got %s with load started %v",
+					tt.millivolts, tt.loadRuns, report.Verdict, load.started)
+			}
+			if r := resultsByComponent(mgr.GetResults())["power_system"]; tt.loadRuns && r.Reading != 5.0 {
+				t.Errorf("Expected sampled 5V supply, got %v", r.Reading)
+			}
+		}
+	})
+
+	t.Run("Stress Load", func(t *testing.T) {
+		load := NewStressLoad(2, 1)
+		if err := load.Start(ctx); err != nil {
+			t.Fatalf("Failed to start load: %v", err)
+		}
+		if err := load.Start(ctx); err == nil {
+			t.Error("Expected error starting load twice")
+		}
+		if err := load.Stop(); err != nil {
+			t.Fatalf("Failed to stop load: %v", err)
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+)
+
+// Default delay before sampling a loopback input
+const defaultLoopbackSettle = time.Millisecond
+
+// pinSnapshot records a pin's mode and level so it can be restored
+type pinSnapshot struct {
+	name   string
+	output bool
+	level  bool
+	pull   gpio.Pull
+}
+
+// savePin records the current mode, level and pull of a pin
+func (m *Manager) savePin(name string) (pinSnapshot, error) {
+	output, err := m.cfg.GPIO.IsPinOutput(name)
+	if err != nil {
+		return pinSnapshot{}, err
+	}
+	level, err := m.cfg.GPIO.GetPinState(name)
+	if err != nil {
+		return pinSnapshot{}, err
+	}
+	pull, err := m.cfg.GPIO.GetPinPull(name)
+	if err != nil {
+		return pinSnapshot{}, err
+	}
+	return pinSnapshot{name: name, output: output, level: level, pull: pull}, nil
+}
+
+// restorePin returns a pin to its recorded mode and level
+func (m *Manager) restorePin(s pinSnapshot) error {
+	if s.output {
+		return m.cfg.GPIO.SetPinState(s.name, s.level)
+	}
+	return m.cfg.GPIO.SetPinInput(s.name, s.pull)
+}
+
+// loopbackSample holds the partner input readings for one pair
+type loopbackSample struct {
+	high     bool // Input with the output driven high
+	low      bool // Input with the output driven low
+	pullUp   bool // Input with the output released and pulled up
+	pullDown bool // Input with the output released and pulled down
+}
+
+// classifyLoopback maps loopback readings to a fault
+func classifyLoopback(s loopbackSample) GPIOFault {
+	switch {
+	case s.high && !s.low:
+		if !s.pullUp || s.pullDown {
+			return FaultPull
+		}
+		return FaultNone
+	case s.high && s.low:
+		return FaultStuckHigh
+	case !s.high && s.low:
+		return FaultShort
+	case s.pullUp:
+		return FaultOpen
+	default:
+		return FaultStuckLow
+	}
+}
+
+// testLoopbacks drives each loopback pair through both levels and both
+// pulls, checks no other pair's input follows it, and restores every pin
+func (m *Manager) testLoopbacks(ctx context.Context) (err error) {
+	pairs := m.cfg.GPIOLoopbacks
+	settle := m.cfg.LoopbackSettle
+	if settle == 0 {
+		settle = defaultLoopbackSettle
+	}
+
+	var saved []pinSnapshot
+	for _, pair := range pairs {
+		for _, name := range []string{pair.Output, pair.Input} {
+			snapshot, serr := m.savePin(name)
+			if serr != nil {
+				m.recordResult(ctx, TestResult{
+					Type:        TestGPIO,
+					Component:   name,
+					Status:      StatusFail,
+					Description: "Failed to read pin state",
+					Error:       serr,
+					Timestamp:   time.Now(),
+				})
+				return fmt.Errorf("failed to save pin %s: %w", name, serr)
+			}
+			saved = append(saved, snapshot)
+		}
+	}
+	defer func() {
+		// Restore in reverse so a pin listed twice ends in its original state
+		for i := len(saved) - 1; i >= 0; i-- {
+			if rerr := m.restorePin(saved[i]); rerr != nil {
+				m.recordResult(ctx, TestResult{
+					Type:        TestGPIO,
+					Component:   saved[i].name,
+					Status:      StatusFail,
+					Description: "Failed to restore pin state",
+					Error:       rerr,
+					Timestamp:   time.Now(),
+				})
+				err = errors.Join(err, fmt.Errorf("failed to restore pin %s: %w", saved[i].name, rerr))
+			}
+		}
+	}()
+
+	// read samples an input after the line settles
+	read := func(name string) (bool, error) {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(settle):
+		}
+		return m.cfg.GPIO.GetPinState(name)
+	}
+
+	var errs []error
+	for i, pair := range pairs {
+		component := pair.Output + "->" + pair.Input
+
+		// Release every output and pull every input down, so only this
+		// pair's output drives a line
+		var sample loopbackSample
+		var crossTalk []string
+		otherHigh := make(map[int]bool)
+		serr := func() error {
+			for _, p := range pairs {
+				if err := m.cfg.GPIO.SetPinInput(p.Output, gpio.PullNone); err != nil {
+					return err
+				}
+				if err := m.cfg.GPIO.SetPinInput(p.Input, gpio.PullDown); err != nil {
+					return err
+				}
+			}
+
+			for _, level := range []bool{true, false} {
+				if err := m.cfg.GPIO.SetPinState(pair.Output, level); err != nil {
+					return err
+				}
+				value, err := read(pair.Input)
+				if err != nil {
+					return err
+				}
+				if level {
+					sample.high = value
+				} else {
+					sample.low = value
+				}
+				for j, other := range pairs {
+					if j == i || other.Input == pair.Input {
+						continue
+					}
+					otherValue, err := m.cfg.GPIO.GetPinState(other.Input)
+					if err != nil {
+						return err
+					}
+					if level {
+						otherHigh[j] = otherValue
+					} else if otherHigh[j] && !otherValue {
+						crossTalk = append(crossTalk, other.Input)
+					}
+				}
+			}
+
+			if err := m.cfg.GPIO.SetPinInput(pair.Output, gpio.PullNone); err != nil {
+				return err
+			}
+			for _, pull := range []gpio.Pull{gpio.PullUp, gpio.PullDown} {
+				if err := m.cfg.GPIO.SetPinInput(pair.Input, pull); err != nil {
+					return err
+				}
+				value, err := read(pair.Input)
+				if err != nil {
+					return err
+				}
+				if pull == gpio.PullUp {
+					sample.pullUp = value
+				} else {
+					sample.pullDown = value
+				}
+			}
+			return nil
+		}()
+		if serr != nil {
+			m.recordResult(ctx, TestResult{
+				Type:        TestGPIO,
+				Component:   component,
+				Status:      StatusFail,
+				Description: "Loopback test could not drive pins",
+				Error:       serr,
+				Timestamp:   time.Now(),
+			})
+			errs = append(errs, fmt.Errorf("loopback %s: %w", component, serr))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		fault := classifyLoopback(sample)
+		description := fmt.Sprintf("Loopback fault %s", fault)
+		if fault == FaultNone && len(crossTalk) > 0 {
+			fault = FaultShort
+			description = fmt.Sprintf("Loopback fault %s to %v", fault, crossTalk)
+		}
+		if fault != FaultNone {
+			m.recordResult(ctx, TestResult{
+				Type:        TestGPIO,
+				Component:   component,
+				Status:      StatusFail,
+				Description: description,
+				Timestamp:   time.Now(),
+			})
+			errs = append(errs, fmt.Errorf("loopback %s: %s", component, description))
+			continue
+		}
+
+		m.recordResult(ctx, TestResult{
+			Type:        TestGPIO,
+			Component:   component,
+			Status:      StatusPass,
+			Description: "Loopback pair functional",
+			Timestamp:   time.Now(),
+		})
+	}
+
+	return errors.Join(errs...)
+}
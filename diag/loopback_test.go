@@ -0,0 +1,179 @@
+package diag
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+	pgpio "periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpiotest"
+)
+
+// testWire is a net connecting jumpered pins
+type testWire struct {
+	mux   sync.Mutex
+	pins  []*wirePin
+	stuck *pgpio.Level // Net shorted to a supply rail
+}
+
+// wirePin is a pin attached to a test wire
+type wirePin struct {
+	gpiotest.Pin
+	wire    *testWire
+	driving bool
+	level   pgpio.Level
+	pull    pgpio.Pull
+	noPull  bool // Internal pull resistor is broken
+}
+
+// attach creates a pin on the wire
+func (w *testWire) attach(name string) *wirePin {
+	p := &wirePin{Pin: gpiotest.Pin{N: name}, wire: w}
+	w.pins = append(w.pins, p)
+	return p
+}
+
+func (p *wirePin) In(pull pgpio.Pull, edge pgpio.Edge) error {
+	p.wire.mux.Lock()
+	defer p.wire.mux.Unlock()
+	p.driving = false
+	p.pull = pull
+	return nil
+}
+
+func (p *wirePin) Out(l pgpio.Level) error {
+	p.wire.mux.Lock()
+	defer p.wire.mux.Unlock()
+	p.driving = true
+	p.level = l
+	return nil
+}
+
+func (p *wirePin) Pull() pgpio.Pull {
+	p.wire.mux.Lock()
+	defer p.wire.mux.Unlock()
+	return p.pull
+}
+
+func (p *wirePin) Read() pgpio.Level {
+	w := p.wire
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if w.stuck != nil {
+		return *w.stuck
+	}
+	for _, pin := range w.pins {
+		if pin.driving {
+			return pin.level
+		}
+	}
+	for _, pin := range w.pins {
+		if pin.noPull {
+			continue
+		}
+		switch pin.pull {
+		case pgpio.PullUp:
+			return pgpio.High
+		case pgpio.PullDown:
+			return pgpio.Low
+		}
+	}
+	return pgpio.Low
+}
+
+func TestGPIOLoopback(t *testing.T) {
+	ctx := context.Background()
+	stuckHigh := pgpio.High
+
+	// Each board builds the wiring for four loopback pairs
+	tests := []struct {
+		name  string
+		wire  func(out, in []*wirePin, wires []*testWire)
+		fault GPIOFault
+	}{
+		{"Functional", func([]*wirePin, []*wirePin, []*testWire) {}, FaultNone},
+		{"Stuck High", func(_ []*wirePin, _ []*wirePin, w []*testWire) { w[0].stuck = &stuckHigh }, FaultStuckHigh},
+		{"Open Jumper", func(_ []*wirePin, in []*wirePin, w []*testWire) {
+			w[0].pins = w[0].pins[:1]
+			in[0].wire = &testWire{pins: []*wirePin{in[0]}}
+		}, FaultOpen},
+		{"Broken Pull", func(_ []*wirePin, in []*wirePin, _ []*testWire) { in[0].noPull = true }, FaultPull},
+		{"Shorted Pairs", func(_, _ []*wirePin, w []*testWire) {
+			// Bridge pair 0 and pair 1 onto one net
+			for _, p := range w[1].pins {
+				p.wire = w[0]
+			}
+			w[0].pins = append(w[0].pins, w[1].pins...)
+		}, FaultShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gpioCtrl, err := NewMockGPIO()
+			if err != nil {
+				t.Fatalf("Failed to create GPIO controller: %v", err)
+			}
+
+			var outs, ins []*wirePin
+			var wires []*testWire
+			var pairs []GPIOLoopback
+			for i := 0; i < 4; i++ {
+				w := &testWire{}
+				outs = append(outs, w.attach("out"))
+				ins = append(ins, w.attach("in"))
+				wires = append(wires, w)
+				pair := GPIOLoopback{Output: "out" + strconv.Itoa(i), Input: "in" + strconv.Itoa(i)}
+				pairs = append(pairs, pair)
+			}
+			tt.wire(outs, ins, wires)
+			for i, pair := range pairs {
+				if err := gpioCtrl.ConfigurePin(pair.Output, outs[i], gpio.PullNone); err != nil {
+					t.Fatalf("Failed to configure pin: %v", err)
+				}
+				if err := gpioCtrl.ConfigurePin(pair.Input, ins[i], gpio.PullUp); err != nil {
+					t.Fatalf("Failed to configure pin: %v", err)
+				}
+			}
+			// Leave one output driving a relay high before the test
+			if err := gpioCtrl.SetPinState("out3", true); err != nil {
+				t.Fatalf("Failed to drive pin: %v", err)
+			}
+
+			mgr, err := New(Config{GPIO: gpioCtrl, GPIOLoopbacks: pairs})
+			if err != nil {
+				t.Fatalf("Failed to create diagnostic manager: %v", err)
+			}
+			err = mgr.TestGPIO(ctx)
+			if (err == nil) != (tt.fault == FaultNone) {
+				t.Fatalf("Unexpected loopback result: %v", err)
+			}
+			if tt.fault != FaultNone && !strings.Contains(err.Error(), string(tt.fault)) {
+				t.Errorf("Expected %s fault, got %v", tt.fault, err)
+			}
+
+			// Every pin is back in its original mode and level
+			if output, _ := gpioCtrl.IsPinOutput("out3"); !output || outs[3].level != pgpio.High {
+				t.Error("Driven output not restored")
+			}
+			if output, _ := gpioCtrl.IsPinOutput("out0"); output {
+				t.Error("Input-mode pin left driving")
+			}
+			if pull, _ := gpioCtrl.GetPinPull("in1"); pull != gpio.PullUp {
+				t.Errorf("Expected input pull restored, got %v", pull)
+			}
+
+			passed := 0
+			for _, r := range mgr.GetResults() {
+				if r.Status == StatusPass {
+					passed++
+				}
+			}
+			if tt.fault == FaultNone && passed != len(pairs) {
+				t.Errorf("Expected %d passing pairs, got %d", len(pairs), passed)
+			}
+		})
+	}
+}
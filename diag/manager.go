@@ -7,13 +7,24 @@ import (
 	"time"
 )
 
+// Default number of results kept in memory
+const defaultMaxResults = 1000
+
 // Manager handles hardware diagnostics and testing
 type Manager struct {
 	mux sync.RWMutex
 	cfg Config
 
+	// Registered tests in registration order
+	tests    []Test
+	registry map[string]Test
+
 	// Test history
 	results []TestResult
+
+	// Held shared while a test runs and exclusively by exclusive tests and
+	// burn-in, so scheduled, manual and burn-in runs never overlap them
+	exclusive sync.RWMutex
 }
 
 // New creates a new hardware diagnostics manager
@@ -26,9 +37,39 @@ func New(cfg Config) (*Manager, error) {
 	if cfg.Retries == 0 {
 		cfg.Retries = 3
 	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = defaultRetryDelay
+	}
+	if cfg.Parallelism == 0 {
+		cfg.Parallelism = defaultParallelism
+	}
+	if cfg.MaxResults == 0 {
+		cfg.MaxResults = defaultMaxResults
+	}
 	if cfg.LoadTestTime == 0 {
 		cfg.LoadTestTime = 30 * time.Second
 	}
+	if cfg.MaxVoltageSag == 0 {
+		cfg.MaxVoltageSag = defaultMaxVoltageSag
+	}
+	if cfg.MaxRipple == 0 {
+		cfg.MaxRipple = defaultMaxRipple
+	}
+	if cfg.MaxRecoveryTime == 0 {
+		cfg.MaxRecoveryTime = defaultMaxRecoveryTime
+	}
+	if len(cfg.FanSweep) == 0 {
+		cfg.FanSweep = defaultFanSweep
+	}
+	if cfg.FanSettleTime == 0 {
+		cfg.FanSettleTime = defaultFanSettleTime
+	}
+	if cfg.FanStallRPM == 0 {
+		cfg.FanStallRPM = defaultFanStallRPM
+	}
+	if cfg.FanDegradedRatio == 0 {
+		cfg.FanDegradedRatio = defaultFanDegradedRatio
+	}
 	if cfg.MinVoltage == 0 {
 		cfg.MinVoltage = 4.8 // 4.8V minimum for 5V system
 	}
@@ -36,50 +77,53 @@ func New(cfg Config) (*Manager, error) {
 		cfg.TempRange = [2]float64{-10, 50} // -10°C to 50°C
 	}
 
-	return &Manager{
-		cfg: cfg,
-	}, nil
+	m := &Manager{
+		cfg:      cfg,
+		registry: make(map[string]Test),
+	}
+	m.registerBuiltins()
+
+	return m, nil
 }
 
-// TestGPIO performs GPIO pin diagnostics
+// TestGPIO performs GPIO pin diagnostics. Configured pins are only read, so
+// attached loads are never energized; loopback pairs are driven through both
+// levels and every pin is restored to its prior mode and level afterwards.
 func (m *Manager) TestGPIO(ctx context.Context) error {
 	for _, pin := range m.cfg.GPIOPins {
-		// Test output mode
-		if err := m.cfg.GPIO.SetPinState(pin, true); err != nil {
-			m.recordResult(TestResult{
-				Type:        TestGPIO,
-				Component:   pin,
-				Status:      StatusFail,
-				Description: "Failed to set pin HIGH",
-				Error:       err,
-				Timestamp:   time.Now(),
-			})
-			return fmt.Errorf("failed to set pin %s HIGH: %w", pin, err)
-		}
-
-		// Verify state
 		state, err := m.cfg.GPIO.GetPinState(pin)
-		if err != nil || !state {
-			m.recordResult(TestResult{
+		if err != nil {
+			m.recordResult(ctx, TestResult{
 				Type:        TestGPIO,
 				Component:   pin,
 				Status:      StatusFail,
-				Description: "Pin readback mismatch",
+				Description: "Failed to read pin",
 				Error:       err,
 				Timestamp:   time.Now(),
 			})
-			return fmt.Errorf("pin %s state mismatch", pin)
+			return fmt.Errorf("failed to read pin %s: %w", pin, err)
 		}
 
-		m.recordResult(TestResult{
+		reading := 0.0
+		if state {
+			reading = 1
+		}
+		m.recordResult(ctx, TestResult{
 			Type:        TestGPIO,
 			Component:   pin,
 			Status:      StatusPass,
-			Description: "GPIO pin functional",
+			Reading:     reading,
+			Description: "GPIO pin readable",
 			Timestamp:   time.Now(),
 		})
 	}
 
+	if len(m.cfg.GPIOLoopbacks) > 0 {
+		if err := m.testLoopbacks(ctx); err != nil {
+			return fmt.Errorf("GPIO loopback failed: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -89,25 +133,38 @@ func (m *Manager) TestPower(ctx context.Context) error {
 		return fmt.Errorf("power manager not configured")
 	}
 
-	// Test power stability
-	state := m.cfg.Power.GetState()
-	if state.Voltage < m.cfg.MinVoltage {
-		m.recordResult(TestResult{
+	// Measure the supply directly; the power state carries no voltage
+	sample, err := m.cfg.Power.Sample()
+	if err != nil {
+		m.recordResult(ctx, TestResult{
+			Type:        TestPower,
+			Component:   "voltage",
+			Status:      StatusFail,
+			Expected:    m.cfg.MinVoltage,
+			Description: "Failed to sample supply voltage",
+			Error:       err,
+			Timestamp:   time.Now(),
+		})
+		return fmt.Errorf("failed to sample supply voltage: %w", err)
+	}
+	if sample.Voltage < m.cfg.MinVoltage {
+		m.recordResult(ctx, TestResult{
 			Type:        TestPower,
 			Component:   "voltage",
 			Status:      StatusFail,
-			Reading:     state.Voltage,
+			Reading:     sample.Voltage,
 			Expected:    m.cfg.MinVoltage,
 			Description: "Voltage below minimum",
 			Timestamp:   time.Now(),
 		})
-		return fmt.Errorf("voltage %v below minimum %v", state.Voltage, m.cfg.MinVoltage)
+		return fmt.Errorf("voltage %v below minimum %v", sample.Voltage, m.cfg.MinVoltage)
 	}
 
-	m.recordResult(TestResult{
+	m.recordResult(ctx, TestResult{
 		Type:        TestPower,
 		Component:   "power_system",
 		Status:      StatusPass,
+		Reading:     sample.Voltage,
 		Description: "Power system functional",
 		Timestamp:   time.Now(),
 	})
@@ -125,7 +182,7 @@ func (m *Manager) TestThermal(ctx context.Context) error {
 
 	// Verify temperature readings
 	if state.CPUTemp < m.cfg.TempRange[0] || state.CPUTemp > m.cfg.TempRange[1] {
-		m.recordResult(TestResult{
+		m.recordResult(ctx, TestResult{
 			Type:        TestThermal,
 			Component:   "cpu_temp",
 			Status:      StatusFail,
@@ -138,7 +195,7 @@ func (m *Manager) TestThermal(ctx context.Context) error {
 	}
 
 	if state.GPUTemp < m.cfg.TempRange[0] || state.GPUTemp > m.cfg.TempRange[1] {
-		m.recordResult(TestResult{
+		m.recordResult(ctx, TestResult{
 			Type:        TestThermal,
 			Component:   "gpu_temp",
 			Status:      StatusFail,
@@ -150,20 +207,12 @@ func (m *Manager) TestThermal(ctx context.Context) error {
 			m.cfg.TempRange[0], m.cfg.TempRange[1])
 	}
 
-	// Set fan to low speed for test
-	if err := m.cfg.Thermal.SetFanSpeed(25); err != nil {
-		m.recordResult(TestResult{
-			Type:        TestThermal,
-			Component:   "fan",
-			Status:      StatusFail,
-			Description: "Failed to control fan speed",
-			Error:       err,
-			Timestamp:   time.Now(),
-		})
-		return fmt.Errorf("failed to control fan: %w", err)
+	// Characterize the fan, restoring automatic cooling afterwards
+	if err := m.TestFanResponse(ctx); err != nil {
+		return fmt.Errorf("fan response test failed: %w", err)
 	}
 
-	m.recordResult(TestResult{
+	m.recordResult(ctx, TestResult{
 		Type:        TestThermal,
 		Component:   "thermal_system",
 		Status:      StatusPass,
@@ -184,7 +233,7 @@ func (m *Manager) TestSecurity(ctx context.Context) error {
 
 	// Verify security sensors respond
 	if state.CaseOpen {
-		m.recordResult(TestResult{
+		m.recordResult(ctx, TestResult{
 			Type:        TestSecurity,
 			Component:   "case_sensor",
 			Status:      StatusWarning,
@@ -194,7 +243,7 @@ func (m *Manager) TestSecurity(ctx context.Context) error {
 	}
 
 	if state.MotionDetected {
-		m.recordResult(TestResult{
+		m.recordResult(ctx, TestResult{
 			Type:        TestSecurity,
 			Component:   "motion_sensor",
 			Status:      StatusWarning,
@@ -204,7 +253,7 @@ func (m *Manager) TestSecurity(ctx context.Context) error {
 	}
 
 	if !state.VoltageNormal {
-		m.recordResult(TestResult{
+		m.recordResult(ctx, TestResult{
 			Type:        TestSecurity,
 			Component:   "voltage_monitor",
 			Status:      StatusFail,
@@ -214,7 +263,7 @@ func (m *Manager) TestSecurity(ctx context.Context) error {
 		return fmt.Errorf("security voltage monitor shows abnormal state")
 	}
 
-	m.recordResult(TestResult{
+	m.recordResult(ctx, TestResult{
 		Type:        TestSecurity,
 		Component:   "security_system",
 		Status:      StatusPass,
@@ -226,35 +275,11 @@ func (m *Manager) TestSecurity(ctx context.Context) error {
 }
 
 // RunAll performs a complete hardware diagnostic suite
-func (m *Manager) RunAll(ctx context.Context) error {
-	tests := []struct {
-		name string
-		fn   func(context.Context) error
-	}{
-		{"GPIO", m.TestGPIO},
-		{"Power", m.TestPower},
-		{"Thermal", m.TestThermal},
-		{"Security", m.TestSecurity},
-	}
-
-	for _, test := range tests {
-		for retry := 0; retry < m.cfg.Retries; retry++ {
-			err := test.fn(ctx)
-			if err == nil {
-				break
-			}
-			if retry == m.cfg.Retries-1 {
-				return fmt.Errorf("%s tests failed after %d retries: %w",
-					test.name, m.cfg.Retries, err)
-			}
-			time.Sleep(time.Second) // Wait between retries
-		}
-	}
-
-	return nil
+func (m *Manager) RunAll(ctx context.Context) (*Report, error) {
+	return m.Run(ctx, Selection{})
 }
 
-// GetResults returns all test results
+// GetResults returns the most recent test results
 func (m *Manager) GetResults() []TestResult {
 	m.mux.RLock()
 	defer m.mux.RUnlock()
@@ -264,10 +289,21 @@ func (m *Manager) GetResults() []TestResult {
 	return results
 }
 
-// recordResult stores a test result and notifies callback if configured
-func (m *Manager) recordResult(result TestResult) {
+// recordResult stores a test result and notifies callback if configured.
+// Results are attributed to the test running in ctx.
+func (m *Manager) recordResult(ctx context.Context, result TestResult) {
+	if run, ok := ctx.Value(testRunKey{}).(*testRun); ok {
+		if result.Test == "" {
+			result.Test = run.name
+		}
+		run.add(result)
+	}
+
 	m.mux.Lock()
 	m.results = append(m.results, result)
+	if excess := len(m.results) - m.cfg.MaxResults; excess > 0 {
+		m.results = append(m.results[:0], m.results[excess:]...)
+	}
 	m.mux.Unlock()
 
 	if m.cfg.OnTestComplete != nil {
@@ -2,17 +2,100 @@ package diag
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+	"github.com/wrale/wrale-fleet-metal-hw/thermal"
 )
 
 func NewMockGPIO() (*gpio.Controller, error) {
 	return gpio.New(gpio.WithSimulation())
 }
 
+// newTestManager creates a diagnostic manager, on a simulated GPIO controller unless cfg sets one
+func newTestManager(t *testing.T, cfg Config) *Manager {
+	t.Helper()
+	if cfg.GPIO == nil {
+		gpioCtrl, err := NewMockGPIO()
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		cfg.GPIO = gpioCtrl
+	}
+	mgr, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create diagnostic manager: %v", err)
+	}
+	return mgr
+}
+
+// writeTestFile writes a sysfs-style fixture
+func writeTestFile(t *testing.T, path, value string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(value), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// newTestPower creates a power manager reading simulated ADCs; the current
+// ADC is omitted when milliamps is empty. It returns the voltage ADC path.
+func newTestPower(t *testing.T, gpioCtrl *gpio.Controller, millivolts, milliamps string) (*power.Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := power.Config{GPIO: gpioCtrl, VoltageADCPath: filepath.Join(dir, "in0_input")}
+	writeTestFile(t, cfg.VoltageADCPath, millivolts)
+	if milliamps != "" {
+		cfg.CurrentADCPath = filepath.Join(dir, "curr1_input")
+		writeTestFile(t, cfg.CurrentADCPath, milliamps)
+	}
+	powerMgr, err := power.New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create power manager: %v", err)
+	}
+	return powerMgr, cfg.VoltageADCPath
+}
+
+// fakeTach reports a speed derived from the commanded fan duty
+type fakeTach struct {
+	monitor    *thermal.Monitor
+	rpmPerDuty float64
+}
+
+func (f *fakeTach) ReadRPM() (float64, error) {
+	return float64(f.monitor.GetState().FanSpeed) * f.rpmPerDuty, nil
+}
+
+// newTestThermal creates a thermal monitor driving a simulated fan, with a
+// tachometer at rpmPerDuty unless noTach is set
+func newTestThermal(t *testing.T, gpioCtrl *gpio.Controller, rpmPerDuty float64, noTach bool) *thermal.Monitor {
+	t.Helper()
+	tach := &fakeTach{rpmPerDuty: rpmPerDuty}
+	cfg := thermal.Config{GPIO: gpioCtrl, FanControlPin: "fan"}
+	if !noTach {
+		cfg.FanTach = tach
+	}
+	monitor, err := thermal.New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create thermal monitor: %v", err)
+	}
+	tach.monitor = monitor
+	return monitor
+}
+
+// resultsByComponent indexes results by component, later results winning
+func resultsByComponent(results []TestResult) map[string]TestResult {
+	byComponent := make(map[string]TestResult)
+	for _, r := range results {
+		byComponent[r.Component] = r
+	}
+	return byComponent
+}
+
 func TestDiagnostics(t *testing.T) {
 	// Create GPIO controller for tests in simulation mode
 	gpioCtrl, err := NewMockGPIO()
@@ -75,7 +158,7 @@ func TestDiagnostics(t *testing.T) {
 
 	// Test complete diagnostic suite
 	t.Run("Full Diagnostic Suite", func(t *testing.T) {
-		if err := mgr.RunAll(context.Background()); err != nil {
+		if _, err := mgr.RunAll(context.Background()); err != nil {
 			// Expect this to fail since we're in simulation mode
 			t.Skip("Skipping full test suite on simulation")
 		}
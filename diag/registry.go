@@ -0,0 +1,199 @@
+package diag
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// funcTest adapts a function to the Test interface
+type funcTest struct {
+	info TestInfo
+	fn   func(ctx context.Context, report Reporter) error
+}
+
+// NewTest creates a Test from metadata and a run function
+func NewTest(info TestInfo, fn func(ctx context.Context, report Reporter) error) Test {
+	return &funcTest{info: info, fn: fn}
+}
+
+func (t *funcTest) Info() TestInfo {
+	return t.info
+}
+
+func (t *funcTest) Run(ctx context.Context, report Reporter) error {
+	return t.fn(ctx, report)
+}
+
+// builtinTest wraps one of the manager's own diagnostics
+func builtinTest(info TestInfo, fn func(context.Context) error) Test {
+	return NewTest(info, func(ctx context.Context, _ Reporter) error {
+		return fn(ctx)
+	})
+}
+
+// registerBuiltins registers the standard hardware diagnostics
+func (m *Manager) registerBuiltins() {
+	builtins := []Test{
+		builtinTest(TestInfo{
+			Name:        "gpio",
+			Description: "GPIO pin reads and loopback pairs",
+			Category:    TestGPIO,
+			Tags:        []string{"gpio", "quick"},
+			Requires:    []Subsystem{SubsystemGPIO},
+		}, m.TestGPIO),
+		builtinTest(TestInfo{
+			Name:        "power",
+			Description: "Supply voltage check",
+			Category:    TestPower,
+			Tags:        []string{"power", "quick"},
+			Requires:    []Subsystem{SubsystemPower},
+		}, m.TestPower),
+		builtinTest(TestInfo{
+			Name:        "power_load",
+			Description: "Supply sag, ripple and recovery under load",
+			Category:    TestPower,
+			Tags:        []string{"power", "load", "slow"},
+			DependsOn:   []string{"power"},
+			Exclusive:   true,
+			Destructive: true,
+			Requires:    []Subsystem{SubsystemPower},
+		}, m.TestPowerLoad),
+		builtinTest(TestInfo{
+			Name:        "thermal",
+			Description: "Temperature ranges and fan response",
+			Category:    TestThermal,
+			Tags:        []string{"thermal", "fan"},
+			Exclusive:   true,
+			Requires:    []Subsystem{SubsystemThermal},
+		}, m.TestThermal),
+		builtinTest(TestInfo{
+			Name:        "security",
+			Description: "Tamper sensor state",
+			Category:    TestSecurity,
+			Tags:        []string{"security", "quick"},
+			Requires:    []Subsystem{SubsystemSecurity},
+		}, m.TestSecurity),
+	}
+	for _, test := range builtins {
+		m.tests = append(m.tests, test)
+		m.registry[test.Info().Name] = test
+	}
+}
+
+// Register adds a custom diagnostic test
+func (m *Manager) Register(test Test) error {
+	info := test.Info()
+	if info.Name == "" {
+		return fmt.Errorf("test name required")
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, exists := m.registry[info.Name]; exists {
+		return fmt.Errorf("test %s already registered", info.Name)
+	}
+	m.tests = append(m.tests, test)
+	m.registry[info.Name] = test
+	return nil
+}
+
+// Tests returns the metadata of all registered tests in registration order
+func (m *Manager) Tests() []TestInfo {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	infos := make([]TestInfo, 0, len(m.tests))
+	for _, test := range m.tests {
+		infos = append(infos, test.Info())
+	}
+	return infos
+}
+
+// matches reports whether a test is chosen by the selection, ignoring
+// dependencies
+func (s Selection) matches(info TestInfo) bool {
+	named := slices.Contains(s.Names, info.Name)
+	if len(s.Names) > 0 && !named {
+		return false
+	}
+	if len(s.Tags) > 0 && !slices.ContainsFunc(info.Tags, func(tag string) bool {
+		return slices.Contains(s.Tags, tag)
+	}) {
+		return false
+	}
+	if len(s.Categories) > 0 && !slices.Contains(s.Categories, info.Category) {
+		return false
+	}
+	return named || !info.Destructive || s.IncludeDestructive
+}
+
+// Resolve returns the tests chosen by a selection, with their dependencies,
+// ordered so that every test runs after the tests it depends on
+func (m *Manager) Resolve(sel Selection) ([]Test, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	for _, name := range sel.Names {
+		if _, ok := m.registry[name]; !ok {
+			return nil, fmt.Errorf("unknown test %s", name)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	marks := make(map[string]int)
+	var ordered []Test
+	var visit func(test Test) error
+	visit = func(test Test) error {
+		info := test.Info()
+		switch marks[info.Name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle at test %s", info.Name)
+		case done:
+			return nil
+		}
+		marks[info.Name] = visiting
+		for _, dep := range info.DependsOn {
+			depTest, ok := m.registry[dep]
+			if !ok {
+				return fmt.Errorf("test %s depends on unknown test %s", info.Name, dep)
+			}
+			if err := visit(depTest); err != nil {
+				return err
+			}
+		}
+		marks[info.Name] = done
+		ordered = append(ordered, test)
+		return nil
+	}
+
+	for _, test := range m.tests {
+		if !sel.matches(test.Info()) {
+			continue
+		}
+		if err := visit(test); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// hasSubsystem reports whether a subsystem is configured
+func (m *Manager) hasSubsystem(s Subsystem) bool {
+	switch s {
+	case SubsystemGPIO:
+		return m.cfg.GPIO != nil
+	case SubsystemPower:
+		return m.cfg.Power != nil
+	case SubsystemThermal:
+		return m.cfg.Thermal != nil
+	case SubsystemSecurity:
+		return m.cfg.Security != nil
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	gpioCtrl, err := NewMockGPIO()
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	// ran records the order custom tests execute in
+	var ran []string
+	custom := func(info TestInfo) Test {
+		return NewTest(info, func(ctx context.Context, report Reporter) error {
+			ran = append(ran, info.Name)
+			report(TestResult{Component: info.Name, Status: StatusPass})
+			return nil
+		})
+	}
+
+	t.Run("Register", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 1})
+		if err := mgr.Register(custom(TestInfo{Name: "modem"})); err != nil {
+			t.Fatalf("Failed to register test: %v", err)
+		}
+		if err := mgr.Register(custom(TestInfo{Name: "modem"})); err == nil {
+			t.Error("Expected error registering duplicate test")
+		}
+		if err := mgr.Register(custom(TestInfo{})); err == nil {
+			t.Error("Expected error registering unnamed test")
+		}
+
+		infos := mgr.Tests()
+		if len(infos) != 6 || infos[5].Name != "modem" {
+			t.Errorf("Expected built-in tests followed by modem, got %d tests", len(infos))
+		}
+	})
+
+	t.Run("Tag Selection", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 1})
+		mgr.Register(custom(TestInfo{Name: "ssd_smart", Category: "STORAGE", Tags: []string{"storage"}}))
+		mgr.Register(custom(TestInfo{Name: "camera", Category: "CAMERA", Tags: []string{"camera", "quick"}}))
+		mgr.Register(custom(TestInfo{Name: "ssd_wipe", Tags: []string{"storage"}, Destructive: true}))
+
+		tests, err := mgr.Resolve(Selection{Tags: []string{"storage"}})
+		if err != nil {
+			t.Fatalf("Failed to resolve selection: %v", err)
+		}
+		if len(tests) != 1 || tests[0].Info().Name != "ssd_smart" {
+			t.Errorf("Expected only non-destructive storage test, got %d tests", len(tests))
+		}
+
+		tests, _ = mgr.Resolve(Selection{Tags: []string{"storage"}, IncludeDestructive: true})
+		if len(tests) != 2 {
+			t.Errorf("Expected destructive test when included, got %d tests", len(tests))
+		}
+
+		tests, _ = mgr.Resolve(Selection{Tags: []string{"quick"}, Categories: []TestType{"CAMERA"}})
+		if len(tests) != 1 || tests[0].Info().Name != "camera" {
+			t.Errorf("Expected camera test, got %d tests", len(tests))
+		}
+
+		// The load test browns out the supply and must be opted into
+		tests, _ = mgr.Resolve(Selection{Tags: []string{"load"}})
+		if len(tests) != 0 {
+			t.Errorf("Expected power_load excluded by default, got %d tests", len(tests))
+		}
+		tests, _ = mgr.Resolve(Selection{Names: []string{"power_load"}})
+		if len(tests) != 2 || tests[1].Info().Name != "power_load" {
+			t.Errorf("Expected power_load when named, got %d tests", len(tests))
+		}
+
+		if _, err := mgr.Resolve(Selection{Names: []string{"missing"}}); err == nil {
+			t.Error("Expected error selecting unknown test")
+		}
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 1})
+		ran = nil
+		mgr.Register(custom(TestInfo{Name: "camera_capture", DependsOn: []string{"camera_power"}}))
+		mgr.Register(custom(TestInfo{Name: "camera_power"}))
+
+		if _, err := mgr.Run(ctx, Selection{Names: []string{"camera_capture"}}); err != nil {
+			t.Fatalf("Failed to run tests: %v", err)
+		}
+		if len(ran) != 2 || ran[0] != "camera_power" || ran[1] != "camera_capture" {
+			t.Errorf("Expected dependency to run first, got %v", ran)
+		}
+		for _, r := range mgr.GetResults() {
+			if r.Test != r.Component {
+				t.Errorf("Result %s attributed to %q", r.Component, r.Test)
+			}
+		}
+
+		mgr.Register(custom(TestInfo{Name: "loop_a", DependsOn: []string{"loop_b"}}))
+		mgr.Register(custom(TestInfo{Name: "loop_b", DependsOn: []string{"loop_a"}}))
+		if _, err := mgr.Resolve(Selection{Names: []string{"loop_a"}}); err == nil {
+			t.Error("Expected error for dependency cycle")
+		}
+	})
+
+	t.Run("Required Subsystems", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 1})
+		if _, err := mgr.Run(ctx, Selection{Tags: []string{"power"}, IncludeDestructive: true}); err != nil {
+			t.Fatalf("Failed to run tests: %v", err)
+		}
+		results := mgr.GetResults()
+		if len(results) != 2 {
+			t.Fatalf("Expected two skipped power tests, got %d results", len(results))
+		}
+		for _, r := range results {
+			if r.Status != StatusSkipped {
+				t.Errorf("Expected %s skipped, got %s", r.Test, r.Status)
+			}
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 1})
+		mgr.Register(NewTest(TestInfo{Name: "hang", Timeout: 10 * time.Millisecond},
+			func(ctx context.Context, report Reporter) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}))
+
+		_, err := mgr.Run(ctx, Selection{Names: []string{"hang"}})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+
+		// A test that never checks ctx is abandoned at the deadline
+		release := make(chan struct{})
+		defer close(release)
+		mgr.Register(NewTest(TestInfo{Name: "stuck", Timeout: 10 * time.Millisecond},
+			func(context.Context, Reporter) error {
+				<-release
+				return nil
+			}))
+
+		start := time.Now()
+		report, err := mgr.Run(ctx, Selection{Names: []string{"stuck"}})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Run blocked on a test ignoring ctx for %s", elapsed)
+		}
+		if len(report.Tests) != 1 || report.Tests[0].Status != StatusFail {
+			t.Errorf("Expected stuck test to fail, got %+v", report.Tests)
+		}
+	})
+}
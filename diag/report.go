@@ -0,0 +1,380 @@
+package diag
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ReportSchemaVersion identifies the JSON report layout. It changes only
+// when fields are removed or change meaning.
+const ReportSchemaVersion = 1
+
+// jsonResult is the JSON form of a TestResult
+type jsonResult struct {
+	Test        string     `json:"test,omitempty"`
+	Type        TestType   `json:"type"`
+	Component   string     `json:"component"`
+	Status      TestStatus `json:"status"`
+	Reading     float64    `json:"reading"`
+	Expected    float64    `json:"expected"`
+	Description string     `json:"description"`
+	Error       string     `json:"error,omitempty"`
+	Timestamp   time.Time  `json:"timestamp"`
+}
+
+// jsonTest is the JSON form of a TestReport, without its results
+type jsonTest struct {
+	Name       string     `json:"name"`
+	Category   TestType   `json:"category"`
+	Status     TestStatus `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Start      time.Time  `json:"start"`
+	DurationMS float64    `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// jsonSummary is the JSON form of a Summary
+type jsonSummary struct {
+	Total    int `json:"total"`
+	Passed   int `json:"passed"`
+	Failed   int `json:"failed"`
+	Warnings int `json:"warnings"`
+	Skipped  int `json:"skipped"`
+}
+
+// jsonReport is the JSON form of a Report, without its tests
+type jsonReport struct {
+	Schema     int         `json:"schema"`
+	Start      time.Time   `json:"start"`
+	DurationMS float64     `json:"duration_ms"`
+	Verdict    TestStatus  `json:"verdict"`
+	Summary    jsonSummary `json:"summary"`
+}
+
+// errorString returns the message of a possibly nil error
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// stringError restores a serialized error message
+func stringError(msg string) error {
+	if msg == "" {
+		return nil
+	}
+	return errors.New(msg)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func fromMilliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// MarshalJSON encodes the result with its error as a string
+func (r TestResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonResult{
+		Test:        r.Test,
+		Type:        r.Type,
+		Component:   r.Component,
+		Status:      r.Status,
+		Reading:     r.Reading,
+		Expected:    r.Expected,
+		Description: r.Description,
+		Error:       errorString(r.Error),
+		Timestamp:   r.Timestamp,
+	})
+}
+
+// UnmarshalJSON decodes a result, restoring its error message
+func (r *TestResult) UnmarshalJSON(data []byte) error {
+	var j jsonResult
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*r = TestResult{
+		Test:        j.Test,
+		Type:        j.Type,
+		Component:   j.Component,
+		Status:      j.Status,
+		Reading:     j.Reading,
+		Expected:    j.Expected,
+		Description: j.Description,
+		Error:       stringError(j.Error),
+		Timestamp:   j.Timestamp,
+	}
+	return nil
+}
+
+// MarshalJSON encodes the test with its error as a string and its duration
+// in milliseconds
+func (t TestReport) MarshalJSON() ([]byte, error) {
+	results := t.Results
+	if results == nil {
+		results = []TestResult{}
+	}
+	return json.Marshal(struct {
+		jsonTest
+		Results []TestResult `json:"results"`
+	}{
+		jsonTest: jsonTest{
+			Name:       t.Name,
+			Category:   t.Category,
+			Status:     t.Status,
+			Attempts:   t.Attempts,
+			Start:      t.Start,
+			DurationMS: milliseconds(t.Duration),
+			Error:      errorString(t.Error),
+		},
+		Results: results,
+	})
+}
+
+// UnmarshalJSON decodes a test report
+func (t *TestReport) UnmarshalJSON(data []byte) error {
+	var j struct {
+		jsonTest
+		Results []TestResult `json:"results"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*t = TestReport{
+		Name:     j.Name,
+		Category: j.Category,
+		Status:   j.Status,
+		Attempts: j.Attempts,
+		Start:    j.Start,
+		Duration: fromMilliseconds(j.DurationMS),
+		Error:    stringError(j.Error),
+		Results:  j.Results,
+	}
+	return nil
+}
+
+// MarshalJSON encodes the report in the versioned JSON schema
+func (r Report) MarshalJSON() ([]byte, error) {
+	tests := r.Tests
+	if tests == nil {
+		tests = []TestReport{}
+	}
+	return json.Marshal(struct {
+		jsonReport
+		Tests []TestReport `json:"tests"`
+	}{
+		jsonReport: jsonReport{
+			Schema:     ReportSchemaVersion,
+			Start:      r.Start,
+			DurationMS: milliseconds(r.Duration),
+			Verdict:    r.Verdict,
+			Summary:    jsonSummary(r.Summary),
+		},
+		Tests: tests,
+	})
+}
+
+// UnmarshalJSON decodes a report, rejecting newer schema versions
+func (r *Report) UnmarshalJSON(data []byte) error {
+	var j struct {
+		jsonReport
+		Tests []TestReport `json:"tests"`
+	}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Schema > ReportSchemaVersion {
+		return fmt.Errorf("unsupported report schema %d", j.Schema)
+	}
+	*r = Report{
+		Start:    j.Start,
+		Duration: fromMilliseconds(j.DurationMS),
+		Verdict:  j.Verdict,
+		Summary:  Summary(j.Summary),
+		Tests:    j.Tests,
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	return nil
+}
+
+// ReadReport decodes a report written by WriteJSON
+func ReadReport(rd io.Reader) (*Report, error) {
+	var r Report
+	if err := json.NewDecoder(rd).Decode(&r); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %w", err)
+	}
+	return &r, nil
+}
+
+// JUnit XML elements
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// resultLine formats one measurement for JUnit output
+func resultLine(r TestResult) string {
+	line := fmt.Sprintf("%s %s %s", r.Status, r.Component, r.Description)
+	if r.Reading != 0 || r.Expected != 0 {
+		line += fmt.Sprintf(" (reading %g, expected %g)", r.Reading, r.Expected)
+	}
+	if r.Error != nil {
+		line += ": " + r.Error.Error()
+	}
+	return line
+}
+
+// WriteJUnit writes the report as JUnit XML with one test suite per
+// category, so hardware rigs can publish results through standard CI tooling
+func (r *Report) WriteJUnit(w io.Writer) error {
+	doc := junitSuites{
+		Name:     "hardware-diagnostics",
+		Tests:    r.Summary.Total,
+		Failures: r.Summary.Failed,
+		Skipped:  r.Summary.Skipped,
+		Time:     junitSeconds(r.Duration),
+	}
+
+	suites := make(map[TestType]int)
+	durations := make(map[TestType]time.Duration)
+	for _, t := range r.Tests {
+		i, ok := suites[t.Category]
+		if !ok {
+			i = len(doc.Suites)
+			suites[t.Category] = i
+			doc.Suites = append(doc.Suites, junitSuite{
+				Name:      string(t.Category),
+				Timestamp: t.Start.UTC().Format(time.RFC3339),
+			})
+		}
+		suite := &doc.Suites[i]
+
+		lines := make([]string, 0, len(t.Results))
+		for _, res := range t.Results {
+			lines = append(lines, resultLine(res))
+		}
+		tc := junitCase{
+			Name:      t.Name,
+			Classname: "diag." + string(t.Category),
+			Time:      junitSeconds(t.Duration),
+			SystemOut: strings.Join(lines, "\n"),
+		}
+		switch t.Status {
+		case StatusFail:
+			msg := "test failed"
+			if t.Error != nil {
+				msg = t.Error.Error()
+			}
+			tc.Failure = &junitMessage{Message: msg, Body: tc.SystemOut}
+			suite.Failures++
+		case StatusSkipped:
+			msg := "test skipped"
+			if len(t.Results) > 0 {
+				msg = t.Results[0].Description
+			}
+			tc.Skipped = &junitMessage{Message: msg}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		durations[t.Category] += t.Duration
+		suite.Time = junitSeconds(durations[t.Category])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+// WriteText writes the report as aligned tables for technicians: a summary
+// line, one row per test, then every recorded measurement
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Diagnostics %s: %d tests, %d passed, %d failed, %d warnings, %d skipped in %s\n\n",
+		r.Verdict, r.Summary.Total, r.Summary.Passed, r.Summary.Failed,
+		r.Summary.Warnings, r.Summary.Skipped, r.Duration.Round(time.Millisecond))
+
+	fmt.Fprintln(tw, "TEST\tCATEGORY\tSTATUS\tATTEMPTS\tDURATION\tERROR")
+	for _, t := range r.Tests {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", t.Name, t.Category, t.Status,
+			t.Attempts, t.Duration.Round(time.Millisecond), errorString(t.Error))
+	}
+
+	fmt.Fprintln(tw, "\nTEST\tCOMPONENT\tSTATUS\tREADING\tEXPECTED\tDESCRIPTION")
+	for _, t := range r.Tests {
+		for _, res := range t.Results {
+			description := res.Description
+			if res.Error != nil {
+				description += ": " + res.Error.Error()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%g\t%g\t%s\n", t.Name, res.Component, res.Status,
+				res.Reading, res.Expected, description)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write text report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportEncoding(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	report := &Report{
+		Start:    start,
+		Duration: 1500 * time.Millisecond,
+		Verdict:  StatusFail,
+		Summary:  Summary{Total: 3, Passed: 1, Failed: 1, Skipped: 1},
+		Tests: []TestReport{
+			{
+				Name: "gpio", Category: TestGPIO, Status: StatusPass, Attempts: 1,
+				Start: start, Duration: 20 * time.Millisecond,
+				Results: []TestResult{{
+					Test: "gpio", Type: TestGPIO, Component: "pin1", Status: StatusPass,
+					Reading: 1, Description: "GPIO pin readable", Timestamp: start,
+				}},
+			},
+			{
+				Name: "power_load", Category: TestPower, Status: StatusFail, Attempts: 3,
+				Start: start, Duration: 1200 * time.Millisecond,
+				Error: errors.New("voltage sag 0.4V exceeds 0.25V"),
+				Results: []TestResult{{
+					Test: "power_load", Type: TestPower, Component: "load_sag", Status: StatusFail,
+					Reading: 0.4, Expected: 0.25, Description: "Voltage sag <under load>",
+					Error: errors.New("sag too large"), Timestamp: start,
+				}},
+			},
+			{
+				Name: "security", Category: TestSecurity, Status: StatusSkipped, Start: start,
+				Results: []TestResult{{
+					Test: "security", Type: TestSecurity, Component: "security", Status: StatusSkipped,
+					Description: "SECURITY subsystem not configured", Timestamp: start,
+				}},
+			},
+		},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteJSON(&buf); err != nil {
+			t.Fatalf("Failed to write JSON: %v", err)
+		}
+
+		var raw map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+			t.Fatalf("Failed to parse JSON: %v", err)
+		}
+		if raw["schema"] != float64(ReportSchemaVersion) || raw["duration_ms"] != 1500.0 {
+			t.Errorf("Unexpected report header: %v", raw)
+		}
+		if !strings.Contains(buf.String(), `"error": "sag too large"`) {
+			t.Error("Result error not encoded as a string")
+		}
+
+		decoded, err := ReadReport(&buf)
+		if err != nil {
+			t.Fatalf("Failed to read report: %v", err)
+		}
+		if decoded.Summary != report.Summary || len(decoded.Tests) != 3 {
+			t.Fatalf("Report not round tripped: %+v", decoded.Summary)
+		}
+		failed := decoded.Tests[1]
+		if failed.Duration != 1200*time.Millisecond || failed.Error.Error() != report.Tests[1].Error.Error() {
+			t.Errorf("Test not round tripped: %v, %v", failed.Duration, failed.Error)
+		}
+		if res := failed.Results[0]; res.Error == nil || res.Reading != 0.4 || !res.Timestamp.Equal(start) {
+			t.Errorf("Result not round tripped: %+v", res)
+		}
+
+		if _, err := ReadReport(strings.NewReader(`{"schema": 99}`)); err == nil {
+			t.Error("Expected error for newer schema")
+		}
+	})
+
+	t.Run("JUnit", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteJUnit(&buf); err != nil {
+			t.Fatalf("Failed to write JUnit: %v", err)
+		}
+
+		var doc junitSuites
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("Failed to parse JUnit XML: %v", err)
+		}
+		if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 1 || len(doc.Suites) != 3 {
+			t.Fatalf("Unexpected suites: %+v", doc)
+		}
+		power := doc.Suites[1]
+		if power.Name != "POWER" || power.Cases[0].Failure == nil {
+			t.Fatalf("Expected failing power case, got %+v", power)
+		}
+		if msg := power.Cases[0].Failure.Message; msg != "voltage sag 0.4V exceeds 0.25V" {
+			t.Errorf("Unexpected failure message %q", msg)
+		}
+		if skipped := doc.Suites[2].Cases[0].Skipped; skipped == nil || skipped.Message != "SECURITY subsystem not configured" {
+			t.Error("Expected skipped security case")
+		}
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteText(&buf); err != nil {
+			t.Fatalf("Failed to write text: %v", err)
+		}
+		out := buf.String()
+		if !strings.HasPrefix(out, "Diagnostics FAIL: 3 tests, 1 passed, 1 failed") {
+			t.Errorf("Unexpected summary line: %q", strings.SplitN(out, "\n", 2)[0])
+		}
+		for _, want := range []string{"power_load  POWER", "load_sag", "sag too large"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Text report missing %q", want)
+			}
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// Default runner settings
+	defaultRetryDelay  = 1 * time.Second
+	defaultParallelism = 4
+)
+
+// testRunKey carries the running test so results can be attributed
+type testRunKey struct{}
+
+// testRun collects the results recorded by one test attempt
+type testRun struct {
+	name string
+
+	mux     sync.Mutex
+	results []TestResult
+}
+
+func (r *testRun) add(result TestResult) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.results = append(r.results, result)
+}
+
+func (r *testRun) reset() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.results = nil
+}
+
+func (r *testRun) collected() []TestResult {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	results := make([]TestResult, len(r.results))
+	copy(results, r.results)
+	return results
+}
+
+// Run executes the selected tests and reports their outcomes. Independent
+// tests run concurrently up to Parallelism, exclusive tests run alone, also
+// across concurrent runs and burn-in, and each test starts once its
+// dependencies finish. Tests whose required subsystems are not configured,
+// or whose dependencies did not pass, are skipped. Failures do not stop the
+// run. Reports are recorded to History when configured; the returned error
+// joins every test failure and a failure to record the report, so a passing
+// run can still return an error when History is unavailable.
+func (m *Manager) Run(ctx context.Context, sel Selection) (*Report, error) {
+	tests, err := m.Resolve(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Start: time.Now(),
+		Tests: make([]TestReport, len(tests)),
+	}
+	index := make(map[string]int, len(tests))
+	done := make([]chan struct{}, len(tests))
+	for i, test := range tests {
+		index[test.Info().Name] = i
+		done[i] = make(chan struct{})
+	}
+
+	sem := make(chan struct{}, m.cfg.Parallelism)
+	var wg sync.WaitGroup
+	for i, test := range tests {
+		wg.Add(1)
+		go func(i int, test Test) {
+			defer wg.Done()
+			defer close(done[i])
+
+			info := test.Info()
+			run := &testRun{name: info.Name}
+			testCtx := context.WithValue(ctx, testRunKey{}, run)
+
+			// A dependency's report is complete once its done channel closes
+			reason := m.missingSubsystem(info)
+			for _, dep := range info.DependsOn {
+				<-done[index[dep]]
+				if status := report.Tests[index[dep]].Status; reason == "" && status != StatusPass && status != StatusWarning {
+					reason = fmt.Sprintf("Dependency %s did not pass", dep)
+				}
+			}
+			if reason != "" {
+				report.Tests[i] = m.skipTest(testCtx, info, run, reason)
+				return
+			}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				report.Tests[i] = TestReport{
+					Name:     info.Name,
+					Category: info.Category,
+					Status:   StatusFail,
+					Start:    time.Now(),
+					Error:    ctx.Err(),
+				}
+				return
+			}
+			if info.Exclusive {
+				m.exclusive.Lock()
+				defer m.exclusive.Unlock()
+			} else {
+				m.exclusive.RLock()
+				defer m.exclusive.RUnlock()
+			}
+
+			report.Tests[i] = m.runTest(testCtx, test, run)
+		}(i, test)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, t := range report.Tests {
+		report.Summary.Total++
+		switch t.Status {
+		case StatusPass:
+			report.Summary.Passed++
+		case StatusWarning:
+			report.Summary.Warnings++
+		case StatusSkipped:
+			report.Summary.Skipped++
+		case StatusFail:
+			report.Summary.Failed++
+			if t.Error != nil {
+				errs = append(errs, fmt.Errorf("%s test failed: %w", t.Name, t.Error))
+			} else {
+				errs = append(errs, fmt.Errorf("%s test failed", t.Name))
+			}
+		}
+	}
+	switch {
+	case report.Summary.Failed > 0:
+		report.Verdict = StatusFail
+	case report.Summary.Warnings > 0:
+		report.Verdict = StatusWarning
+	default:
+		report.Verdict = StatusPass
+	}
+	report.Duration = time.Since(report.Start)
+
+	if m.cfg.History != nil {
+		if err := m.cfg.History.Record(ctx, report); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record history: %w", err))
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// missingSubsystem explains which required subsystem is not configured, or
+// returns empty
+func (m *Manager) missingSubsystem(info TestInfo) string {
+	for _, s := range info.Requires {
+		if !m.hasSubsystem(s) {
+			return fmt.Sprintf("%s subsystem not configured", s)
+		}
+	}
+	return ""
+}
+
+// skipTest records a test that could not run
+func (m *Manager) skipTest(ctx context.Context, info TestInfo, run *testRun, reason string) TestReport {
+	start := time.Now()
+	m.recordResult(ctx, TestResult{
+		Type:        info.Category,
+		Component:   info.Name,
+		Status:      StatusSkipped,
+		Description: reason,
+		Timestamp:   start,
+	})
+	return TestReport{
+		Name:     info.Name,
+		Category: info.Category,
+		Status:   StatusSkipped,
+		Start:    start,
+		Results:  run.collected(),
+	}
+}
+
+// runTest runs one test until it passes or its attempts are exhausted,
+// bounding each attempt by the test timeout
+func (m *Manager) runTest(ctx context.Context, test Test, run *testRun) TestReport {
+	info := test.Info()
+	tr := TestReport{
+		Name:     info.Name,
+		Category: info.Category,
+		Start:    time.Now(),
+	}
+	report := func(result TestResult) {
+		if result.Type == "" {
+			result.Type = info.Category
+		}
+		if result.Timestamp.IsZero() {
+			result.Timestamp = time.Now()
+		}
+		m.recordResult(ctx, result)
+	}
+
+	var err error
+attempts:
+	for tr.Attempts < m.cfg.Retries {
+		if tr.Attempts > 0 {
+			select {
+			case <-ctx.Done():
+				break attempts
+			case <-time.After(m.cfg.RetryDelay):
+			}
+		}
+		tr.Attempts++
+		run.reset()
+		if err = m.attempt(ctx, test, info.Timeout, report); err == nil {
+			break
+		}
+	}
+
+	tr.Duration = time.Since(tr.Start)
+	tr.Results = run.collected()
+	tr.Error = err
+	tr.Status = testStatus(err, tr.Results)
+	return tr
+}
+
+// attempt runs a test once, converting a panic in a custom test to an error.
+// A test that ignores ctx is abandoned once the timeout or ctx expires and
+// ctx.Err() is returned; it keeps running in the background until it returns
+// and results it reports meanwhile are still recorded.
+func (m *Manager) attempt(ctx context.Context, test Test, timeout time.Duration, report Reporter) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("test panicked: %v", r)
+			}
+		}()
+		done <- test.Run(ctx, report)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// testStatus derives a test's status from its error and the worst result it
+// recorded
+func testStatus(err error, results []TestResult) TestStatus {
+	if err != nil {
+		return StatusFail
+	}
+	status := StatusPass
+	for _, r := range results {
+		switch r.Status {
+		case StatusFail:
+			return StatusFail
+		case StatusWarning:
+			status = StatusWarning
+		}
+	}
+	return status
+}
@@ -0,0 +1,133 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunner(t *testing.T) {
+	gpioCtrl, err := NewMockGPIO()
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	// sleeper tracks how many tests run at once
+	var running, peak atomic.Int32
+	sleeper := func(name string, exclusive bool) Test {
+		return NewTest(TestInfo{Name: name, Tags: []string{"sleep"}, Exclusive: exclusive},
+			func(ctx context.Context, report Reporter) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				time.Sleep(50 * time.Millisecond)
+				report(TestResult{Component: name, Status: StatusPass})
+				return nil
+			})
+	}
+
+	t.Run("Parallel", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Parallelism: 2})
+		for _, name := range []string{"a", "b", "c", "d"} {
+			mgr.Register(sleeper(name, false))
+		}
+
+		peak.Store(0)
+		report, err := mgr.Run(context.Background(), Selection{Tags: []string{"sleep"}})
+		if err != nil {
+			t.Fatalf("Failed to run tests: %v", err)
+		}
+		if peak.Load() != 2 {
+			t.Errorf("Expected 2 concurrent tests, got %d", peak.Load())
+		}
+		if report.Verdict != StatusPass || report.Summary.Passed != 4 {
+			t.Errorf("Unexpected report: %s with %+v", report.Verdict, report.Summary)
+		}
+		if report.Duration >= 200*time.Millisecond {
+			t.Errorf("Tests not run concurrently, took %v", report.Duration)
+		}
+	})
+
+	t.Run("Exclusive", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl})
+		mgr.Register(sleeper("shared_a", false))
+		mgr.Register(sleeper("burn", true))
+		mgr.Register(sleeper("shared_b", false))
+
+		peak.Store(0)
+		if _, err := mgr.Run(context.Background(), Selection{Tags: []string{"sleep"}}); err != nil {
+			t.Fatalf("Failed to run tests: %v", err)
+		}
+		if peak.Load() > 2 {
+			t.Errorf("Exclusive test overlapped others, peak %d", peak.Load())
+		}
+	})
+
+	t.Run("Continue After Failure", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 2, RetryDelay: time.Millisecond})
+		errBroken := errors.New("modem not responding")
+		var attempts atomic.Int32
+		mgr.Register(NewTest(TestInfo{Name: "modem"}, func(ctx context.Context, report Reporter) error {
+			attempts.Add(1)
+			report(TestResult{Component: "modem", Status: StatusFail})
+			return errBroken
+		}))
+		mgr.Register(NewTest(TestInfo{Name: "modem_signal", DependsOn: []string{"modem"}},
+			func(ctx context.Context, report Reporter) error {
+				return nil
+			}))
+		mgr.Register(NewTest(TestInfo{Name: "camera"}, func(ctx context.Context, report Reporter) error {
+			report(TestResult{Component: "camera", Status: StatusWarning})
+			return nil
+		}))
+
+		report, err := mgr.Run(context.Background(), Selection{Names: []string{"modem", "modem_signal", "camera"}})
+		if !errors.Is(err, errBroken) {
+			t.Errorf("Expected modem failure, got %v", err)
+		}
+		if report == nil {
+			t.Fatal("Expected report after failures")
+		}
+		want := Summary{Total: 3, Failed: 1, Warnings: 1, Skipped: 1}
+		if report.Summary != want || report.Verdict != StatusFail {
+			t.Errorf("Unexpected report: %s with %+v", report.Verdict, report.Summary)
+		}
+		if attempts.Load() != 2 || report.Tests[0].Attempts != 2 {
+			t.Errorf("Expected 2 attempts, got %d", attempts.Load())
+		}
+		if len(report.Tests[0].Results) != 1 {
+			t.Errorf("Expected results from final attempt only, got %d", len(report.Tests[0].Results))
+		}
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, RetryDelay: time.Hour})
+		mgr.Register(NewTest(TestInfo{Name: "flaky"}, func(ctx context.Context, report Reporter) error {
+			return errors.New("flaky")
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		report, err := mgr.Run(ctx, Selection{Names: []string{"flaky"}})
+		if err == nil || report.Verdict != StatusFail {
+			t.Error("Expected failed run")
+		}
+		if time.Since(start) > time.Second {
+			t.Error("Retry delay ignored cancellation")
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		mgr := newTestManager(t, Config{GPIO: gpioCtrl, Retries: 1})
+		mgr.Register(NewTest(TestInfo{Name: "buggy"}, func(ctx context.Context, report Reporter) error {
+			panic("driver bug")
+		}))
+		if _, err := mgr.Run(context.Background(), Selection{Names: []string{"buggy"}}); err == nil {
+			t.Error("Expected panic reported as failure")
+		}
+	})
+}
@@ -0,0 +1,409 @@
+package diag
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+	"github.com/wrale/wrale-fleet-metal-hw/secure"
+	"github.com/wrale/wrale-fleet-metal-hw/thermal"
+)
+
+const (
+	// Default minimum time between event-triggered runs of a schedule
+	defaultMinInterval = 15 * time.Minute
+	// Pending events beyond this are dropped while a run is in progress
+	eventQueueSize = 16
+	// Furthest a cron expression is searched for its next match
+	maxCronSearch = 5 * 366 * 24 * time.Hour
+)
+
+// Event is a system condition that can trigger diagnostics
+type Event string
+
+const (
+	EventThermalWarning  Event = "THERMAL_WARNING"
+	EventThermalCritical Event = "THERMAL_CRITICAL"
+	EventPowerCritical   Event = "POWER_CRITICAL"
+	EventPowerFailover   Event = "POWER_FAILOVER"
+	EventTamper          Event = "TAMPER"
+)
+
+// TriggerType identifies what started a scheduled run
+type TriggerType string
+
+const (
+	TriggerBoot  TriggerType = "BOOT"
+	TriggerCron  TriggerType = "CRON"
+	TriggerEvent TriggerType = "EVENT"
+)
+
+// Schedule runs a selection of tests on a timetable, at boot or on events
+type Schedule struct {
+	Name        string
+	Selection   Selection
+	Cron        string        // Five field cron expression or @hourly, @daily, @weekly, @monthly
+	AtBoot      bool          // Run when the scheduler starts
+	Events      []Event       // Events that trigger a run
+	MinInterval time.Duration // Minimum time since the last run before an event may trigger another, default 15m
+}
+
+// ScheduledRun is the outcome of one scheduled run
+type ScheduledRun struct {
+	Schedule string
+	Trigger  TriggerType
+	Event    Event // Triggering event, empty unless Trigger is EVENT
+	Report   *Report
+	Err      error
+}
+
+// SchedulerConfig holds the diagnostics scheduler configuration
+type SchedulerConfig struct {
+	Schedules []Schedule
+	OnRun     func(ScheduledRun) // Called after each scheduled run
+}
+
+// scheduleState tracks one schedule between runs
+type scheduleState struct {
+	Schedule
+	cron    *cronSpec
+	lastRun time.Time
+	// Pending cron slot, kept across event runs so a slot passing during
+	// one is run afterwards rather than dropped
+	nextCron time.Time
+}
+
+// Scheduler runs diagnostics on schedules and in response to events
+type Scheduler struct {
+	mgr       *Manager
+	schedules []*scheduleState
+	onRun     func(ScheduledRun)
+	events    chan Event
+
+	mux        sync.Mutex
+	suppressed int
+}
+
+// NewScheduler creates a scheduler for a diagnostics manager
+func NewScheduler(mgr *Manager, cfg SchedulerConfig) (*Scheduler, error) {
+	if mgr == nil {
+		return nil, fmt.Errorf("diagnostics manager required")
+	}
+
+	s := &Scheduler{
+		mgr:    mgr,
+		onRun:  cfg.OnRun,
+		events: make(chan Event, eventQueueSize),
+	}
+	names := make(map[string]bool)
+	for _, sched := range cfg.Schedules {
+		if sched.Name == "" {
+			return nil, fmt.Errorf("schedule name required")
+		}
+		if names[sched.Name] {
+			return nil, fmt.Errorf("duplicate schedule %s", sched.Name)
+		}
+		names[sched.Name] = true
+
+		state := &scheduleState{Schedule: sched}
+		if state.MinInterval == 0 {
+			state.MinInterval = defaultMinInterval
+		}
+		if sched.Cron != "" {
+			spec, err := parseCron(sched.Cron)
+			if err != nil {
+				return nil, fmt.Errorf("schedule %s: %w", sched.Name, err)
+			}
+			state.cron = spec
+		}
+		if _, err := mgr.Resolve(sched.Selection); err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", sched.Name, err)
+		}
+		s.schedules = append(s.schedules, state)
+	}
+
+	return s, nil
+}
+
+// Trigger signals an event without blocking. Events are dropped if the queue
+// is full.
+func (s *Scheduler) Trigger(event Event) {
+	select {
+	case s.events <- event:
+	default:
+		s.mux.Lock()
+		s.suppressed++
+		s.mux.Unlock()
+	}
+}
+
+// OnThermalWarning triggers EventThermalWarning, for thermal.Config.OnWarning
+func (s *Scheduler) OnThermalWarning(thermal.ThermalState) {
+	s.Trigger(EventThermalWarning)
+}
+
+// OnThermalCritical triggers EventThermalCritical, for thermal.Config.OnCritical
+func (s *Scheduler) OnThermalCritical(thermal.ThermalState) {
+	s.Trigger(EventThermalCritical)
+}
+
+// OnPowerCritical triggers EventPowerCritical, for power.Config.OnPowerCritical
+func (s *Scheduler) OnPowerCritical(power.PowerState) {
+	s.Trigger(EventPowerCritical)
+}
+
+// OnStabilityEvent triggers EventPowerFailover on source failovers, for
+// power.StabilityConfig.OnStabilityEvent
+func (s *Scheduler) OnStabilityEvent(event power.StabilityEvent) {
+	if event.Type == power.EventSourceFailover {
+		s.Trigger(EventPowerFailover)
+	}
+}
+
+// OnTamper triggers EventTamper, for secure.Config.OnTamper
+func (s *Scheduler) OnTamper(secure.TamperState) {
+	s.Trigger(EventTamper)
+}
+
+// Suppressed returns how many triggers were dropped by rate limiting or a
+// full event queue
+func (s *Scheduler) Suppressed() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.suppressed
+}
+
+// Run executes boot schedules, then runs cron and event-triggered schedules
+// until ctx is cancelled. Runs execute one at a time; events arriving during a
+// run are handled afterwards, subject to each schedule's MinInterval, and
+// cron slots passing during a run are run once it finishes.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for _, state := range s.schedules {
+		if state.AtBoot {
+			s.runSchedule(ctx, state, TriggerBoot, "")
+		}
+	}
+
+	for {
+		var next time.Time
+		now := time.Now()
+		for _, state := range s.schedules {
+			if state.cron == nil {
+				continue
+			}
+			if state.nextCron.IsZero() {
+				state.nextCron = state.cron.next(now)
+			}
+			if at := state.nextCron; !at.IsZero() && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return ctx.Err()
+		case <-fire:
+			s.runDueCron(ctx)
+		case event := <-s.events:
+			if timer != nil {
+				timer.Stop()
+			}
+			s.handleEvent(ctx, event)
+			s.runDueCron(ctx)
+		}
+	}
+}
+
+// runDueCron runs cron schedules whose pending slot has passed, once each
+// however many slots were missed
+func (s *Scheduler) runDueCron(ctx context.Context) {
+	for _, state := range s.schedules {
+		if state.cron == nil || state.nextCron.IsZero() || time.Now().Before(state.nextCron) {
+			continue
+		}
+		state.nextCron = time.Time{}
+		s.runSchedule(ctx, state, TriggerCron, "")
+	}
+}
+
+// handleEvent runs schedules triggered by an event unless they ran within
+// their MinInterval
+func (s *Scheduler) handleEvent(ctx context.Context, event Event) {
+	for _, state := range s.schedules {
+		if !slices.Contains(state.Events, event) {
+			continue
+		}
+		if !state.lastRun.IsZero() && time.Since(state.lastRun) < state.MinInterval {
+			s.mux.Lock()
+			s.suppressed++
+			s.mux.Unlock()
+			continue
+		}
+		s.runSchedule(ctx, state, TriggerEvent, event)
+	}
+}
+
+// runSchedule runs a schedule's selection and reports the outcome
+func (s *Scheduler) runSchedule(ctx context.Context, state *scheduleState, trigger TriggerType, event Event) {
+	state.lastRun = time.Now()
+	report, err := s.mgr.Run(ctx, state.Selection)
+	if s.onRun != nil {
+		s.onRun(ScheduledRun{
+			Schedule: state.Name,
+			Trigger:  trigger,
+			Event:    event,
+			Report:   report,
+			Err:      err,
+		})
+	}
+}
+
+// cronSpec is a parsed cron expression, each field a bitmask of allowed values
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// Cron expression shorthands
+var cronAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// parseCron parses a standard five field cron expression: minute, hour,
+// day of month, month and day of week. Fields accept *, values, ranges,
+// lists and steps.
+func parseCron(expr string) (*cronSpec, error) {
+	if alias, ok := cronAliases[expr]; ok {
+		expr = alias
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var spec cronSpec
+	var err error
+	if spec.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute: %w", err)
+	}
+	if spec.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour: %w", err)
+	}
+	if spec.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if spec.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month: %w", err)
+	}
+	if spec.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %w", err)
+	}
+	// Sunday is both 0 and 7
+	if spec.dow&(1<<7) != 0 {
+		spec.dow |= 1
+	}
+	spec.domAny = fields[2] == "*"
+	spec.dowAny = fields[4] == "*"
+	return &spec, nil
+}
+
+// parseCronField parses one comma separated cron field into a bitmask
+func parseCronField(field string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("bad value in %q", part)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("bad range in %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q outside %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// matchesDay applies cron day semantics: when both day fields are
+// restricted, either may match
+func (c *cronSpec) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first matching minute after t, or zero if none is found
+func (c *cronSpec) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			// Skip straight to the next allowed minute within the hour
+			rest := c.minute >> uint(t.Minute())
+			if rest == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)) * time.Minute)
+			}
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,183 @@
+package diag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/power"
+	"github.com/wrale/wrale-fleet-metal-hw/thermal"
+)
+
+func TestCron(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatalf("Failed to parse time: %v", err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		expr  string
+		after string
+		want  string
+	}{
+		{"* * * * *", "2024-03-01 12:00", "2024-03-01 12:01"},
+		{"*/15 * * * *", "2024-03-01 12:07", "2024-03-01 12:15"},
+		{"30 2 * * *", "2024-03-01 12:00", "2024-03-02 02:30"},
+		{"0 9-17/4 * * *", "2024-03-01 10:00", "2024-03-01 13:00"},
+		{"0 0 1,15 * *", "2024-03-02 00:00", "2024-03-15 00:00"},
+		{"0 3 * * 0", "2024-03-01 12:00", "2024-03-03 03:00"}, // Sunday
+		{"0 3 * * 7", "2024-03-01 12:00", "2024-03-03 03:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"@monthly", "2024-12-15 00:00", "2025-01-01 00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			spec, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("Failed to parse cron: %v", err)
+			}
+			if got := spec.next(at(tt.after)); !got.Equal(at(tt.want)) {
+				t.Errorf("Expected %s, got %s", tt.want, got.Format("2006-01-02 15:04"))
+			}
+		})
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("Expected error parsing %q", expr)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	gpioCtrl, err := NewMockGPIO()
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+	mgr, err := New(Config{GPIO: gpioCtrl})
+	if err != nil {
+		t.Fatalf("Failed to create diagnostic manager: %v", err)
+	}
+
+	t.Run("Invalid Schedules", func(t *testing.T) {
+		for _, schedules := range [][]Schedule{
+			{{Name: ""}},
+			{{Name: "a"}, {Name: "a"}},
+			{{Name: "bad_cron", Cron: "every day"}},
+			{{Name: "unknown_test", Selection: Selection{Names: []string{"missing"}}}},
+		} {
+			if _, err := NewScheduler(mgr, SchedulerConfig{Schedules: schedules}); err == nil {
+				t.Errorf("Expected error for schedules %+v", schedules)
+			}
+		}
+	})
+
+	t.Run("Boot And Events", func(t *testing.T) {
+		runs := make(chan ScheduledRun, 10)
+		sched, err := NewScheduler(mgr, SchedulerConfig{
+			Schedules: []Schedule{
+				{Name: "boot", Selection: Selection{Names: []string{"gpio"}}, AtBoot: true},
+				{
+					Name:        "thermal_check",
+					Selection:   Selection{Tags: []string{"quick"}},
+					Events:      []Event{EventThermalCritical, EventPowerFailover},
+					MinInterval: time.Hour,
+				},
+			},
+			OnRun: func(run ScheduledRun) { runs <- run },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create scheduler: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error, 1)
+		go func() { done <- sched.Run(ctx) }()
+
+		next := func() ScheduledRun {
+			select {
+			case run := <-runs:
+				return run
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for scheduled run")
+				return ScheduledRun{}
+			}
+		}
+
+		if run := next(); run.Schedule != "boot" || run.Trigger != TriggerBoot || run.Report == nil {
+			t.Errorf("Expected boot run, got %+v", run)
+		}
+
+		// Non-failover stability events are ignored
+		sched.OnStabilityEvent(power.StabilityEvent{Type: power.EventVoltageRipple})
+		sched.OnThermalCritical(thermal.ThermalState{CPUTemp: 90})
+		run := next()
+		if run.Schedule != "thermal_check" || run.Event != EventThermalCritical {
+			t.Errorf("Expected thermal triggered run, got %+v", run)
+		}
+
+		// A flapping sensor is rate limited
+		for i := 0; i < 5; i++ {
+			sched.OnThermalCritical(thermal.ThermalState{CPUTemp: 90})
+			sched.OnStabilityEvent(power.StabilityEvent{Type: power.EventSourceFailover})
+		}
+		for deadline := time.Now().Add(time.Second); sched.Suppressed() < 10 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		select {
+		case run := <-runs:
+			t.Errorf("Expected rate limited events, got run %+v", run)
+		default:
+		}
+		if sched.Suppressed() != 10 {
+			t.Errorf("Expected 10 suppressed triggers, got %d", sched.Suppressed())
+		}
+
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Expected cancellation, got %v", err)
+		}
+	})
+	t.Run("Cron During Event Run", func(t *testing.T) {
+		mgr := newTestManager(t, Config{})
+		mgr.Register(NewTest(TestInfo{Name: "slow"}, func(ctx context.Context, report Reporter) error {
+			time.Sleep(50 * time.Millisecond)
+			report(TestResult{Component: "slow", Status: StatusPass})
+			return nil
+		}))
+		runs := make(chan ScheduledRun, 10)
+		sched, err := NewScheduler(mgr, SchedulerConfig{
+			Schedules: []Schedule{
+				{Name: "minutely", Selection: Selection{Names: []string{"gpio"}}, Cron: "* * * * *"},
+				{Name: "on_tamper", Selection: Selection{Names: []string{"slow"}}, Events: []Event{EventTamper}},
+			},
+			OnRun: func(run ScheduledRun) { runs <- run },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create scheduler: %v", err)
+		}
+
+		// The cron slot passes while the event run is in progress
+		sched.schedules[0].nextCron = time.Now().Add(20 * time.Millisecond)
+		sched.Trigger(EventTamper)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go sched.Run(ctx)
+
+		for _, want := range []string{"on_tamper", "minutely"} {
+			select {
+			case run := <-runs:
+				if run.Schedule != want {
+					t.Errorf("Expected %s run, got %s", want, run.Schedule)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Timed out waiting for %s run", want)
+			}
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package diag
 
 import (
+	"context"
 	"time"
 
 	"github.com/wrale/wrale-fleet-metal-hw/gpio"
@@ -21,6 +22,7 @@ const (
 
 // TestResult represents a hardware test outcome
 type TestResult struct {
+	Test        string // Name of the registered test that produced the result
 	Type        TestType
 	Component   string
 	Status      TestStatus
@@ -41,6 +43,81 @@ const (
 	StatusSkipped TestStatus = "SKIPPED"
 )
 
+// Subsystem identifies a hardware subsystem a test depends on
+type Subsystem string
+
+const (
+	SubsystemGPIO     Subsystem = "GPIO"
+	SubsystemPower    Subsystem = "POWER"
+	SubsystemThermal  Subsystem = "THERMAL"
+	SubsystemSecurity Subsystem = "SECURITY"
+)
+
+// TestInfo describes a registered diagnostic test
+type TestInfo struct {
+	Name        string        // Unique test name
+	Description string        // Human readable summary
+	Category    TestType      // Subsystem category for reporting
+	Tags        []string      // Free form labels used for selection
+	Destructive bool          // Test may disturb attached hardware or running services
+	Timeout     time.Duration // Per attempt timeout, zero for none
+	Exclusive   bool          // Test must not run alongside any other test
+	DependsOn   []string      // Tests that must run first
+	Requires    []Subsystem   // Subsystems that must be configured, otherwise the test is skipped
+}
+
+// Reporter records a result for the running test
+type Reporter func(TestResult)
+
+// Test is a pluggable diagnostic test
+type Test interface {
+	// Info returns the test metadata
+	Info() TestInfo
+	// Run executes the test, reporting each measurement. A non-nil error
+	// fails the test.
+	Run(ctx context.Context, report Reporter) error
+}
+
+// Selection chooses which registered tests to run. Empty fields match every
+// test; a test must match all non-empty fields. Dependencies of selected
+// tests are always included.
+type Selection struct {
+	Names              []string   // Run only these tests
+	Tags               []string   // Run tests carrying any of these tags
+	Categories         []TestType // Run tests in any of these categories
+	IncludeDestructive bool       // Run destructive tests not named explicitly
+}
+
+// TestReport is the outcome of one registered test within a run
+type TestReport struct {
+	Name     string
+	Category TestType
+	Status   TestStatus
+	Attempts int
+	Start    time.Time
+	Duration time.Duration
+	Error    error
+	Results  []TestResult // Results recorded during the final attempt
+}
+
+// Summary counts test outcomes within a run
+type Summary struct {
+	Total    int
+	Passed   int
+	Failed   int
+	Warnings int
+	Skipped  int
+}
+
+// Report is the structured outcome of a diagnostic run
+type Report struct {
+	Start    time.Time
+	Duration time.Duration
+	Verdict  TestStatus // FAIL if any test failed, WARNING if any warned, otherwise PASS
+	Summary  Summary
+	Tests    []TestReport // In dependency order
+}
+
 // Config holds the hardware diagnostics configuration
 type Config struct {
 	// Hardware subsystem interfaces
@@ -50,16 +127,50 @@ type Config struct {
 	Security *secure.Manager
 
 	// Test parameters
-	GPIOPins     []string      // GPIO pins to test
-	LoadTestTime time.Duration // Duration for power load tests
-	MinVoltage   float64       // Minimum acceptable voltage
-	TempRange    [2]float64    // Valid temperature range
-	Retries      int           // Number of test retries
+	GPIOPins           []string       // GPIO pins to check, read only
+	GPIOLoopbacks      []GPIOLoopback // Jumpered pin pairs to drive and verify
+	LoopbackSettle     time.Duration  // Delay before sampling a loopback input, default 1ms
+	LoadTestTime       time.Duration  // Duration for power load tests
+	LoadGenerator      LoadGenerator  // Load applied during power tests, default CPU stress
+	LoadSampleInterval time.Duration  // Supply sample interval during load tests, default 100ms
+	MaxVoltageSag      float64        // Maximum voltage drop under load, default 0.25V
+	MaxRipple          float64        // Maximum peak-to-peak ripple under load, default 0.1V
+	MaxRecoveryTime    time.Duration  // Maximum time to recover after load, default 2s
+	FanSweep           []uint32       // Fan duty cycles to characterize, default 25-100%
+	FanSettleTime      time.Duration  // Settle time at each sweep point, default 3s
+	FanStallRPM        float64        // Speeds below this count as stalled, default 300
+	FanRatedRPM        float64        // Rated speed at full duty, zero to skip the degradation check
+	FanDegradedRatio   float64        // Fraction of rated speed below which the fan is degraded, default 0.7
+	MinVoltage         float64        // Minimum acceptable voltage
+	TempRange          [2]float64     // Valid temperature range
+	Retries            int            // Number of test attempts
+	RetryDelay         time.Duration  // Delay between attempts, default 1s
+	Parallelism        int            // Maximum tests run concurrently, default 4
+	MaxResults         int            // Results kept in memory, default 1000
+	History            *History       // Optional persistent report history
 
 	// Optional callbacks
 	OnTestComplete func(TestResult)
 }
 
+// GPIOLoopback is an output pin wired to an input pin for loopback testing
+type GPIOLoopback struct {
+	Output string
+	Input  string
+}
+
+// GPIOFault classifies a failed loopback pair
+type GPIOFault string
+
+const (
+	FaultNone      GPIOFault = "NONE"
+	FaultStuckHigh GPIOFault = "STUCK_HIGH" // Input high regardless of the output
+	FaultStuckLow  GPIOFault = "STUCK_LOW"  // Input low regardless of the output or its pull-up
+	FaultOpen      GPIOFault = "OPEN"       // Input follows its pulls but not the output
+	FaultShort     GPIOFault = "SHORT"      // Input inverted or another pair's input follows the output
+	FaultPull      GPIOFault = "PULL"       // Internal pull-up or pull-down ineffective
+)
+
 // RawReadings holds direct sensor readings
 type RawReadings struct {
 	GPIOStates     map[string]bool // Raw GPIO pin states
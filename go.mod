@@ -3,6 +3,14 @@ module github.com/wrale/wrale-fleet-metal-hw
 go 1.21
 
 require (
+	github.com/google/go-tpm v0.9.0
+	go.etcd.io/bbolt v1.3.10
 	periph.io/x/conn/v3 v3.7.0
 	periph.io/x/host/v3 v3.8.2
 )
+
+require (
+	github.com/google/go-tpm-tools v0.3.13-0.20230620182252-4639ecce2aba // indirect
+	github.com/jonboulle/clockwork v0.3.0 // indirect
+	golang.org/x/sys v0.8.0 // indirect
+)
@@ -21,6 +21,7 @@ type Controller struct {
 	pins       map[string]gpio.PinIO
 	interrupts map[string]*interruptState
 	pwmPins    map[string]*pwmState
+	outputs    map[string]bool
 	enabled    bool
 	simulation bool
 
@@ -47,6 +48,7 @@ func New(opts ...Option) (*Controller, error) {
 		pins:       make(map[string]gpio.PinIO),
 		interrupts: make(map[string]*interruptState),
 		pwmPins:    make(map[string]*pwmState),
+		outputs:    make(map[string]bool),
 		enabled:    true,
 		simulation: options.SimulationMode,
 		simPins:    make(map[string]*simPin),
@@ -64,6 +66,7 @@ func (c *Controller) ConfigurePin(name string, pin gpio.PinIO, pull gpio.Pull) e
 
 	if c.simulation {
 		c.pins[name] = pin // Allow nil pin in simulation mode
+		c.outputs[name] = false
 		c.simPins[name] = &simPin{
 			value: false,
 			pull:  pull,
@@ -87,6 +90,7 @@ func (c *Controller) ConfigurePin(name string, pin gpio.PinIO, pull gpio.Pull) e
 	}
 
 	c.pins[name] = pin
+	c.outputs[name] = false
 	return nil
 }
 
@@ -101,6 +105,7 @@ func (c *Controller) SetPinState(name string, high bool) error {
 			return fmt.Errorf("pin %s not found", name)
 		}
 		simPin.value = high
+		c.outputs[name] = true
 		// Also set physical pin if one exists
 		if pin := c.pins[name]; pin != nil {
 			if high {
@@ -120,12 +125,61 @@ func (c *Controller) SetPinState(name string, high bool) error {
 		return fmt.Errorf("pin %s is nil", name)
 	}
 
+	c.outputs[name] = true
 	if high {
 		return pin.Out(gpio.High)
 	}
 	return pin.Out(gpio.Low)
 }
 
+// SetPinInput returns a pin to input mode with the given pull-up/down,
+// releasing any level it was driving
+func (c *Controller) SetPinInput(name string, pull gpio.Pull) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	pin, exists := c.pins[name]
+	if !exists {
+		return fmt.Errorf("pin %s not found", name)
+	}
+	if state, ok := c.interrupts[name]; ok && state.enabled {
+		return fmt.Errorf("pin %s has an interrupt enabled", name)
+	}
+
+	if c.simulation {
+		simPin := c.simPins[name]
+		simPin.pull = pull
+		// A released simulated line settles to its pull
+		switch pull {
+		case gpio.PullUp:
+			simPin.value = true
+		case gpio.PullDown:
+			simPin.value = false
+		}
+	} else if pin == nil {
+		return fmt.Errorf("pin %s is nil", name)
+	}
+
+	if pin != nil {
+		if err := pin.In(pull, gpio.NoEdge); err != nil {
+			return fmt.Errorf("failed to configure pin: %w", err)
+		}
+	}
+	c.outputs[name] = false
+	return nil
+}
+
+// IsPinOutput reports whether a pin is currently driven as an output
+func (c *Controller) IsPinOutput(name string) (bool, error) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if _, exists := c.pins[name]; !exists {
+		return false, fmt.Errorf("pin %s not found", name)
+	}
+	return c.outputs[name], nil
+}
+
 // GetPinState reads the current state of a GPIO pin
 func (c *Controller) GetPinState(name string) (bool, error) {
 	c.mux.RLock()
@@ -47,10 +47,12 @@ func (c *Controller) EnableInterrupt(name string, cfg InterruptConfig) error {
 		return fmt.Errorf("pin %s not found", name)
 	}
 
-	// Ensure pin supports interrupts
-	input := pin.In(gpio.PullUp, gpio.BothEdges)
-	if input != nil {
-		return fmt.Errorf("failed to configure pin for interrupts: %v", input)
+	// Ensure pin supports interrupts, simulated pins may have no physical pin
+	if pin != nil {
+		input := pin.In(gpio.PullUp, gpio.BothEdges)
+		if input != nil {
+			return fmt.Errorf("failed to configure pin for interrupts: %v", input)
+		}
 	}
 
 	// Initialize interrupt tracking
@@ -86,6 +88,68 @@ func (c *Controller) DisableInterrupt(name string) error {
 	return nil
 }
 
+// periphEdge converts an Edge to the periph edge setting
+func periphEdge(edge Edge) gpio.Edge {
+	switch edge {
+	case Rising:
+		return gpio.RisingEdge
+	case Falling:
+		return gpio.FallingEdge
+	default:
+		return gpio.BothEdges
+	}
+}
+
+// SetPinEdge configures a pin as an input with hardware edge detection for
+// WaitForEdge, for pulse counting and timing-sensitive single-wire protocols
+func (c *Controller) SetPinEdge(name string, pull gpio.Pull, edge Edge) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	pin, exists := c.pins[name]
+	if !exists {
+		return fmt.Errorf("pin %s not found", name)
+	}
+	if state, ok := c.interrupts[name]; ok && state.enabled {
+		return fmt.Errorf("pin %s has an interrupt enabled", name)
+	}
+
+	if c.simulation {
+		c.simPins[name].pull = pull
+	} else if pin == nil {
+		return fmt.Errorf("pin %s is nil", name)
+	}
+
+	if pin != nil {
+		if err := pin.In(pull, periphEdge(edge)); err != nil {
+			return fmt.Errorf("failed to configure pin edge detection: %w", err)
+		}
+	}
+	c.outputs[name] = false
+	return nil
+}
+
+// WaitForEdge blocks until an edge configured by SetPinEdge occurs, reporting
+// false on timeout. The controller is not locked while waiting.
+func (c *Controller) WaitForEdge(name string, timeout time.Duration) (bool, error) {
+	c.mux.RLock()
+	pin, exists := c.pins[name]
+	c.mux.RUnlock()
+
+	if !exists {
+		return false, fmt.Errorf("pin %s not found", name)
+	}
+	if pin == nil {
+		if !c.simulation {
+			return false, fmt.Errorf("pin %s is nil", name)
+		}
+		// Simulated pins without hardware never see edges
+		time.Sleep(timeout)
+		return false, nil
+	}
+	return pin.WaitForEdge(timeout), nil
+}
+
 // handleInterrupt processes a pin interrupt
 func (c *Controller) handleInterrupt(name string, state bool) {
 	c.mux.RLock()
@@ -133,11 +197,16 @@ func (c *Controller) monitorPin(ctx context.Context, name string, pin gpio.PinIO
 // Monitor starts monitoring pin changes in the background
 func (c *Controller) Monitor(ctx context.Context) error {
 	// Start monitoring each pin with interrupts enabled
+	c.mux.RLock()
 	for name, pin := range c.pins {
+		if pin == nil {
+			continue
+		}
 		if state, hasInterrupt := c.interrupts[name]; hasInterrupt && state.enabled {
 			go c.monitorPin(ctx, name, pin)
 		}
 	}
+	c.mux.RUnlock()
 
 	<-ctx.Done()
 	return nil
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpiotest"
 	"periph.io/x/conn/v3/physic"
 )
 
@@ -124,3 +125,35 @@ func TestInterrupts(t *testing.T) {
 		t.Error("Monitor did not complete in time")
 	}
 }
+
+func TestWaitForEdge(t *testing.T) {
+	ctrl, err := New(WithSimulation())
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	pin := &gpiotest.Pin{N: "edge_pin", EdgesChan: make(chan gpio.Level, 1)}
+	if err := ctrl.ConfigurePin("edge_pin", pin, gpio.Float); err != nil {
+		t.Fatalf("Failed to configure pin: %v", err)
+	}
+	if err := ctrl.SetPinEdge("edge_pin", PullUp, Falling); err != nil {
+		t.Fatalf("Failed to configure edge detection: %v", err)
+	}
+	if pin.P != gpio.PullUp {
+		t.Error("Edge pin not pulled up")
+	}
+
+	pin.EdgesChan <- gpio.Low
+	if edge, err := ctrl.WaitForEdge("edge_pin", time.Second); err != nil || !edge {
+		t.Errorf("Expected edge, got %v: %v", edge, err)
+	}
+	if state, _ := ctrl.GetPinState("edge_pin"); state {
+		t.Error("Pin level not updated by edge")
+	}
+	if edge, _ := ctrl.WaitForEdge("edge_pin", time.Millisecond); edge {
+		t.Error("Expected timeout without edge")
+	}
+	if _, err := ctrl.WaitForEdge("missing", time.Millisecond); err == nil {
+		t.Error("Expected error for unknown pin")
+	}
+}
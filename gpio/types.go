@@ -8,6 +8,10 @@ type Pull = gpio.Pull
 const (
 	// PullNone specifies no pull up/down
 	PullNone = gpio.Float
+	// PullUp specifies an internal pull-up
+	PullUp = gpio.PullUp
+	// PullDown specifies an internal pull-down
+	PullDown = gpio.PullDown
 )
 
 // PWMConfig holds PWM pin configuration
@@ -0,0 +1,53 @@
+package power
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PowerSample is an instantaneous supply measurement
+type PowerSample struct {
+	Voltage   float64 // Volts
+	Current   float64 // Amps, zero when no current sensor is configured
+	Timestamp time.Time
+}
+
+// Sample reads the supply voltage and current ADCs. ADC files hold
+// millivolts and milliamps, following the hwmon sysfs convention.
+func (m *Manager) Sample() (PowerSample, error) {
+	if m.voltageADC == "" {
+		return PowerSample{}, fmt.Errorf("voltage ADC not configured")
+	}
+
+	voltage, err := readMilli(m.voltageADC)
+	if err != nil {
+		return PowerSample{}, fmt.Errorf("failed to read voltage: %w", err)
+	}
+	sample := PowerSample{Voltage: voltage, Timestamp: time.Now()}
+
+	if m.currentADC != "" {
+		current, err := readMilli(m.currentADC)
+		if err != nil {
+			return PowerSample{}, fmt.Errorf("failed to read current: %w", err)
+		}
+		sample.Current = current
+	}
+	return sample, nil
+}
+
+// readMilli reads a milli-unit ADC value and converts it to base units
+func readMilli(path string) (float64, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+	raw, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ADC value: %w", err)
+	}
+	return raw / 1000, nil
+}
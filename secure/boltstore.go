@@ -0,0 +1,186 @@
+package secure
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt bucket names
+var (
+	boltStateBucket  = []byte("state")
+	boltEventsBucket = []byte("events")
+)
+
+// BoltStore persists security state and events in an embedded bbolt database.
+// Events are kept in per-device buckets keyed by timestamp for range queries.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens or creates a bbolt database at path
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltStateBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltEventsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// SaveState persists the device's security state
+func (s *BoltStore) SaveState(ctx context.Context, deviceID string, state TamperState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltStateBucket).Put([]byte(deviceID), data)
+	})
+}
+
+// LoadState retrieves the device's last saved state
+func (s *BoltStore) LoadState(ctx context.Context, deviceID string) (TamperState, error) {
+	var state TamperState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltStateBucket).Get([]byte(deviceID))
+		if data == nil {
+			return fmt.Errorf("no state for device %s", deviceID)
+		}
+		return json.Unmarshal(data, &state)
+	})
+	return state, err
+}
+
+// eventKey orders events by timestamp, with a sequence number to keep keys unique
+func eventKey(at time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(at.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// LogEvent records a security event
+func (s *BoltStore) LogEvent(ctx context.Context, deviceID string, eventType string, details interface{}) error {
+	event := Event{
+		DeviceID:  deviceID,
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Details:   details,
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(boltEventsBucket).CreateBucketIfNotExists([]byte(deviceID))
+		if err != nil {
+			return err
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(eventKey(event.Timestamp, seq), data)
+	})
+}
+
+// QueryEvents returns events matching the query in chronological order
+func (s *BoltStore) QueryEvents(ctx context.Context, q EventQuery) ([]Event, error) {
+	var events []Event
+	err := s.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(boltEventsBucket)
+
+		var devices [][]byte
+		if q.DeviceID != "" {
+			devices = append(devices, []byte(q.DeviceID))
+		} else {
+			if err := root.ForEach(func(k, v []byte) error {
+				if v == nil { // Nested buckets have nil values
+					devices = append(devices, append([]byte(nil), k...))
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, device := range devices {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			bucket := root.Bucket(device)
+			if bucket == nil {
+				continue
+			}
+			if err := scanBoltEvents(bucket, q, &events); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	if q.Limit > 0 && len(events) > q.Limit {
+		events = events[:q.Limit]
+	}
+	return events, nil
+}
+
+// scanBoltEvents collects matching events from a device bucket within the query's time range
+func scanBoltEvents(bucket *bolt.Bucket, q EventQuery, events *[]Event) error {
+	c := bucket.Cursor()
+
+	k, v := c.First()
+	if !q.Since.IsZero() {
+		k, v = c.Seek(eventKey(q.Since, 0))
+	}
+
+	found := 0
+	for ; k != nil; k, v = c.Next() {
+		if !q.Until.IsZero() && int64(binary.BigEndian.Uint64(k[:8])) >= q.Until.UnixNano() {
+			break
+		}
+		var event Event
+		if err := json.Unmarshal(v, &event); err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+		if !q.matches(event) {
+			continue
+		}
+		*events = append(*events, event)
+		found++
+		if q.Limit > 0 && found >= q.Limit {
+			break
+		}
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
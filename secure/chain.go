@@ -0,0 +1,246 @@
+package secure
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrChainBroken is returned when an event log fails chain verification
+var ErrChainBroken = errors.New("event chain broken")
+
+// Signer signs and verifies event hashes with a device key
+type Signer interface {
+	Sign(digest []byte) ([]byte, error)
+	Verify(digest, sig []byte) bool
+}
+
+// HMACSigner signs event hashes with a shared HMAC-SHA256 key
+type HMACSigner struct {
+	key []byte
+}
+
+// NewHMACSigner creates a signer from a shared secret key
+func NewHMACSigner(key []byte) *HMACSigner {
+	return &HMACSigner{key: append([]byte(nil), key...)}
+}
+
+// Sign returns the HMAC of the digest
+func (s *HMACSigner) Sign(digest []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(digest)
+	return mac.Sum(nil), nil
+}
+
+// Verify checks the HMAC of the digest in constant time
+func (s *HMACSigner) Verify(digest, sig []byte) bool {
+	expected, _ := s.Sign(digest)
+	return hmac.Equal(expected, sig)
+}
+
+// Ed25519Signer signs event hashes with a device Ed25519 key. A signer created
+// from only a public key can verify logs but not sign them.
+type Ed25519Signer struct {
+	priv ed25519.PrivateKey
+	pub  ed25519.PublicKey
+}
+
+// NewEd25519Signer creates a signer from a device private key
+func NewEd25519Signer(priv ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{
+		priv: priv,
+		pub:  priv.Public().(ed25519.PublicKey),
+	}
+}
+
+// NewEd25519Verifier creates a verify-only signer from a device public key
+func NewEd25519Verifier(pub ed25519.PublicKey) *Ed25519Signer {
+	return &Ed25519Signer{pub: pub}
+}
+
+// Sign signs the digest with the private key
+func (s *Ed25519Signer) Sign(digest []byte) ([]byte, error) {
+	if s.priv == nil {
+		return nil, fmt.Errorf("no private key for signing")
+	}
+	return ed25519.Sign(s.priv, digest), nil
+}
+
+// Verify checks the digest signature with the public key
+func (s *Ed25519Signer) Verify(digest, sig []byte) bool {
+	return ed25519.Verify(s.pub, digest, sig)
+}
+
+// ChainReport summarizes a verified event chain
+type ChainReport struct {
+	Entries  int
+	FirstSeq uint64
+	LastSeq  uint64
+	// Hash of the newest entry; keep it outside the device as a head anchor
+	// for later verification
+	Head string
+	// The oldest entries are missing, as happens when rotated files are
+	// dropped, and the chain was accepted from an anchored start
+	Truncated bool
+}
+
+// ChainCheckpoint identifies a chain entry by sequence number and hash
+type ChainCheckpoint struct {
+	Seq  uint64
+	Hash string
+}
+
+// ChainAnchor holds trusted checkpoints kept outside the log, e.g. by the
+// fleet backend, that pin both ends of a verified chain
+type ChainAnchor struct {
+	// Oldest entry that must remain; earlier entries may have been rotated
+	// away. When unset the chain must start at its origin.
+	Start ChainCheckpoint
+	// A previously reported head; it must still be present, so removal of
+	// trailing entries is detected
+	Head ChainCheckpoint
+}
+
+// chainPayload is the canonical encoding covered by an event hash
+type chainPayload struct {
+	Seq       uint64          `json:"seq"`
+	PrevHash  string          `json:"prev_hash"`
+	DeviceID  string          `json:"device_id"`
+	Type      string          `json:"type"`
+	Timestamp string          `json:"timestamp"`
+	Details   json.RawMessage `json:"details"`
+}
+
+// canonicalDetails encodes details in the form they take after a JSON round
+// trip, so hashes computed before writing match those computed after reading
+func canonicalDetails(details interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// eventHash computes the chained hash of an event
+func eventHash(e Event) ([]byte, error) {
+	details, err := canonicalDetails(e.Details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event details: %w", err)
+	}
+	data, err := json.Marshal(chainPayload{
+		Seq:       e.Seq,
+		PrevHash:  e.PrevHash,
+		DeviceID:  e.DeviceID,
+		Type:      e.Type,
+		Timestamp: e.Timestamp.UTC().Format(time.RFC3339Nano),
+		Details:   details,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// sealEvent links an event to the previous hash and signs it
+func sealEvent(e *Event, seq uint64, prevHash string, signer Signer) error {
+	e.Seq = seq
+	e.PrevHash = prevHash
+	digest, err := eventHash(*e)
+	if err != nil {
+		return err
+	}
+	sig, err := signer.Sign(digest)
+	if err != nil {
+		return fmt.Errorf("failed to sign event: %w", err)
+	}
+	e.Hash = hex.EncodeToString(digest)
+	e.Signature = hex.EncodeToString(sig)
+	return nil
+}
+
+// VerifyChain checks that events form an unbroken, correctly signed hash chain
+// in order. Modified, deleted, inserted and reordered entries are reported as
+// ErrChainBroken. The chain must start at its origin unless it covers the
+// anchor's start checkpoint, and must still contain the anchor's head.
+func VerifyChain(events []Event, signer Signer, anchor ChainAnchor) (ChainReport, error) {
+	var report ChainReport
+
+	// checkpoint verifies an entry against an anchor checkpoint
+	checkpoint := func(e Event, cp ChainCheckpoint) bool {
+		return e.Seq != cp.Seq || cp.Hash == "" || e.Hash == cp.Hash
+	}
+
+	for i, e := range events {
+		fail := func(reason string) (ChainReport, error) {
+			return report, fmt.Errorf("%w: entry %d (seq %d): %s", ErrChainBroken, i, e.Seq, reason)
+		}
+
+		if e.Hash == "" || e.Seq == 0 {
+			return fail("unsigned entry")
+		}
+		digest, err := eventHash(e)
+		if err != nil {
+			return report, err
+		}
+		if hex.EncodeToString(digest) != e.Hash {
+			return fail("hash mismatch")
+		}
+		sig, err := hex.DecodeString(e.Signature)
+		if err != nil || !signer.Verify(digest, sig) {
+			return fail("invalid signature")
+		}
+
+		if i == 0 {
+			switch {
+			case e.Seq == 1 && e.PrevHash != "":
+				return fail("chain origin has a previous hash")
+			case e.Seq != 1 && anchor.Start.Seq == 0:
+				return fail(fmt.Sprintf("%d leading entries missing without an anchored start", e.Seq-1))
+			case e.Seq > anchor.Start.Seq && anchor.Start.Seq != 0:
+				return fail(fmt.Sprintf("anchored start seq %d missing", anchor.Start.Seq))
+			}
+			report.FirstSeq = e.Seq
+			report.Truncated = e.Seq != 1
+		} else {
+			prev := events[i-1]
+			if e.Seq <= prev.Seq {
+				return fail("out of order")
+			}
+			if e.Seq != prev.Seq+1 {
+				return fail(fmt.Sprintf("%d entries missing", e.Seq-prev.Seq-1))
+			}
+			if e.PrevHash != prev.Hash {
+				return fail("previous hash mismatch")
+			}
+		}
+		if !checkpoint(e, anchor.Start) {
+			return fail("does not match anchored start")
+		}
+		if !checkpoint(e, anchor.Head) {
+			return fail("does not match anchored head")
+		}
+
+		report.Entries++
+		report.LastSeq = e.Seq
+		report.Head = e.Hash
+	}
+
+	if anchor.Start.Seq > report.LastSeq {
+		return report, fmt.Errorf("%w: anchored start seq %d missing", ErrChainBroken, anchor.Start.Seq)
+	}
+	if anchor.Head.Seq > report.LastSeq {
+		return report, fmt.Errorf("%w: %d trailing entries missing before anchored head seq %d",
+			ErrChainBroken, anchor.Head.Seq-report.LastSeq, anchor.Head.Seq)
+	}
+	return report, nil
+}
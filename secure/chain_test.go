@@ -0,0 +1,209 @@
+package secure
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeChainedLog logs n events to a signed event log and returns its path
+func writeChainedLog(t *testing.T, signer Signer, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	log, err := NewEventLog(EventLogConfig{Path: path, Signer: signer})
+	if err != nil {
+		t.Fatalf("Failed to create event log: %v", err)
+	}
+	defer log.Close()
+
+	for i := 0; i < n; i++ {
+		details := map[string]interface{}{"sensor": SensorCase, "count": i}
+		if err := log.LogEvent(context.Background(), "dev-a", fmt.Sprintf("event_%d", i), details); err != nil {
+			t.Fatalf("Failed to log event: %v", err)
+		}
+	}
+	return path
+}
+
+// editLines rewrites a JSONL file through fn
+func editLines(t *testing.T, path string, fn func([][]byte) [][]byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	lines = fn(lines)
+	data = append(bytes.Join(lines, []byte("\n")), '\n')
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+}
+
+// verifyFile verifies a signed event log file as a freshly started process would
+func verifyFile(t *testing.T, path string, signer Signer, anchor ChainAnchor) (ChainReport, error) {
+	t.Helper()
+	log, err := NewEventLog(EventLogConfig{Path: path, Signer: signer})
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	defer log.Close()
+	return log.Verify(context.Background(), anchor)
+}
+
+func TestChainVerify(t *testing.T) {
+	hmacSigner := NewHMACSigner([]byte("device-secret"))
+
+	t.Run("Intact HMAC", func(t *testing.T) {
+		path := writeChainedLog(t, hmacSigner, 5)
+		report, err := verifyFile(t, path, hmacSigner, ChainAnchor{})
+		if err != nil {
+			t.Fatalf("Failed to verify intact log: %v", err)
+		}
+		if report.Entries != 5 || report.FirstSeq != 1 || report.LastSeq != 5 || report.Truncated {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("Intact Ed25519", func(t *testing.T) {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		path := writeChainedLog(t, NewEd25519Signer(priv), 3)
+
+		// Verification needs only the public key
+		if _, err := verifyFile(t, path, NewEd25519Verifier(pub), ChainAnchor{}); err != nil {
+			t.Errorf("Failed to verify with public key: %v", err)
+		}
+
+		otherPub, _, _ := ed25519.GenerateKey(nil)
+		if _, err := verifyFile(t, path, NewEd25519Verifier(otherPub), ChainAnchor{}); !errors.Is(err, ErrChainBroken) {
+			t.Errorf("Expected chain error with wrong key, got %v", err)
+		}
+	})
+
+	t.Run("Chain Continues After Reopen", func(t *testing.T) {
+		path := writeChainedLog(t, hmacSigner, 2)
+		log, err := NewEventLog(EventLogConfig{Path: path, Signer: hmacSigner})
+		if err != nil {
+			t.Fatalf("Failed to reopen event log: %v", err)
+		}
+		if err := log.LogEvent(context.Background(), "dev-a", "after_reopen", nil); err != nil {
+			t.Fatalf("Failed to log event: %v", err)
+		}
+		report, err := log.Verify(context.Background(), ChainAnchor{})
+		log.Close()
+		if err != nil || report.LastSeq != 3 {
+			t.Errorf("Expected chain of 3 after reopen, got %+v: %v", report, err)
+		}
+	})
+
+	tamperings := []struct {
+		name string
+		edit func([][]byte) [][]byte
+	}{
+		{"Modified", func(lines [][]byte) [][]byte {
+			lines[2] = bytes.Replace(lines[2], []byte("event_2"), []byte("event_x"), 1)
+			return lines
+		}},
+		{"Modified Details", func(lines [][]byte) [][]byte {
+			lines[1] = bytes.Replace(lines[1], []byte(`"count":1`), []byte(`"count":7`), 1)
+			return lines
+		}},
+		{"Deleted", func(lines [][]byte) [][]byte {
+			return append(lines[:2], lines[3:]...)
+		}},
+		{"Deleted Oldest", func(lines [][]byte) [][]byte {
+			return lines[2:]
+		}},
+		{"Deleted Newest", func(lines [][]byte) [][]byte {
+			return lines[:3]
+		}},
+		{"Reordered", func(lines [][]byte) [][]byte {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		}},
+		{"Inserted", func(lines [][]byte) [][]byte {
+			forged := []byte(`{"device_id":"dev-a","type":"forged","timestamp":"2024-01-01T00:00:00Z"}`)
+			return append(lines[:3], append([][]byte{forged}, lines[3:]...)...)
+		}},
+	}
+	for _, tt := range tamperings {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeChainedLog(t, hmacSigner, 5)
+			intact, err := verifyFile(t, path, hmacSigner, ChainAnchor{})
+			if err != nil {
+				t.Fatalf("Failed to verify intact log: %v", err)
+			}
+
+			// The head was reported off the device before tampering
+			anchor := ChainAnchor{Head: ChainCheckpoint{Seq: intact.LastSeq, Hash: intact.Head}}
+			editLines(t, path, tt.edit)
+			if _, err := verifyFile(t, path, hmacSigner, anchor); !errors.Is(err, ErrChainBroken) {
+				t.Errorf("Expected chain error, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Rotation Truncation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+		log, err := NewEventLog(EventLogConfig{Path: path, MaxSize: 512, MaxFiles: 1, Signer: hmacSigner})
+		if err != nil {
+			t.Fatalf("Failed to create event log: %v", err)
+		}
+		defer log.Close()
+		for i := 0; i < 20; i++ {
+			if err := log.LogEvent(context.Background(), "dev-a", "event", nil); err != nil {
+				t.Fatalf("Failed to log event: %v", err)
+			}
+		}
+
+		// The log anchors its own start when rotation drops files
+		report, err := log.Verify(context.Background(), ChainAnchor{})
+		if err != nil {
+			t.Fatalf("Failed to verify rotated log: %v", err)
+		}
+		if !report.Truncated || report.LastSeq != 20 || report.FirstSeq == 1 {
+			t.Errorf("Expected truncated chain ending at 20, got %+v", report)
+		}
+
+		// After a restart the start must be anchored externally
+		if _, err := verifyFile(t, path, hmacSigner, ChainAnchor{}); !errors.Is(err, ErrChainBroken) {
+			t.Errorf("Expected unanchored truncated chain to fail, got %v", err)
+		}
+		anchor := ChainAnchor{Start: ChainCheckpoint{Seq: report.FirstSeq}}
+		if _, err := verifyFile(t, path, hmacSigner, anchor); err != nil {
+			t.Errorf("Failed to verify with anchored start: %v", err)
+		}
+		anchor.Start.Seq = report.FirstSeq - 1
+		if _, err := verifyFile(t, path, hmacSigner, anchor); !errors.Is(err, ErrChainBroken) {
+			t.Errorf("Expected error with anchored start missing, got %v", err)
+		}
+	})
+
+	t.Run("Live Tail Truncation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+		log, err := NewEventLog(EventLogConfig{Path: path, Signer: hmacSigner})
+		if err != nil {
+			t.Fatalf("Failed to create event log: %v", err)
+		}
+		defer log.Close()
+		for i := 0; i < 4; i++ {
+			if err := log.LogEvent(context.Background(), "dev-a", "event", nil); err != nil {
+				t.Fatalf("Failed to log event: %v", err)
+			}
+		}
+
+		// The running log anchors its own head
+		editLines(t, path, func(lines [][]byte) [][]byte { return lines[:2] })
+		if _, err := log.Verify(context.Background(), ChainAnchor{}); !errors.Is(err, ErrChainBroken) {
+			t.Errorf("Expected trailing deletion detected, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,296 @@
+package secure
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// Default event log rotation limits
+	defaultEventLogMaxSize  = 10 * 1024 * 1024
+	defaultEventLogMaxFiles = 5
+)
+
+// EventLogConfig holds JSONL event log configuration
+type EventLogConfig struct {
+	Path     string // Active log file path
+	MaxSize  int64  // Size in bytes at which the log rotates
+	MaxFiles int    // Number of rotated files to keep
+
+	// Optional device key; when set, events are hash-chained and signed
+	Signer Signer
+}
+
+// EventLog is an append-only JSON Lines security event log with size-based rotation
+type EventLog struct {
+	mux  sync.Mutex
+	cfg  EventLogConfig
+	file *os.File
+	size int64
+
+	// Hash chain head, and the oldest retained entry once rotation has
+	// dropped files during this process's lifetime
+	seq   uint64
+	head  string
+	start ChainCheckpoint
+}
+
+// NewEventLog opens or creates a JSONL event log
+func NewEventLog(cfg EventLogConfig) (*EventLog, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("event log path is required")
+	}
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = defaultEventLogMaxSize
+	}
+	if cfg.MaxFiles == 0 {
+		cfg.MaxFiles = defaultEventLogMaxFiles
+	}
+
+	l := &EventLog{cfg: cfg}
+	if cfg.Signer != nil {
+		if err := l.recoverChain(); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// open opens the active log file for appending
+func (l *EventLog) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.cfg.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create event log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(l.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat event log: %w", err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// rotatedPath returns the path of the nth rotated file
+func (l *EventLog) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", l.cfg.Path, n)
+}
+
+// rotate shifts rotated files and starts a new active file - must be called with lock held
+func (l *EventLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to close event log: %w", err)
+	}
+
+	err := os.Remove(l.rotatedPath(l.cfg.MaxFiles))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove oldest event log: %w", err)
+	}
+	dropped := err == nil
+	for n := l.cfg.MaxFiles - 1; n >= 1; n-- {
+		if err := os.Rename(l.rotatedPath(n), l.rotatedPath(n+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate event log: %w", err)
+		}
+	}
+	if err := os.Rename(l.cfg.Path, l.rotatedPath(1)); err != nil {
+		return fmt.Errorf("failed to rotate event log: %w", err)
+	}
+
+	// Anchor verification at the oldest entry still retained
+	if dropped && l.cfg.Signer != nil {
+		var events []Event
+		if _, err := scanEventFile(l.paths()[0], EventQuery{Limit: 1}, &events); err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			l.start = ChainCheckpoint{Seq: events[0].Seq, Hash: events[0].Hash}
+		}
+	}
+
+	return l.open()
+}
+
+// paths returns log file paths, oldest first
+func (l *EventLog) paths() []string {
+	paths := make([]string, 0, l.cfg.MaxFiles+1)
+	for n := l.cfg.MaxFiles; n >= 1; n-- {
+		paths = append(paths, l.rotatedPath(n))
+	}
+	return append(paths, l.cfg.Path)
+}
+
+// recoverChain restores the chain head from the newest chained entry
+func (l *EventLog) recoverChain() error {
+	paths := l.paths()
+	for i := len(paths) - 1; i >= 0; i-- {
+		var events []Event
+		if _, err := scanEventFile(paths[i], EventQuery{}, &events); err != nil {
+			return err
+		}
+		for j := len(events) - 1; j >= 0; j-- {
+			if events[j].Hash != "" {
+				l.seq = events[j].Seq
+				l.head = events[j].Hash
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// Append writes an event to the log, chaining and signing it when a signer is configured
+func (l *EventLog) Append(event Event) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.file == nil {
+		return fmt.Errorf("event log is closed")
+	}
+
+	if l.cfg.Signer != nil {
+		if err := sealEvent(&event, l.seq+1, l.head, l.cfg.Signer); err != nil {
+			return err
+		}
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	line = append(line, '\n')
+	if l.size > 0 && l.size+int64(len(line)) > l.cfg.MaxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event log: %w", err)
+	}
+	if l.cfg.Signer != nil {
+		l.seq = event.Seq
+		l.head = event.Hash
+	}
+	return nil
+}
+
+// LogEvent records a security event with the current time
+func (l *EventLog) LogEvent(ctx context.Context, deviceID string, eventType string, details interface{}) error {
+	return l.Append(Event{
+		DeviceID:  deviceID,
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Details:   details,
+	})
+}
+
+// QueryEvents scans rotated and active files, oldest first
+func (l *EventLog) QueryEvents(ctx context.Context, q EventQuery) ([]Event, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	var events []Event
+	for _, path := range l.paths() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		done, err := scanEventFile(path, q, &events)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+	return events, nil
+}
+
+// Verify checks the hash chain across rotated and active files using the
+// configured signer. Unset anchor checkpoints default to the head this log
+// last wrote and the oldest entry it retained when rotating; after a restart
+// that has dropped rotated files, pass a start checkpoint kept off the device.
+func (l *EventLog) Verify(ctx context.Context, anchor ChainAnchor) (ChainReport, error) {
+	if l.cfg.Signer == nil {
+		return ChainReport{}, fmt.Errorf("event log is not signed")
+	}
+
+	l.mux.Lock()
+	if anchor.Start.Seq == 0 {
+		anchor.Start = l.start
+	}
+	if anchor.Head.Seq == 0 {
+		anchor.Head = ChainCheckpoint{Seq: l.seq, Hash: l.head}
+	}
+	l.mux.Unlock()
+
+	events, err := l.QueryEvents(ctx, EventQuery{})
+	if err != nil {
+		return ChainReport{}, err
+	}
+	return VerifyChain(events, l.cfg.Signer, anchor)
+}
+
+// scanEventFile appends matching events from a JSONL file, reporting when the limit is reached
+func scanEventFile(path string, q EventQuery, events *[]Event) (bool, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			// A torn final line after power loss must not hide earlier events
+			continue
+		}
+		if !q.matches(event) {
+			continue
+		}
+		*events = append(*events, event)
+		if q.Limit > 0 && len(*events) >= q.Limit {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return false, nil
+}
+
+// Close closes the active log file
+func (l *EventLog) Close() error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,144 @@
+package secure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileStore persists security state as atomically written JSON files, one per
+// device, and records events in a rotating JSONL event log in the same directory
+type FileStore struct {
+	mux    sync.Mutex
+	dir    string
+	events *EventLog
+}
+
+// NewFileStore creates a file-backed state store rooted at dir
+func NewFileStore(dir string, logCfg EventLogConfig) (*FileStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("state directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if logCfg.Path == "" {
+		logCfg.Path = filepath.Join(dir, "events.jsonl")
+	}
+	events, err := NewEventLog(logCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileStore{
+		dir:    dir,
+		events: events,
+	}, nil
+}
+
+// statePath returns the state file path for a device
+func (s *FileStore) statePath(deviceID string) (string, error) {
+	if deviceID == "" || deviceID == "." || deviceID == ".." || strings.ContainsAny(deviceID, `/\`) {
+		return "", fmt.Errorf("invalid device ID %q", deviceID)
+	}
+	return filepath.Join(s.dir, deviceID+".state.json"), nil
+}
+
+// SaveState atomically replaces the device's state file
+func (s *FileStore) SaveState(ctx context.Context, deviceID string, state TamperState) error {
+	path, err := s.statePath(deviceID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return writeFileAtomic(path, data)
+}
+
+// LoadState reads the device's last saved state
+func (s *FileStore) LoadState(ctx context.Context, deviceID string) (TamperState, error) {
+	path, err := s.statePath(deviceID)
+	if err != nil {
+		return TamperState{}, err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return TamperState{}, fmt.Errorf("failed to read state: %w", err)
+	}
+	var state TamperState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return TamperState{}, fmt.Errorf("failed to decode state: %w", err)
+	}
+	return state, nil
+}
+
+// LogEvent appends a security event to the event log
+func (s *FileStore) LogEvent(ctx context.Context, deviceID string, eventType string, details interface{}) error {
+	return s.events.LogEvent(ctx, deviceID, eventType, details)
+}
+
+// QueryEvents returns logged events matching the query
+func (s *FileStore) QueryEvents(ctx context.Context, q EventQuery) ([]Event, error) {
+	return s.events.QueryEvents(ctx, q)
+}
+
+// Verify checks the event log hash chain against trusted anchor checkpoints
+func (s *FileStore) Verify(ctx context.Context, anchor ChainAnchor) (ChainReport, error) {
+	return s.events.Verify(ctx, anchor)
+}
+
+// Close closes the event log
+func (s *FileStore) Close() error {
+	return s.events.Close()
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	// Sync the directory so the rename survives power loss
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
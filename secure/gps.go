@@ -0,0 +1,469 @@
+package secure
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Mean Earth radius used for great-circle distances
+	earthRadiusMeters = 6371000
+	// Default geofence radius
+	defaultGeofenceRadius = 100.0
+	// Default maximum age of a usable fix
+	defaultMaxFixAge = 10 * time.Second
+	// Default fix quality limits
+	defaultMaxHDOP       = 5.0
+	defaultMinSatellites = 4
+	// Default consecutive outside fixes before relocation tamper
+	defaultConfirmFixes = 3
+)
+
+// GPSFix is a position fix from a GPS receiver
+type GPSFix struct {
+	Latitude   float64   `json:"lat"`
+	Longitude  float64   `json:"lon"`
+	Altitude   float64   `json:"alt,omitempty"`
+	Satellites int       `json:"satellites,omitempty"`
+	HDOP       float64   `json:"hdop,omitempty"`
+	Time       time.Time `json:"time,omitempty"` // Receiver UTC time
+	Received   time.Time `json:"received"`       // Local time the fix arrived
+}
+
+// GPSSource provides the latest GPS fix
+type GPSSource interface {
+	LastFix() (GPSFix, error)
+}
+
+// fixCache holds the latest fix reported by a receiver
+type fixCache struct {
+	mux   sync.Mutex
+	fix   GPSFix
+	valid bool
+}
+
+// LastFix returns the latest valid fix
+func (c *fixCache) LastFix() (GPSFix, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !c.valid {
+		return GPSFix{}, fmt.Errorf("no GPS fix")
+	}
+	return c.fix, nil
+}
+
+// update applies fn to the cached fix and records whether it is valid
+func (c *fixCache) update(valid bool, fn func(*GPSFix)) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.valid = valid
+	if valid {
+		fn(&c.fix)
+		c.fix.Received = time.Now()
+	}
+}
+
+// NMEAReader reads NMEA 0183 sentences from a serial GPS module
+type NMEAReader struct {
+	fixCache
+	r io.Reader
+}
+
+// NewNMEAReader creates a reader for an NMEA stream
+func NewNMEAReader(r io.Reader) *NMEAReader {
+	return &NMEAReader{r: r}
+}
+
+// OpenNMEASerial opens a serial GPS device configured by the system for its baud rate
+func OpenNMEASerial(path string) (*NMEAReader, io.Closer, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open GPS device: %w", err)
+	}
+	return NewNMEAReader(f), f, nil
+}
+
+// Run reads sentences until the stream ends or ctx is cancelled. A blocked
+// read returns only when the underlying reader is closed.
+func (n *NMEAReader) Run(ctx context.Context) error {
+	scanner := bufio.NewScanner(n.r)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Malformed and unsupported sentences are skipped
+		n.handleSentence(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read NMEA stream: %w", err)
+	}
+	return io.EOF
+}
+
+// handleSentence applies a GGA or RMC sentence to the cached fix
+func (n *NMEAReader) handleSentence(line string) error {
+	fields, err := parseNMEA(line)
+	if err != nil {
+		return err
+	}
+
+	switch fields[0][len(fields[0])-3:] {
+	case "RMC":
+		if len(fields) < 10 {
+			return fmt.Errorf("short RMC sentence")
+		}
+		if fields[2] != "A" {
+			n.update(false, nil)
+			return nil
+		}
+		lat, lon, err := nmeaPosition(fields[3], fields[4], fields[5], fields[6])
+		if err != nil {
+			return err
+		}
+		at, _ := time.Parse("020106150405.999999999", fields[9]+fields[1])
+		n.update(true, func(f *GPSFix) {
+			f.Latitude, f.Longitude, f.Time = lat, lon, at
+		})
+	case "GGA":
+		if len(fields) < 10 {
+			return fmt.Errorf("short GGA sentence")
+		}
+		if fields[6] == "" || fields[6] == "0" {
+			n.update(false, nil)
+			return nil
+		}
+		lat, lon, err := nmeaPosition(fields[2], fields[3], fields[4], fields[5])
+		if err != nil {
+			return err
+		}
+		sats, _ := strconv.Atoi(fields[7])
+		hdop, _ := strconv.ParseFloat(fields[8], 64)
+		alt, _ := strconv.ParseFloat(fields[9], 64)
+		n.update(true, func(f *GPSFix) {
+			f.Latitude, f.Longitude = lat, lon
+			f.Satellites, f.HDOP, f.Altitude = sats, hdop, alt
+			f.Time = nmeaClock(f.Time, fields[1])
+		})
+	}
+	return nil
+}
+
+// nmeaClock applies a GGA hhmmss.ss fix time to the date of the previous fix,
+// or of the local clock before the first RMC, rolling over at midnight.
+// Unparseable times yield a zero time.
+func nmeaClock(prev time.Time, clock string) time.Time {
+	c, err := time.Parse("150405.999999999", clock)
+	if err != nil {
+		return time.Time{}
+	}
+	base := prev
+	if base.IsZero() {
+		base = time.Now().UTC()
+	}
+	at := time.Date(base.Year(), base.Month(), base.Day(),
+		c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), time.UTC)
+	switch {
+	case at.Sub(base) > 12*time.Hour:
+		at = at.AddDate(0, 0, -1)
+	case base.Sub(at) > 12*time.Hour:
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
+
+// parseNMEA verifies a sentence checksum and splits it into fields
+func parseNMEA(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "$") {
+		return nil, fmt.Errorf("not an NMEA sentence")
+	}
+	star := strings.LastIndexByte(line, '*')
+	if star < 0 || star+3 != len(line) {
+		return nil, fmt.Errorf("missing NMEA checksum")
+	}
+	want, err := strconv.ParseUint(line[star+1:], 16, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NMEA checksum: %w", err)
+	}
+
+	body := line[1:star]
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	if sum != byte(want) {
+		return nil, fmt.Errorf("NMEA checksum mismatch")
+	}
+
+	fields := strings.Split(body, ",")
+	if len(fields[0]) < 5 {
+		return nil, fmt.Errorf("invalid NMEA sentence type %q", fields[0])
+	}
+	return fields, nil
+}
+
+// nmeaPosition converts ddmm.mmmm/dddmm.mmmm coordinates to signed decimal degrees
+func nmeaPosition(lat, latHemi, lon, lonHemi string) (float64, float64, error) {
+	convert := func(value string, degDigits int, negative bool) (float64, error) {
+		if len(value) < degDigits+2 {
+			return 0, fmt.Errorf("invalid NMEA coordinate %q", value)
+		}
+		deg, err := strconv.ParseFloat(value[:degDigits], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid NMEA coordinate %q", value)
+		}
+		min, err := strconv.ParseFloat(value[degDigits:], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid NMEA coordinate %q", value)
+		}
+		result := deg + min/60
+		if negative {
+			result = -result
+		}
+		return result, nil
+	}
+
+	latDeg, err := convert(lat, 2, latHemi == "S")
+	if err != nil {
+		return 0, 0, err
+	}
+	lonDeg, err := convert(lon, 3, lonHemi == "W")
+	if err != nil {
+		return 0, 0, err
+	}
+	return latDeg, lonDeg, nil
+}
+
+// GPSDClient reads fixes from a gpsd daemon's JSON protocol
+type GPSDClient struct {
+	fixCache
+	conn net.Conn
+}
+
+// gpsdTPV is a gpsd time-position-velocity report
+type gpsdTPV struct {
+	Class string    `json:"class"`
+	Mode  int       `json:"mode"`
+	Time  time.Time `json:"time"`
+	Lat   float64   `json:"lat"`
+	Lon   float64   `json:"lon"`
+	Alt   float64   `json:"alt"`
+}
+
+// DialGPSD connects to gpsd and enables JSON reports
+func DialGPSD(ctx context.Context, addr string) (*GPSDClient, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gpsd: %w", err)
+	}
+	if _, err := conn.Write([]byte(`?WATCH={"enable":true,"json":true};` + "\n")); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable gpsd reports: %w", err)
+	}
+	return &GPSDClient{conn: conn}, nil
+}
+
+// Run reads gpsd reports until the connection closes or ctx is cancelled
+func (g *GPSDClient) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		g.conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(g.conn)
+	for scanner.Scan() {
+		var tpv gpsdTPV
+		if err := json.Unmarshal(scanner.Bytes(), &tpv); err != nil || tpv.Class != "TPV" {
+			continue
+		}
+		// Mode 2 is a 2D fix and mode 3 a 3D fix
+		g.update(tpv.Mode >= 2, func(f *GPSFix) {
+			f.Latitude, f.Longitude, f.Altitude, f.Time = tpv.Lat, tpv.Lon, tpv.Alt, tpv.Time
+		})
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read gpsd reports: %w", err)
+	}
+	return io.EOF
+}
+
+// Close closes the gpsd connection
+func (g *GPSDClient) Close() error {
+	return g.conn.Close()
+}
+
+// Distance returns the great-circle distance between two points in meters
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// GeofenceConfig holds the enrolled location of a device
+type GeofenceConfig struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64       // Allowed distance in meters, default 100
+	MaxFixAge time.Duration // Older fixes are a sensor fault, default 10s
+
+	// Fixes above MaxHDOP or below MinSatellites are not usable, default 5
+	// and 4; receivers that do not report a value are not checked against it
+	MaxHDOP       float64
+	MinSatellites int
+	// Consecutive new fixes outside the radius before relocation tamper, default 3
+	ConfirmFixes int
+	// No usable fix for this long counts as tamper, e.g. a cut antenna or
+	// jamming; zero reports it as a sensor fault only
+	LossTamper time.Duration
+}
+
+// GeofenceSensor raises relocation tamper when the device leaves its enrolled location
+type GeofenceSensor struct {
+	mux      sync.Mutex
+	name     string
+	source   GPSSource
+	cfg      GeofenceConfig
+	enrolled bool
+
+	// Debounce and loss tracking
+	lastFix    time.Time // Receiver time of the last counted fix
+	outside    int       // Consecutive fixes outside the radius
+	lastUsable time.Time // When a usable fix was last seen
+}
+
+// NewGeofenceSensor creates a geofence sensor. A zero location is treated as
+// not enrolled until Enroll records the current fix.
+func NewGeofenceSensor(name string, source GPSSource, cfg GeofenceConfig) *GeofenceSensor {
+	if cfg.Radius == 0 {
+		cfg.Radius = defaultGeofenceRadius
+	}
+	if cfg.MaxFixAge == 0 {
+		cfg.MaxFixAge = defaultMaxFixAge
+	}
+	if cfg.MaxHDOP == 0 {
+		cfg.MaxHDOP = defaultMaxHDOP
+	}
+	if cfg.MinSatellites == 0 {
+		cfg.MinSatellites = defaultMinSatellites
+	}
+	if cfg.ConfirmFixes == 0 {
+		cfg.ConfirmFixes = defaultConfirmFixes
+	}
+	return &GeofenceSensor{
+		name:     name,
+		source:   source,
+		cfg:      cfg,
+		enrolled: cfg.Latitude != 0 || cfg.Longitude != 0,
+	}
+}
+
+func (g *GeofenceSensor) Name() string     { return g.name }
+func (g *GeofenceSensor) Kind() SensorKind { return SensorKindGeofence }
+
+// usableFix returns the latest fix if it is recent and accurate enough to trust
+func (g *GeofenceSensor) usableFix(cfg GeofenceConfig) (GPSFix, error) {
+	fix, err := g.source.LastFix()
+	if err != nil {
+		return GPSFix{}, err
+	}
+	if age := time.Since(fix.Received); age > cfg.MaxFixAge {
+		return GPSFix{}, fmt.Errorf("GPS fix is stale (%s old)", age.Round(time.Second))
+	}
+	if fix.HDOP > cfg.MaxHDOP {
+		return GPSFix{}, fmt.Errorf("GPS fix HDOP %.1f above %.1f", fix.HDOP, cfg.MaxHDOP)
+	}
+	if fix.Satellites > 0 && fix.Satellites < cfg.MinSatellites {
+		return GPSFix{}, fmt.Errorf("GPS fix uses %d satellites, need %d", fix.Satellites, cfg.MinSatellites)
+	}
+	return fix, nil
+}
+
+// Enroll records the current fix as the device location
+func (g *GeofenceSensor) Enroll() (GPSFix, error) {
+	g.mux.Lock()
+	cfg := g.cfg
+	g.mux.Unlock()
+
+	fix, err := g.usableFix(cfg)
+	if err != nil {
+		return GPSFix{}, fmt.Errorf("failed to enroll location: %w", err)
+	}
+
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	g.cfg.Latitude = fix.Latitude
+	g.cfg.Longitude = fix.Longitude
+	g.enrolled = true
+	g.outside = 0
+	return fix, nil
+}
+
+// Read compares the latest fix with the enrolled location. Relocation is
+// reported once ConfirmFixes consecutive new fixes lie outside the radius.
+func (g *GeofenceSensor) Read(ctx context.Context) (SensorReading, error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+
+	if !g.enrolled {
+		return SensorReading{}, fmt.Errorf("geofence location not enrolled")
+	}
+	now := time.Now()
+	if g.lastUsable.IsZero() {
+		g.lastUsable = now
+	}
+	fix, err := g.usableFix(g.cfg)
+	if err != nil {
+		lost := now.Sub(g.lastUsable)
+		if g.cfg.LossTamper > 0 && lost >= g.cfg.LossTamper {
+			return SensorReading{
+				Tampered: true,
+				Detail:   fmt.Sprintf("no usable GPS fix for %s: %v", lost.Round(time.Second), err),
+			}, nil
+		}
+		return SensorReading{}, err
+	}
+	g.lastUsable = now
+
+	// Sentences from one receiver epoch share a fix time and count once
+	epoch := fix.Time
+	if epoch.IsZero() {
+		epoch = fix.Received
+	}
+	distance := Distance(g.cfg.Latitude, g.cfg.Longitude, fix.Latitude, fix.Longitude)
+	if !epoch.Equal(g.lastFix) {
+		g.lastFix = epoch
+		if distance > g.cfg.Radius {
+			g.outside++
+		} else {
+			g.outside = 0
+		}
+	}
+	return SensorReading{
+		Tampered: g.outside >= g.cfg.ConfirmFixes,
+		Value:    distance,
+		Detail:   fmt.Sprintf("%.0fm from enrolled location", distance),
+		Data: map[string]interface{}{
+			"fix":        fix,
+			"distance_m": distance,
+			"radius_m":   g.cfg.Radius,
+		},
+	}, nil
+}
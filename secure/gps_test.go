@@ -0,0 +1,295 @@
+package secure
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// nmea wraps a sentence body with its checksum
+func nmea(body string) string {
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	return fmt.Sprintf("$%s*%02X", body, sum)
+}
+
+// staticFix is a GPS source returning a fixed position
+type staticFix struct {
+	fix GPSFix
+	err error
+}
+
+func (s *staticFix) LastFix() (GPSFix, error) { return s.fix, s.err }
+
+func TestNMEAReader(t *testing.T) {
+	t.Run("Parse Sentences", func(t *testing.T) {
+		stream := strings.Join([]string{
+			nmea("GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,"),
+			nmea("GPRMC,123520,A,4807.038,N,01131.000,W,022.4,084.4,230394,003.1,W"),
+			"$GPGGA,bad*00",
+		}, "\r\n")
+		reader := NewNMEAReader(strings.NewReader(stream))
+		if _, err := reader.LastFix(); err == nil {
+			t.Error("Expected error before first fix")
+		}
+		reader.Run(context.Background())
+
+		fix, err := reader.LastFix()
+		if err != nil {
+			t.Fatalf("Failed to get fix: %v", err)
+		}
+		if math.Abs(fix.Latitude-48.1173) > 1e-4 || math.Abs(fix.Longitude+11.5167) > 1e-4 {
+			t.Errorf("Unexpected position %f,%f", fix.Latitude, fix.Longitude)
+		}
+		if fix.Satellites != 8 || fix.Altitude != 545.4 {
+			t.Errorf("Expected GGA fields to be kept, got %+v", fix)
+		}
+		want := time.Date(1994, 3, 23, 12, 35, 20, 0, time.UTC)
+		if !fix.Time.Equal(want) {
+			t.Errorf("Expected time %v, got %v", want, fix.Time)
+		}
+	})
+
+	t.Run("Lost Fix", func(t *testing.T) {
+		stream := nmea("GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,") + "\n" +
+			nmea("GPRMC,123520,V,,,,,,,230394,,")
+		reader := NewNMEAReader(strings.NewReader(stream))
+		reader.Run(context.Background())
+		if _, err := reader.LastFix(); err == nil {
+			t.Error("Expected void RMC to invalidate fix")
+		}
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		if _, err := parseNMEA("$GPGGA,123519*00"); err == nil {
+			t.Error("Expected checksum mismatch")
+		}
+		if _, err := parseNMEA("$GPGGA,123519"); err == nil {
+			t.Error("Expected missing checksum error")
+		}
+	})
+}
+
+func TestGPSDClient(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Wait for the watch command before streaming reports
+		bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprintln(conn, `{"class":"VERSION","release":"3.25"}`)
+		fmt.Fprintln(conn, `{"class":"TPV","mode":1}`)
+		fmt.Fprintln(conn, `{"class":"TPV","mode":3,"time":"2024-05-01T10:00:00Z","lat":51.5,"lon":-0.12,"alt":20}`)
+	}()
+
+	ctx := context.Background()
+	client, err := DialGPSD(ctx, ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial gpsd: %v", err)
+	}
+	defer client.Close()
+	client.Run(ctx)
+
+	fix, err := client.LastFix()
+	if err != nil {
+		t.Fatalf("Failed to get fix: %v", err)
+	}
+	if fix.Latitude != 51.5 || fix.Longitude != -0.12 || fix.Altitude != 20 {
+		t.Errorf("Unexpected fix %+v", fix)
+	}
+}
+
+func TestGeofenceSensor(t *testing.T) {
+	ctx := context.Background()
+	source := &staticFix{fix: GPSFix{Latitude: 51.5, Longitude: -0.12, Received: time.Now()}}
+
+	t.Run("Enroll", func(t *testing.T) {
+		sensor := NewGeofenceSensor("site", source, GeofenceConfig{})
+		if _, err := sensor.Read(ctx); err == nil {
+			t.Error("Expected error before enrollment")
+		}
+		if _, err := sensor.Enroll(); err != nil {
+			t.Fatalf("Failed to enroll: %v", err)
+		}
+		reading, err := sensor.Read(ctx)
+		if err != nil {
+			t.Fatalf("Failed to read geofence: %v", err)
+		}
+		if reading.Tampered || reading.Value > 1 {
+			t.Errorf("Expected enrolled location to be inside fence, got %+v", reading)
+		}
+	})
+
+	t.Run("Relocation", func(t *testing.T) {
+		// 0.001 degrees of latitude is about 111m
+		sensor := NewGeofenceSensor("site", source, GeofenceConfig{Latitude: 51.501, Longitude: -0.12, ConfirmFixes: 1})
+		reading, err := sensor.Read(ctx)
+		if err != nil {
+			t.Fatalf("Failed to read geofence: %v", err)
+		}
+		if !reading.Tampered || math.Abs(reading.Value-111) > 1 {
+			t.Errorf("Expected relocation about 111m away, got %+v", reading)
+		}
+
+		sensor = NewGeofenceSensor("site", source, GeofenceConfig{Latitude: 51.501, Longitude: -0.12, Radius: 200, ConfirmFixes: 1})
+		if reading, _ := sensor.Read(ctx); reading.Tampered {
+			t.Error("Expected position inside wider radius")
+		}
+	})
+
+	t.Run("Debounce", func(t *testing.T) {
+		moving := &staticFix{fix: GPSFix{Latitude: 51.501, Longitude: -0.12, Received: time.Now()}}
+		sensor := NewGeofenceSensor("site", moving, GeofenceConfig{Latitude: 51.5, Longitude: -0.12})
+
+		// Repeated reads of the same fix count once
+		for i := 0; i < 3; i++ {
+			if reading, _ := sensor.Read(ctx); reading.Tampered {
+				t.Fatal("Expected a single outside fix to be ignored")
+			}
+		}
+		// A jump back inside resets the count
+		moving.fix = GPSFix{Latitude: 51.5, Longitude: -0.12, Received: time.Now().Add(time.Millisecond)}
+		sensor.Read(ctx)
+		for i := 1; i <= 3; i++ {
+			moving.fix = GPSFix{Latitude: 51.501, Longitude: -0.12, Received: time.Now().Add(time.Duration(i+1) * time.Millisecond)}
+			reading, err := sensor.Read(ctx)
+			if err != nil {
+				t.Fatalf("Failed to read geofence: %v", err)
+			}
+			if reading.Tampered != (i == 3) {
+				t.Errorf("Fix %d: expected tamper %v, got %v", i, i == 3, reading.Tampered)
+			}
+		}
+	})
+
+	t.Run("Receiver Epoch", func(t *testing.T) {
+		reader := NewNMEAReader(strings.NewReader(""))
+		sensor := NewGeofenceSensor("site", reader, GeofenceConfig{Latitude: 51.5, Longitude: -0.12, ConfirmFixes: 2})
+
+		// feed applies a GGA and RMC pair from one epoch, reading after each sentence
+		at := time.Now().UTC().Truncate(time.Second)
+		feed := func() SensorReading {
+			var reading SensorReading
+			for _, body := range []string{
+				"GPGGA," + at.Format("150405") + ",5130.060,N,00007.200,W,1,08,0.9,20.0,M,46.9,M,,",
+				"GPRMC," + at.Format("150405") + ",A,5130.060,N,00007.200,W,000.0,000.0," + at.Format("020106") + ",,",
+			} {
+				if err := reader.handleSentence(nmea(body)); err != nil {
+					t.Fatalf("Failed to handle sentence: %v", err)
+				}
+				var err error
+				if reading, err = sensor.Read(ctx); err != nil {
+					t.Fatalf("Failed to read geofence: %v", err)
+				}
+			}
+			at = at.Add(time.Second)
+			return reading
+		}
+
+		// 0.001 degrees of latitude outside the fence
+		if reading := feed(); reading.Tampered {
+			t.Error("Expected one epoch to count as a single outside fix")
+		}
+		if reading := feed(); !reading.Tampered {
+			t.Error("Expected relocation after two receiver epochs")
+		}
+	})
+
+	t.Run("Fix Quality", func(t *testing.T) {
+		poor := &staticFix{fix: GPSFix{Latitude: 52.0, Longitude: -0.12, HDOP: 12, Satellites: 8, Received: time.Now()}}
+		sensor := NewGeofenceSensor("site", poor, GeofenceConfig{Latitude: 51.5, Longitude: -0.12, ConfirmFixes: 1})
+		if _, err := sensor.Read(ctx); err == nil {
+			t.Error("Expected error for high HDOP")
+		}
+		poor.fix.HDOP, poor.fix.Satellites = 1.2, 3
+		if _, err := sensor.Read(ctx); err == nil {
+			t.Error("Expected error for too few satellites")
+		}
+		if _, err := sensor.Enroll(); err == nil {
+			t.Error("Expected enrollment to reject a poor fix")
+		}
+	})
+
+	t.Run("Fix Loss", func(t *testing.T) {
+		lost := &staticFix{fix: GPSFix{Latitude: 51.5, Longitude: -0.12, Received: time.Now()}}
+		sensor := NewGeofenceSensor("site", lost, GeofenceConfig{Latitude: 51.5, Longitude: -0.12, LossTamper: time.Minute})
+		if reading, err := sensor.Read(ctx); err != nil || reading.Tampered {
+			t.Fatalf("Expected inside reading, got %+v: %v", reading, err)
+		}
+
+		lost.err = errors.New("no GPS fix")
+		if _, err := sensor.Read(ctx); err == nil {
+			t.Error("Expected brief loss to be a sensor fault")
+		}
+		sensor.lastUsable = time.Now().Add(-2 * time.Minute)
+		reading, err := sensor.Read(ctx)
+		if err != nil || !reading.Tampered {
+			t.Errorf("Expected loss tamper, got %+v: %v", reading, err)
+		}
+	})
+
+	t.Run("Stale Fix", func(t *testing.T) {
+		stale := &staticFix{fix: GPSFix{Latitude: 51.5, Longitude: -0.12, Received: time.Now().Add(-time.Minute)}}
+		sensor := NewGeofenceSensor("site", stale, GeofenceConfig{Latitude: 51.5, Longitude: -0.12})
+		if _, err := sensor.Read(ctx); err == nil {
+			t.Error("Expected error for stale fix")
+		}
+	})
+
+	t.Run("Manager", func(t *testing.T) {
+		gpioCtrl, _ := newTestGPIO(t)
+		store := newMemStore()
+		moving := &staticFix{fix: GPSFix{Latitude: 51.5, Longitude: -0.12, Received: time.Now()}}
+		sensor := NewGeofenceSensor("site", moving, GeofenceConfig{Latitude: 51.5, Longitude: -0.12})
+		manager, err := New(Config{
+			GPIO:          gpioCtrl,
+			CaseSensor:    "case",
+			MotionSensor:  "motion",
+			VoltageSensor: "voltage",
+			DeviceID:      "test-device",
+			StateStore:    store,
+			Sensors:       []SecuritySensor{sensor},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create security manager: %v", err)
+		}
+
+		manager.checkSecurity(ctx)
+		for i := 0; i < 3; i++ {
+			moving.fix = GPSFix{Latitude: 52.0, Longitude: -0.12, Received: time.Now().Add(time.Duration(i) * time.Millisecond)}
+			manager.checkSecurity(ctx)
+		}
+
+		var details map[string]interface{}
+		for _, e := range store.events {
+			if e.Type == "tamper_detected" {
+				details, _ = e.Details.(map[string]interface{})
+			}
+		}
+		if details == nil {
+			t.Fatal("Expected tamper event for relocation")
+		}
+		if _, ok := details["fix"].(GPSFix); !ok {
+			t.Errorf("Expected fix in event details, got %v", details)
+		}
+		if d, _ := details["distance_m"].(float64); d < 50000 {
+			t.Errorf("Expected distance in event details, got %v", details["distance_m"])
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+)
+
+// sensorPins maps configured sensor pin names to sensor names
+func (m *Manager) sensorPins() map[string]string {
+	pins := make(map[string]string)
+	if m.caseSensor != "" {
+		pins[m.caseSensor] = SensorCase
+	}
+	if m.motionSensor != "" {
+		pins[m.motionSensor] = SensorMotion
+	}
+	if m.voltSensor != "" {
+		pins[m.voltSensor] = SensorVoltage
+	}
+	return pins
+}
+
+// enableInterrupts registers edge handlers on the configured sensor pins.
+// Interrupts are delivered while the GPIO controller's Monitor is running.
+func (m *Manager) enableInterrupts() error {
+	pins := m.sensorPins()
+	if len(pins) == 0 {
+		return fmt.Errorf("no sensor pins configured")
+	}
+
+	for pin, sensor := range pins {
+		sensor := sensor
+		err := m.gpio.EnableInterrupt(pin, gpio.InterruptConfig{
+			Edge:         gpio.Both,
+			DebounceTime: m.sensorDebounce,
+			Handler: func(_ string, high bool) {
+				m.handleSensorEdge(sensor, high, time.Now())
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to enable interrupt on %s sensor: %w", sensor, err)
+		}
+	}
+
+	m.interrupts = true
+	return nil
+}
+
+// isTamperLevel reports whether a sensor level indicates tampering
+func isTamperLevel(sensor string, high bool) bool {
+	if sensor == SensorVoltage {
+		return !high // Voltage sensor is high when voltage is normal
+	}
+	return high
+}
+
+// handleSensorEdge processes a sensor level reported by the GPIO interrupt handler
+func (m *Manager) handleSensorEdge(sensor string, high bool, at time.Time) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.lastInterrupt = at
+
+	// The GPIO layer reports levels, so only act on actual transitions
+	prev, seen := m.levels[sensor]
+	m.levels[sensor] = high
+	if seen && prev == high {
+		return
+	}
+
+	switch sensor {
+	case SensorCase:
+		m.state.CaseOpen = high
+	case SensorMotion:
+		m.state.MotionDetected = high
+	case SensorVoltage:
+		m.state.VoltageNormal = high
+	}
+
+	m.trackSensorLocked(sensor, isTamperLevel(sensor, high), at)
+	if isTamperLevel(sensor, high) {
+		m.tamperLocked(context.Background(), sensor, at)
+	} else if seen {
+		m.sensorClearedLocked(context.Background(), sensor, at)
+	}
+}
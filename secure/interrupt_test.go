@@ -0,0 +1,197 @@
+package secure
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/physic"
+)
+
+// mockSensorPin implements a GPIO pin driven by the test
+type mockSensorPin struct {
+	sync.Mutex
+	state bool
+	pull  gpio.Pull
+}
+
+func (m *mockSensorPin) String() string         { return "mock_sensor" }
+func (m *mockSensorPin) Halt() error            { return nil }
+func (m *mockSensorPin) Name() string           { return "MOCK_SENSOR" }
+func (m *mockSensorPin) Number() int            { return 0 }
+func (m *mockSensorPin) Function() string       { return "In/Out" }
+func (m *mockSensorPin) DefaultPull() gpio.Pull { return gpio.Float }
+func (m *mockSensorPin) In(pull gpio.Pull, edge gpio.Edge) error {
+	m.Lock()
+	defer m.Unlock()
+	m.pull = pull
+	return nil
+}
+func (m *mockSensorPin) Read() gpio.Level {
+	m.Lock()
+	defer m.Unlock()
+	if m.state {
+		return gpio.High
+	}
+	return gpio.Low
+}
+func (m *mockSensorPin) Out(l gpio.Level) error {
+	m.Lock()
+	defer m.Unlock()
+	m.state = l == gpio.High
+	return nil
+}
+func (m *mockSensorPin) Pull() gpio.Pull {
+	m.Lock()
+	defer m.Unlock()
+	return m.pull
+}
+func (m *mockSensorPin) PWM(duty gpio.Duty, f physic.Frequency) error { return nil }
+func (m *mockSensorPin) WaitForEdge(timeout time.Duration) bool       { return true }
+
+// sensorPins holds the mock pins backing a test security manager
+type sensorPins struct {
+	casePin, motionPin, voltagePin *mockSensorPin
+}
+
+// newTestGPIO creates a simulated controller with mock case, motion and voltage pins
+func newTestGPIO(t *testing.T) (*hw_gpio.Controller, sensorPins) {
+	t.Helper()
+	ctrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	pins := sensorPins{
+		casePin:    &mockSensorPin{},
+		motionPin:  &mockSensorPin{},
+		voltagePin: &mockSensorPin{state: true},
+	}
+	for name, pin := range map[string]*mockSensorPin{
+		"case":    pins.casePin,
+		"motion":  pins.motionPin,
+		"voltage": pins.voltagePin,
+	} {
+		if err := ctrl.ConfigurePin(name, pin, gpio.Float); err != nil {
+			t.Fatalf("Failed to configure %s pin: %v", name, err)
+		}
+	}
+	return ctrl, pins
+}
+
+func TestInterruptTamperDetection(t *testing.T) {
+	gpioCtrl, pins := newTestGPIO(t)
+
+	var (
+		notifications int
+		notifyMux     sync.Mutex
+	)
+	manager, err := New(Config{
+		GPIO:                gpioCtrl,
+		CaseSensor:          "case",
+		MotionSensor:        "motion",
+		VoltageSensor:       "voltage",
+		DeviceID:            "test-device",
+		SupervisionInterval: time.Hour,
+		OnTamper: func(TamperState) {
+			notifyMux.Lock()
+			notifications++
+			notifyMux.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+	if !manager.interrupts {
+		t.Fatal("Sensor interrupts not registered")
+	}
+	if interval := manager.pollInterval(); interval != defaultPollInterval {
+		t.Errorf("Poll relaxed before any interrupt was delivered: %v", interval)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = gpioCtrl.Monitor(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+
+	if interval := manager.pollInterval(); interval != time.Hour {
+		t.Errorf("Expected supervision poll once interrupts are delivered, got %v", interval)
+	}
+
+	t.Run("Brief Case Opening", func(t *testing.T) {
+		before := time.Now()
+		if err := pins.casePin.Out(gpio.High); err != nil {
+			t.Fatalf("Failed to open case: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if err := pins.casePin.Out(gpio.Low); err != nil {
+			t.Fatalf("Failed to close case: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+
+		state := manager.GetState()
+		if !state.Latched || state.LatchedBy != SensorCase {
+			t.Fatalf("Expected latched case tamper, got %+v", state)
+		}
+		if state.CaseOpen {
+			t.Error("Case still reported open after closing")
+		}
+		if state.LatchedAt.Before(before) || state.LatchedAt.After(time.Now()) {
+			t.Errorf("Edge timestamp %v outside opening window", state.LatchedAt)
+		}
+
+		notifyMux.Lock()
+		defer notifyMux.Unlock()
+		if notifications != 1 {
+			t.Errorf("Expected one tamper notification, got %d", notifications)
+		}
+	})
+
+	t.Run("Acknowledge", func(t *testing.T) {
+		if err := manager.Acknowledge(context.Background()); err != nil {
+			t.Fatalf("Failed to acknowledge: %v", err)
+		}
+		if state := manager.GetState(); state.Latched {
+			t.Error("Latch not cleared after acknowledgment")
+		}
+		if err := manager.Acknowledge(context.Background()); err == nil {
+			t.Error("Expected error acknowledging without latched event")
+		}
+		if err := manager.Arm(context.Background()); err != nil {
+			t.Fatalf("Failed to re-arm: %v", err)
+		}
+	})
+
+	t.Run("Supervision Poll", func(t *testing.T) {
+		cancel()
+		time.Sleep(10 * time.Millisecond)
+
+		if err := pins.voltagePin.Out(gpio.Low); err != nil {
+			t.Fatalf("Failed to drop voltage: %v", err)
+		}
+		if err := manager.checkSecurity(context.Background()); err != nil {
+			t.Fatalf("Security check failed: %v", err)
+		}
+		if state := manager.GetState(); !state.Latched || state.LatchedBy != SensorVoltage {
+			t.Errorf("Expected poll to latch voltage tamper, got %+v", state)
+		}
+	})
+}
+
+func TestInterruptsUnavailable(t *testing.T) {
+	gpioCtrl, _ := newTestGPIO(t)
+	store := newMemStore()
+	manager, err := New(Config{GPIO: gpioCtrl, DeviceID: "test-device", StateStore: store})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+	if manager.interrupts {
+		t.Error("Interrupts registered without sensor pins")
+	}
+	if store.count("interrupts_unavailable") != 1 {
+		t.Error("Interrupt fallback not recorded in the event log")
+	}
+}
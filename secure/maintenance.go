@@ -0,0 +1,260 @@
+package secure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned when a maintenance token fails verification
+var ErrInvalidToken = errors.New("invalid maintenance token")
+
+// Maintenance tokens are compact JWS tokens signed with EdDSA, so standard
+// JWT tooling can issue them
+const maintenanceTokenHeader = `{"alg":"EdDSA","typ":"JWT"}`
+
+// MaintenanceToken authorizes a technician to service one device for a limited window
+type MaintenanceToken struct {
+	ID         string // Unique token ID, each token opens one window
+	DeviceID   string // Device the token is bound to
+	Technician string // Who is performing the maintenance
+	Reason     string // Work order or description
+	IssuedAt   time.Time
+	NotBefore  time.Time // Optional start of the window
+	ExpiresAt  time.Time // End of the window
+}
+
+// maintenanceClaims is the JWT claim set of a maintenance token
+type maintenanceClaims struct {
+	ID         string `json:"jti"`
+	DeviceID   string `json:"aud"`
+	Technician string `json:"sub"`
+	Reason     string `json:"reason,omitempty"`
+	IssuedAt   int64  `json:"iat"`
+	NotBefore  int64  `json:"nbf,omitempty"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// IssueMaintenanceToken signs a maintenance token with an operator key
+func IssueMaintenanceToken(priv ed25519.PrivateKey, tok MaintenanceToken) (string, error) {
+	if tok.ID == "" || tok.DeviceID == "" || tok.Technician == "" {
+		return "", fmt.Errorf("token ID, device ID and technician are required")
+	}
+	if tok.ExpiresAt.IsZero() {
+		return "", fmt.Errorf("token expiry is required")
+	}
+	if tok.IssuedAt.IsZero() {
+		tok.IssuedAt = time.Now()
+	}
+
+	claims := maintenanceClaims{
+		ID:         tok.ID,
+		DeviceID:   tok.DeviceID,
+		Technician: tok.Technician,
+		Reason:     tok.Reason,
+		IssuedAt:   tok.IssuedAt.Unix(),
+		ExpiresAt:  tok.ExpiresAt.Unix(),
+	}
+	if !tok.NotBefore.IsZero() {
+		claims.NotBefore = tok.NotBefore.Unix()
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token claims: %w", err)
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString([]byte(maintenanceTokenHeader)) + "." + enc.EncodeToString(payload)
+	sig := ed25519.Sign(priv, []byte(signingInput))
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+// ParseMaintenanceToken verifies a token signature against the trusted keys
+// and decodes its claims. Device binding and validity window are checked by
+// the manager when the token is used.
+func ParseMaintenanceToken(token string, keys []ed25519.PublicKey) (MaintenanceToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return MaintenanceToken{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	enc := base64.RawURLEncoding
+	header, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return MaintenanceToken{}, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil || h.Alg != "EdDSA" {
+		return MaintenanceToken{}, fmt.Errorf("%w: unsupported algorithm", ErrInvalidToken)
+	}
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return MaintenanceToken{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, signingInput, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return MaintenanceToken{}, fmt.Errorf("%w: signature not trusted", ErrInvalidToken)
+	}
+
+	payload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return MaintenanceToken{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	var claims maintenanceClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return MaintenanceToken{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	if claims.ID == "" {
+		// Replay protection is keyed by token ID
+		return MaintenanceToken{}, fmt.Errorf("%w: missing token ID", ErrInvalidToken)
+	}
+
+	tok := MaintenanceToken{
+		ID:         claims.ID,
+		DeviceID:   claims.DeviceID,
+		Technician: claims.Technician,
+		Reason:     claims.Reason,
+		IssuedAt:   time.Unix(claims.IssuedAt, 0),
+		ExpiresAt:  time.Unix(claims.ExpiresAt, 0),
+	}
+	if claims.NotBefore != 0 {
+		tok.NotBefore = time.Unix(claims.NotBefore, 0)
+	}
+	return tok, nil
+}
+
+// StartMaintenance verifies a signed maintenance token and suspends alarm
+// escalation until the token expires or ExitMaintenance is called. Sensor
+// changes during the window are logged as maintenance activity.
+func (m *Manager) StartMaintenance(ctx context.Context, token string) (MaintenanceToken, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if len(m.maintenanceKeys) == 0 {
+		return MaintenanceToken{}, fmt.Errorf("no maintenance keys configured")
+	}
+	tok, err := ParseMaintenanceToken(token, m.maintenanceKeys)
+	if err != nil {
+		m.logEventLocked(ctx, "maintenance_rejected", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return MaintenanceToken{}, err
+	}
+
+	now := time.Now()
+	var reject error
+	switch {
+	case tok.DeviceID != m.deviceID:
+		reject = fmt.Errorf("%w: issued for device %s", ErrInvalidToken, tok.DeviceID)
+	case !tok.NotBefore.IsZero() && now.Before(tok.NotBefore):
+		reject = fmt.Errorf("%w: not valid until %s", ErrInvalidToken, tok.NotBefore.Format(time.RFC3339))
+	case !now.Before(tok.ExpiresAt):
+		reject = fmt.Errorf("%w: expired at %s", ErrInvalidToken, tok.ExpiresAt.Format(time.RFC3339))
+	case m.tokenUsedLocked(tok.ID):
+		reject = fmt.Errorf("%w: token %s already used", ErrInvalidToken, tok.ID)
+	case m.state.Mode == ModeAlarm:
+		reject = fmt.Errorf("alarm must be acknowledged before maintenance")
+	}
+	if reject != nil {
+		m.logEventLocked(ctx, "maintenance_rejected", map[string]interface{}{
+			"token_id":   tok.ID,
+			"technician": tok.Technician,
+			"error":      reject.Error(),
+		})
+		return MaintenanceToken{}, reject
+	}
+
+	m.useTokenLocked(tok, now)
+	m.state.MaintenanceID = tok.ID
+	m.state.MaintenanceBy = tok.Technician
+	m.state.MaintenanceUntil = tok.ExpiresAt
+	m.logEventLocked(ctx, "maintenance_started", map[string]interface{}{
+		"token_id":   tok.ID,
+		"technician": tok.Technician,
+		"reason":     tok.Reason,
+		"expires_at": tok.ExpiresAt,
+	})
+
+	if m.state.Mode == ModeMaintenance {
+		// Extending an open window only replaces the token
+		return tok, m.persistLocked(ctx)
+	}
+	return tok, m.transitionLocked(ctx, ModeMaintenance, fmt.Sprintf("maintenance by %s", tok.Technician))
+}
+
+// tokenUsedLocked reports whether a token ID was already used - must be called with lock held
+func (m *Manager) tokenUsedLocked(id string) bool {
+	_, used := m.state.UsedTokens[id]
+	return used
+}
+
+// useTokenLocked records a used token until it expires and forgets expired
+// ones - must be called with lock held
+func (m *Manager) useTokenLocked(tok MaintenanceToken, now time.Time) {
+	used := map[string]time.Time{tok.ID: tok.ExpiresAt}
+	for id, expires := range m.state.UsedTokens {
+		if now.Before(expires) {
+			used[id] = expires
+		}
+	}
+	m.state.UsedTokens = used
+}
+
+// maintenanceDetailsLocked adds the open maintenance window to event details - must be called with lock held
+func (m *Manager) maintenanceDetailsLocked(details map[string]interface{}) {
+	if m.state.MaintenanceID != "" {
+		details["token_id"] = m.state.MaintenanceID
+		details["technician"] = m.state.MaintenanceBy
+	}
+}
+
+// sensorClearedLocked records a sensor returning to normal during maintenance - must be called with lock held
+func (m *Manager) sensorClearedLocked(ctx context.Context, sensor string, at time.Time) {
+	if m.state.Mode != ModeMaintenance {
+		return
+	}
+	details := map[string]interface{}{
+		"sensor":    sensor,
+		"edge_time": at,
+		"active":    false,
+	}
+	m.maintenanceDetailsLocked(details)
+	m.logEventLocked(ctx, "maintenance_activity", details)
+}
+
+// checkMaintenanceLocked re-arms the system when the maintenance window expires - must be called with lock held
+func (m *Manager) checkMaintenanceLocked(ctx context.Context, now time.Time) {
+	if m.state.Mode != ModeMaintenance || m.state.MaintenanceUntil.IsZero() || now.Before(m.state.MaintenanceUntil) {
+		return
+	}
+
+	m.logEventLocked(ctx, "maintenance_expired", map[string]interface{}{
+		"token_id":   m.state.MaintenanceID,
+		"technician": m.state.MaintenanceBy,
+		"expired_at": m.state.MaintenanceUntil,
+	})
+	if err := m.transitionLocked(ctx, ModeArmed, "maintenance window expired"); err != nil {
+		// Log but don't fail on state persistence error
+		fmt.Printf("Failed to persist security state: %v\n", err)
+	}
+
+	// A sensor left active when the window closes raises the alarm
+	if active := m.state.activeSensors(); len(active) > 0 {
+		m.tamperLocked(ctx, active[0], now)
+	}
+}
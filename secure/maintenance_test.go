@@ -0,0 +1,210 @@
+package secure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+)
+
+func TestMaintenanceToken(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	otherPub, otherPriv, _ := ed25519.GenerateKey(nil)
+
+	tok := MaintenanceToken{
+		ID:         "wo-1",
+		DeviceID:   "test-device",
+		Technician: "tech-7",
+		Reason:     "fan replacement",
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	signed, err := IssueMaintenanceToken(priv, tok)
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	t.Run("Verify", func(t *testing.T) {
+		parsed, err := ParseMaintenanceToken(signed, []ed25519.PublicKey{otherPub, pub})
+		if err != nil {
+			t.Fatalf("Failed to parse token: %v", err)
+		}
+		if parsed.ID != tok.ID || parsed.Technician != tok.Technician || parsed.ExpiresAt.Unix() != tok.ExpiresAt.Unix() {
+			t.Errorf("Unexpected claims %+v", parsed)
+		}
+	})
+
+	t.Run("Untrusted Key", func(t *testing.T) {
+		forged, _ := IssueMaintenanceToken(otherPriv, tok)
+		if _, err := ParseMaintenanceToken(forged, []ed25519.PublicKey{pub}); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("Missing ID", func(t *testing.T) {
+		enc := base64.RawURLEncoding
+		payload, _ := json.Marshal(maintenanceClaims{
+			DeviceID: "test-device", Technician: "tech-7", ExpiresAt: tok.ExpiresAt.Unix(),
+		})
+		signingInput := enc.EncodeToString([]byte(maintenanceTokenHeader)) + "." + enc.EncodeToString(payload)
+		unnamed := signingInput + "." + enc.EncodeToString(ed25519.Sign(priv, []byte(signingInput)))
+		if _, err := ParseMaintenanceToken(unnamed, []ed25519.PublicKey{pub}); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken for token without ID, got %v", err)
+		}
+	})
+
+	t.Run("Tampered Claims", func(t *testing.T) {
+		other, _ := IssueMaintenanceToken(priv, MaintenanceToken{
+			ID: "wo-1", DeviceID: "other-device", Technician: "tech-7", ExpiresAt: tok.ExpiresAt,
+		})
+		parts, otherParts := strings.Split(signed, "."), strings.Split(other, ".")
+		spliced := parts[0] + "." + otherParts[1] + "." + parts[2]
+		if _, err := ParseMaintenanceToken(spliced, []ed25519.PublicKey{pub}); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got %v", err)
+		}
+	})
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	gpioCtrl, pins := newTestGPIO(t)
+	store := newMemStore()
+
+	var tampers int
+	manager, err := New(Config{
+		GPIO:            gpioCtrl,
+		CaseSensor:      "case",
+		MotionSensor:    "motion",
+		VoltageSensor:   "voltage",
+		DeviceID:        "test-device",
+		StateStore:      store,
+		MaintenanceKeys: []ed25519.PublicKey{pub},
+		OnTamper:        func(TamperState) { tampers++ },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+
+	issue := func(id, device string, ttl time.Duration) string {
+		signed, err := IssueMaintenanceToken(priv, MaintenanceToken{
+			ID: id, DeviceID: device, Technician: "tech-7", ExpiresAt: time.Now().Add(ttl),
+		})
+		if err != nil {
+			t.Fatalf("Failed to issue token: %v", err)
+		}
+		return signed
+	}
+	setCase := func(open bool) {
+		level := gpio.Low
+		if open {
+			level = gpio.High
+		}
+		pins.casePin.Out(level)
+		if err := manager.checkSecurity(ctx); err != nil {
+			t.Fatalf("Security check failed: %v", err)
+		}
+	}
+
+	t.Run("Rejected Tokens", func(t *testing.T) {
+		if _, err := manager.StartMaintenance(ctx, issue("wo-0", "other-device", time.Hour)); err == nil {
+			t.Error("Expected token for another device to be rejected")
+		}
+		if _, err := manager.StartMaintenance(ctx, issue("wo-0", "test-device", -time.Minute)); err == nil {
+			t.Error("Expected expired token to be rejected")
+		}
+		if store.count("maintenance_rejected") != 2 {
+			t.Errorf("Expected rejected tokens to be logged, got %d", store.count("maintenance_rejected"))
+		}
+		if manager.GetState().Mode != ModeArmed {
+			t.Error("Rejected token changed the security mode")
+		}
+	})
+
+	t.Run("Suppressed Alarm", func(t *testing.T) {
+		token := issue("wo-1", "test-device", time.Hour)
+		if _, err := manager.StartMaintenance(ctx, token); err != nil {
+			t.Fatalf("Failed to start maintenance: %v", err)
+		}
+		setCase(true)
+		setCase(false)
+
+		state := manager.GetState()
+		if state.Mode != ModeMaintenance || state.Latched || tampers != 0 {
+			t.Errorf("Expected alarm to be suppressed, got %s", state.Mode)
+		}
+		if state.MaintenanceBy != "tech-7" {
+			t.Errorf("Expected technician in state, got %q", state.MaintenanceBy)
+		}
+		if store.count("maintenance_activity") != 2 {
+			t.Errorf("Expected open and close logged as maintenance activity, got %d", store.count("maintenance_activity"))
+		}
+
+		if err := manager.ExitMaintenance(ctx); err != nil {
+			t.Fatalf("Failed to exit maintenance: %v", err)
+		}
+		if _, err := manager.StartMaintenance(ctx, token); err == nil {
+			t.Error("Expected reused token to be rejected")
+		}
+		if state := manager.GetState(); state.MaintenanceID != "" {
+			t.Error("Maintenance window not cleared on exit")
+		}
+	})
+
+	t.Run("Replay After Restart", func(t *testing.T) {
+		restarted, err := New(Config{
+			GPIO:            gpioCtrl,
+			CaseSensor:      "case",
+			MotionSensor:    "motion",
+			VoltageSensor:   "voltage",
+			DeviceID:        "test-device",
+			StateStore:      store,
+			MaintenanceKeys: []ed25519.PublicKey{pub},
+		})
+		if err != nil {
+			t.Fatalf("Failed to restart security manager: %v", err)
+		}
+		if _, err := restarted.StartMaintenance(ctx, issue("wo-1", "test-device", time.Hour)); err == nil {
+			t.Error("Expected token used before restart to be rejected")
+		}
+
+		// Expired entries are dropped when the next token is used
+		restarted.mux.Lock()
+		restarted.state.UsedTokens = map[string]time.Time{"wo-1": time.Now().Add(-time.Minute)}
+		restarted.useTokenLocked(MaintenanceToken{ID: "wo-9", ExpiresAt: time.Now().Add(time.Hour)}, time.Now())
+		used := restarted.state.UsedTokens
+		restarted.mux.Unlock()
+		if _, ok := used["wo-1"]; ok || len(used) != 1 {
+			t.Errorf("Expected expired token ID to be forgotten, got %v", used)
+		}
+	})
+
+	t.Run("Window Expiry", func(t *testing.T) {
+		if _, err := manager.StartMaintenance(ctx, issue("wo-2", "test-device", time.Hour)); err != nil {
+			t.Fatalf("Failed to start maintenance: %v", err)
+		}
+		setCase(true)
+
+		// Close the window as if the token had expired
+		manager.mux.Lock()
+		manager.state.MaintenanceUntil = time.Now().Add(-time.Second)
+		manager.mux.Unlock()
+		setCase(true)
+
+		state := manager.GetState()
+		if state.Mode != ModeAlarm || state.LatchedBy != SensorCase {
+			t.Errorf("Expected alarm for case left open after expiry, got %s", state.Mode)
+		}
+		if store.count("maintenance_expired") != 1 || tampers != 1 {
+			t.Errorf("Expected expiry and one tamper, got %d and %d", store.count("maintenance_expired"), tampers)
+		}
+	})
+}
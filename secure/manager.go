@@ -2,8 +2,10 @@ package secure
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/wrale/wrale-fleet-metal-hw/gpio"
 )
@@ -26,7 +28,30 @@ type Manager struct {
 	stateStore StateStore
 
 	// Callbacks for security events
-	onTamper func(TamperState)
+	onTamper     func(TamperState)
+	onModeChange func(ModeTransition)
+
+	// Interrupt-driven detection
+	supervisionInterval time.Duration
+	sensorDebounce      time.Duration
+	interrupts          bool
+	lastInterrupt       time.Time
+	levels              map[string]bool
+
+	// Zeroization policy and trigger tracking
+	zeroize      *ZeroizePolicy
+	zeroizeFired bool
+	activeSince  map[string]time.Time
+
+	// Tamper measurement
+	tpm *TPM
+
+	// Registered sensors in registration order
+	sensors           []SecuritySensor
+	sensorFaultTamper time.Duration
+
+	// Maintenance token verification
+	maintenanceKeys []ed25519.PublicKey
 }
 
 // New creates a new security manager
@@ -34,18 +59,58 @@ func New(cfg Config) (*Manager, error) {
 	if cfg.GPIO == nil {
 		return nil, fmt.Errorf("GPIO controller is required")
 	}
+	if cfg.DeviceID == "" && cfg.TPM != nil {
+		cfg.DeviceID = cfg.TPM.Identity().ID
+	}
 	if cfg.DeviceID == "" {
 		return nil, fmt.Errorf("device ID is required")
 	}
 
+	// Set defaults
+	if cfg.SupervisionInterval == 0 {
+		cfg.SupervisionInterval = defaultSupervisionInterval
+	}
+	if cfg.SensorDebounce == 0 {
+		cfg.SensorDebounce = defaultSensorDebounce
+	}
+	if cfg.InitialMode == "" {
+		cfg.InitialMode = ModeArmed
+	}
+	if cfg.Zeroize != nil {
+		policy := *cfg.Zeroize
+		if len(policy.Sensors) == 0 {
+			policy.Sensors = []string{SensorCase}
+		}
+		cfg.Zeroize = &policy
+	}
+
 	m := &Manager{
-		gpio:         cfg.GPIO,
-		caseSensor:   cfg.CaseSensor,
-		motionSensor: cfg.MotionSensor,
-		voltSensor:   cfg.VoltageSensor,
-		deviceID:     cfg.DeviceID,
-		stateStore:   cfg.StateStore,
-		onTamper:     cfg.OnTamper,
+		gpio:                cfg.GPIO,
+		caseSensor:          cfg.CaseSensor,
+		motionSensor:        cfg.MotionSensor,
+		voltSensor:          cfg.VoltageSensor,
+		deviceID:            cfg.DeviceID,
+		stateStore:          cfg.StateStore,
+		onTamper:            cfg.OnTamper,
+		onModeChange:        cfg.OnModeChange,
+		supervisionInterval: cfg.SupervisionInterval,
+		sensorDebounce:      cfg.SensorDebounce,
+		levels:              make(map[string]bool),
+		zeroize:             cfg.Zeroize,
+		activeSince:         make(map[string]time.Time),
+		tpm:                 cfg.TPM,
+		sensorFaultTamper:   cfg.SensorFaultTamper,
+		maintenanceKeys:     cfg.MaintenanceKeys,
+		state: TamperState{
+			Mode:          cfg.InitialMode,
+			VoltageNormal: true,
+		},
+	}
+
+	for _, sensor := range cfg.Sensors {
+		if err := m.registerSensorLocked(sensor); err != nil {
+			return nil, err
+		}
 	}
 
 	// Load last known state if store is available
@@ -54,6 +119,23 @@ func New(cfg Config) (*Manager, error) {
 			m.state = state
 		}
 	}
+	if m.state.Mode == "" {
+		m.state.Mode = cfg.InitialMode
+	}
+	// Never repeat a wipe across restarts
+	m.zeroizeFired = m.state.Zeroized
+
+	// PCRs reset on reboot, so re-measure a persisted latched alarm
+	if m.state.Latched {
+		m.measureTamperLocked(m.state.LatchedBy, m.state.LatchedAt)
+	}
+
+	// Register sensor interrupts, falling back to polling when unavailable
+	if err := m.enableInterrupts(); err != nil {
+		m.logEventLocked(context.Background(), "interrupts_unavailable", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
 
 	return m, nil
 }
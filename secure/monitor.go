@@ -6,9 +6,11 @@ import (
 	"time"
 )
 
-// Monitor starts continuous security monitoring
+// Monitor starts continuous security monitoring. Sensor interrupts provide
+// detection; the poll supervises them and is the primary path without interrupts.
 func (m *Manager) Monitor(ctx context.Context) error {
-	ticker := time.NewTicker(100 * time.Millisecond)
+	interval := defaultPollInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -19,10 +21,27 @@ func (m *Manager) Monitor(ctx context.Context) error {
 			if err := m.checkSecurity(ctx); err != nil {
 				return fmt.Errorf("security check failed: %w", err)
 			}
+			if next := m.pollInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
 
+// pollInterval returns the supervision interval while sensor interrupts are
+// being delivered, and the fast poll until the first delivery or once
+// deliveries stop, e.g. when the GPIO controller's Monitor is not running
+func (m *Manager) pollInterval() time.Duration {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	if m.interrupts && !m.lastInterrupt.IsZero() && time.Since(m.lastInterrupt) < m.supervisionInterval {
+		return m.supervisionInterval
+	}
+	return defaultPollInterval
+}
+
 // checkSecurity performs a single security check
 func (m *Manager) checkSecurity(ctx context.Context) error {
 	m.mux.Lock()
@@ -46,42 +65,47 @@ func (m *Manager) checkSecurity(ctx context.Context) error {
 		return fmt.Errorf("failed to check voltage sensor: %w", err)
 	}
 
-	// Update state
-	newState := TamperState{
-		CaseOpen:       caseOpen,
-		MotionDetected: motion,
-		VoltageNormal:  voltageOK,
-		LastCheck:      time.Now(),
+	// Update sensor readings, keeping mode and latched alarm
+	prev := m.state
+	m.state.CaseOpen = caseOpen
+	m.state.MotionDetected = motion
+	m.state.VoltageNormal = voltageOK
+	m.state.LastCheck = time.Now()
+
+	// Handle tamper transitions missed by interrupts
+	if caseOpen && !prev.CaseOpen {
+		m.tamperLocked(ctx, SensorCase, m.state.LastCheck)
+	}
+	if motion && !prev.MotionDetected {
+		m.tamperLocked(ctx, SensorMotion, m.state.LastCheck)
+	}
+	if !voltageOK && prev.VoltageNormal {
+		m.tamperLocked(ctx, SensorVoltage, m.state.LastCheck)
+	}
+	if !caseOpen && prev.CaseOpen {
+		m.sensorClearedLocked(ctx, SensorCase, m.state.LastCheck)
+	}
+	if !motion && prev.MotionDetected {
+		m.sensorClearedLocked(ctx, SensorMotion, m.state.LastCheck)
+	}
+	if voltageOK && !prev.VoltageNormal {
+		m.sensorClearedLocked(ctx, SensorVoltage, m.state.LastCheck)
 	}
 
-	// Check for tamper conditions
-	if caseOpen || motion || !voltageOK {
-		if m.onTamper != nil {
-			m.onTamper(newState)
-		}
+	// Poll registered sensors
+	m.pollSensorsLocked(ctx, m.state.LastCheck)
 
-		// Log tamper event if store is available
-		if m.stateStore != nil {
-			eventDetails := map[string]interface{}{
-				"case_open":       caseOpen,
-				"motion_detected": motion,
-				"voltage_normal":  voltageOK,
-			}
-			if err := m.stateStore.LogEvent(ctx, m.deviceID, "tamper_detected", eventDetails); err != nil {
-				// Log but don't fail on event logging error
-				fmt.Printf("Failed to log tamper event: %v", err)
-			}
-		}
-	}
+	m.trackSensorLocked(SensorCase, caseOpen, m.state.LastCheck)
+	m.trackSensorLocked(SensorMotion, motion, m.state.LastCheck)
+	m.trackSensorLocked(SensorVoltage, !voltageOK, m.state.LastCheck)
+	m.checkMaintenanceLocked(ctx, m.state.LastCheck)
+	m.checkZeroizeLocked(ctx, m.state.LastCheck)
 
 	// Persist state if store is available
-	if m.stateStore != nil {
-		if err := m.stateStore.SaveState(ctx, m.deviceID, newState); err != nil {
-			// Log but don't fail on state persistence error
-			fmt.Printf("Failed to persist security state: %v", err)
-		}
+	if err := m.persistLocked(ctx); err != nil {
+		// Log but don't fail on state persistence error
+		fmt.Printf("%v\n", err)
 	}
 
-	m.state = newState
 	return nil
 }
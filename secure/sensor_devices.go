@@ -0,0 +1,418 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/i2c"
+)
+
+// Accelerometer addressing and scaling
+const (
+	ADXL345DefaultAddr = 0x53
+	LIS3DHDefaultAddr  = 0x18
+
+	adxl345DeviceID = 0xE5
+	adxl345Scale    = 0.0039 // g per LSB in full resolution mode
+	lis3dhWhoAmI    = 0x33
+	lis3dhScale     = 0.001 // g per LSB in 12-bit high resolution mode at ±2g
+
+	// Activity threshold scaling for hardware shock latching
+	adxl345ThreshScale = 0.0625 // g per LSB of THRESH_ACT
+	lis3dhThreshScale  = 0.016  // g per LSB of INT1_THS at ±2g
+
+	// Default shock and tilt thresholds
+	defaultShockThreshold = 0.5  // g deviation from rest
+	defaultTiltThreshold  = 15.0 // Degrees from installed orientation
+)
+
+// gpioSensor is a digital tamper input that reads high when tampered
+type gpioSensor struct {
+	name string
+	kind SensorKind
+	gpio *gpio.Controller
+	pin  string
+}
+
+// NewCaseSwitch creates an additional case switch, high when open like the built-in case sensor
+func NewCaseSwitch(name string, ctrl *gpio.Controller, pin string) SecuritySensor {
+	return &gpioSensor{name: name, kind: SensorKindCase, gpio: ctrl, pin: pin}
+}
+
+// NewMeshLoop creates a continuous mesh-wire loop sensor. The loop ties a
+// pulled-up input to ground, so a cut or lifted mesh reads high.
+func NewMeshLoop(name string, ctrl *gpio.Controller, pin string) SecuritySensor {
+	return &gpioSensor{name: name, kind: SensorKindMesh, gpio: ctrl, pin: pin}
+}
+
+func (s *gpioSensor) Name() string     { return s.name }
+func (s *gpioSensor) Kind() SensorKind { return s.kind }
+
+func (s *gpioSensor) Read(ctx context.Context) (SensorReading, error) {
+	high, err := s.gpio.GetPinState(s.pin)
+	if err != nil {
+		return SensorReading{}, err
+	}
+	reading := SensorReading{Tampered: high}
+	if high {
+		reading.Value = 1
+	}
+	return reading, nil
+}
+
+// LightSensor detects light entering the enclosure with a photodiode on an ADC
+type LightSensor struct {
+	name      string
+	path      string
+	threshold float64
+}
+
+// NewLightSensor creates a light sensor reading raw counts from a sysfs ADC
+// channel; the enclosure is considered open at or above threshold
+func NewLightSensor(name string, adcPath string, threshold float64) *LightSensor {
+	return &LightSensor{name: name, path: adcPath, threshold: threshold}
+}
+
+func (s *LightSensor) Name() string     { return s.name }
+func (s *LightSensor) Kind() SensorKind { return SensorKindLight }
+
+func (s *LightSensor) Read(ctx context.Context) (SensorReading, error) {
+	value, err := readADC(s.path)
+	if err != nil {
+		return SensorReading{}, err
+	}
+	return SensorReading{
+		Tampered: value >= s.threshold,
+		Value:    value,
+	}, nil
+}
+
+// readADC reads a raw value from a sysfs ADC channel
+func readADC(path string) (float64, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read ADC: %w", err)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ADC value: %w", err)
+	}
+	return value, nil
+}
+
+// Acceleration is a three-axis acceleration sample in g
+type Acceleration struct {
+	X, Y, Z float64
+}
+
+// magnitude returns the vector length in g
+func (a Acceleration) magnitude() float64 {
+	return math.Sqrt(a.X*a.X + a.Y*a.Y + a.Z*a.Z)
+}
+
+// angleTo returns the angle between two acceleration vectors in degrees
+func (a Acceleration) angleTo(b Acceleration) float64 {
+	mag := a.magnitude() * b.magnitude()
+	if mag == 0 {
+		return 0
+	}
+	cos := (a.X*b.X + a.Y*b.Y + a.Z*b.Z) / mag
+	return math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+}
+
+// Accelerometer reads three-axis acceleration
+type Accelerometer interface {
+	ReadAcceleration() (Acceleration, error)
+}
+
+// ShockLatcher is implemented by accelerometers that latch shocks in hardware
+// between reads, so knocks shorter than the poll interval are not missed
+type ShockLatcher interface {
+	// ArmShock starts latching accelerations deviating from rest by more than threshold g
+	ArmShock(threshold float64) error
+	// ShockLatched reports whether a shock was latched since the last call and clears the latch
+	ShockLatched() (bool, error)
+}
+
+// shockThresholdRegister converts a threshold in g to a register value in [1, max]
+func shockThresholdRegister(threshold, scale float64, max byte) byte {
+	value := math.Round(threshold / scale)
+	if value < 1 {
+		return 1
+	}
+	if value > float64(max) {
+		return max
+	}
+	return byte(value)
+}
+
+// ADXL345 reads an Analog Devices ADXL345 accelerometer over I2C
+type ADXL345 struct {
+	mux         sync.Mutex
+	dev         i2c.Dev
+	initialized bool
+}
+
+// NewADXL345 creates an ADXL345 on the given bus, using the default address when addr is zero
+func NewADXL345(bus i2c.Bus, addr uint16) *ADXL345 {
+	if addr == 0 {
+		addr = ADXL345DefaultAddr
+	}
+	return &ADXL345{dev: i2c.Dev{Bus: bus, Addr: addr}}
+}
+
+// init verifies the device ID and starts measurement at ±2g full resolution
+func (a *ADXL345) init() error {
+	id := make([]byte, 1)
+	if err := a.dev.Tx([]byte{0x00}, id); err != nil {
+		return fmt.Errorf("failed to read ADXL345 device ID: %w", err)
+	}
+	if id[0] != adxl345DeviceID {
+		return fmt.Errorf("unexpected ADXL345 device ID 0x%02x", id[0])
+	}
+	for _, reg := range [][]byte{
+		{0x31, 0x08}, // DATA_FORMAT: full resolution, ±2g
+		{0x2C, 0x0A}, // BW_RATE: 100Hz
+		{0x2D, 0x08}, // POWER_CTL: measure
+	} {
+		if err := a.dev.Tx(reg, nil); err != nil {
+			return fmt.Errorf("failed to configure ADXL345: %w", err)
+		}
+	}
+	a.initialized = true
+	return nil
+}
+
+// ReadAcceleration reads the current acceleration
+func (a *ADXL345) ReadAcceleration() (Acceleration, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if !a.initialized {
+		if err := a.init(); err != nil {
+			return Acceleration{}, err
+		}
+	}
+
+	buf := make([]byte, 6)
+	if err := a.dev.Tx([]byte{0x32}, buf); err != nil {
+		return Acceleration{}, fmt.Errorf("failed to read ADXL345 data: %w", err)
+	}
+	axis := func(i int) float64 {
+		return float64(int16(uint16(buf[i])|uint16(buf[i+1])<<8)) * adxl345Scale
+	}
+	return Acceleration{X: axis(0), Y: axis(2), Z: axis(4)}, nil
+}
+
+// ArmShock enables AC-coupled activity detection on all axes, latched in INT_SOURCE
+func (a *ADXL345) ArmShock(threshold float64) error {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if !a.initialized {
+		if err := a.init(); err != nil {
+			return err
+		}
+	}
+	for _, reg := range [][]byte{
+		{0x24, shockThresholdRegister(threshold, adxl345ThreshScale, 0xFF)}, // THRESH_ACT
+		{0x27, 0xF0}, // ACT_INACT_CTL: AC-coupled activity on X, Y and Z
+		{0x2E, 0x10}, // INT_ENABLE: activity
+	} {
+		if err := a.dev.Tx(reg, nil); err != nil {
+			return fmt.Errorf("failed to arm ADXL345 shock detection: %w", err)
+		}
+	}
+	return nil
+}
+
+// ShockLatched reads and clears the activity flag in INT_SOURCE
+func (a *ADXL345) ShockLatched() (bool, error) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	src := make([]byte, 1)
+	if err := a.dev.Tx([]byte{0x30}, src); err != nil {
+		return false, fmt.Errorf("failed to read ADXL345 interrupt source: %w", err)
+	}
+	return src[0]&0x10 != 0, nil
+}
+
+// LIS3DH reads an ST LIS3DH accelerometer over I2C
+type LIS3DH struct {
+	mux         sync.Mutex
+	dev         i2c.Dev
+	initialized bool
+}
+
+// NewLIS3DH creates a LIS3DH on the given bus, using the default address when addr is zero
+func NewLIS3DH(bus i2c.Bus, addr uint16) *LIS3DH {
+	if addr == 0 {
+		addr = LIS3DHDefaultAddr
+	}
+	return &LIS3DH{dev: i2c.Dev{Bus: bus, Addr: addr}}
+}
+
+// init verifies WHO_AM_I and starts 100Hz high resolution measurement at ±2g
+func (l *LIS3DH) init() error {
+	id := make([]byte, 1)
+	if err := l.dev.Tx([]byte{0x0F}, id); err != nil {
+		return fmt.Errorf("failed to read LIS3DH WHO_AM_I: %w", err)
+	}
+	if id[0] != lis3dhWhoAmI {
+		return fmt.Errorf("unexpected LIS3DH WHO_AM_I 0x%02x", id[0])
+	}
+	for _, reg := range [][]byte{
+		{0x20, 0x57}, // CTRL_REG1: 100Hz, all axes enabled
+		{0x23, 0x88}, // CTRL_REG4: block data update, high resolution, ±2g
+	} {
+		if err := l.dev.Tx(reg, nil); err != nil {
+			return fmt.Errorf("failed to configure LIS3DH: %w", err)
+		}
+	}
+	l.initialized = true
+	return nil
+}
+
+// ReadAcceleration reads the current acceleration
+func (l *LIS3DH) ReadAcceleration() (Acceleration, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if !l.initialized {
+		if err := l.init(); err != nil {
+			return Acceleration{}, err
+		}
+	}
+
+	// Set the auto-increment bit to read all six output registers
+	buf := make([]byte, 6)
+	if err := l.dev.Tx([]byte{0x28 | 0x80}, buf); err != nil {
+		return Acceleration{}, fmt.Errorf("failed to read LIS3DH data: %w", err)
+	}
+	// Output is left-justified 12-bit two's complement
+	axis := func(i int) float64 {
+		return float64(int16(uint16(buf[i])|uint16(buf[i+1])<<8)>>4) * lis3dhScale
+	}
+	return Acceleration{X: axis(0), Y: axis(2), Z: axis(4)}, nil
+}
+
+// ArmShock enables high-pass filtered high events on all axes, latched in INT1_SRC
+func (l *LIS3DH) ArmShock(threshold float64) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if !l.initialized {
+		if err := l.init(); err != nil {
+			return err
+		}
+	}
+	for _, reg := range [][]byte{
+		{0x21, 0x01}, // CTRL_REG2: high-pass filter on interrupt 1
+		{0x24, 0x08}, // CTRL_REG5: latch interrupt 1 until INT1_SRC is read
+		{0x32, shockThresholdRegister(threshold, lis3dhThreshScale, 0x7F)}, // INT1_THS
+		{0x33, 0x00}, // INT1_DURATION: any single sample
+		{0x30, 0x2A}, // INT1_CFG: OR of X, Y and Z high events
+	} {
+		if err := l.dev.Tx(reg, nil); err != nil {
+			return fmt.Errorf("failed to arm LIS3DH shock detection: %w", err)
+		}
+	}
+	return nil
+}
+
+// ShockLatched reads INT1_SRC, which clears the latched interrupt
+func (l *LIS3DH) ShockLatched() (bool, error) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	src := make([]byte, 1)
+	if err := l.dev.Tx([]byte{0x31}, src); err != nil {
+		return false, fmt.Errorf("failed to read LIS3DH interrupt source: %w", err)
+	}
+	return src[0]&0x40 != 0, nil
+}
+
+// ShockTiltConfig holds accelerometer tamper thresholds
+type ShockTiltConfig struct {
+	ShockThreshold float64 // Deviation from 1g that counts as a shock, default 0.5g
+	TiltThreshold  float64 // Degrees from the installed orientation, default 15
+}
+
+// AccelerometerSensor detects shock and tilt relative to the installed orientation
+type AccelerometerSensor struct {
+	mux      sync.Mutex
+	name     string
+	accel    Accelerometer
+	cfg      ShockTiltConfig
+	baseline *Acceleration
+	armed    bool
+}
+
+// NewAccelerometerSensor creates a shock and tilt sensor. The first sample
+// records the installed orientation that tilt is measured against. When the
+// accelerometer is a ShockLatcher, shocks between reads are latched in hardware.
+func NewAccelerometerSensor(name string, accel Accelerometer, cfg ShockTiltConfig) *AccelerometerSensor {
+	if cfg.ShockThreshold == 0 {
+		cfg.ShockThreshold = defaultShockThreshold
+	}
+	if cfg.TiltThreshold == 0 {
+		cfg.TiltThreshold = defaultTiltThreshold
+	}
+	return &AccelerometerSensor{name: name, accel: accel, cfg: cfg}
+}
+
+func (s *AccelerometerSensor) Name() string     { return s.name }
+func (s *AccelerometerSensor) Kind() SensorKind { return SensorKindAccelerometer }
+
+// ResetBaseline records a new installed orientation on the next read, e.g. after maintenance
+func (s *AccelerometerSensor) ResetBaseline() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.baseline = nil
+}
+
+func (s *AccelerometerSensor) Read(ctx context.Context) (SensorReading, error) {
+	a, err := s.accel.ReadAcceleration()
+	if err != nil {
+		return SensorReading{}, err
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.baseline == nil {
+		s.baseline = &a
+	}
+
+	latched := false
+	if latcher, ok := s.accel.(ShockLatcher); ok {
+		if !s.armed {
+			if err := latcher.ArmShock(s.cfg.ShockThreshold); err != nil {
+				return SensorReading{}, err
+			}
+			s.armed = true
+		}
+		if latched, err = latcher.ShockLatched(); err != nil {
+			return SensorReading{}, err
+		}
+	}
+
+	shock := math.Abs(a.magnitude() - 1)
+	tilt := a.angleTo(*s.baseline)
+	detail := fmt.Sprintf("shock %.2fg tilt %.1f°", shock, tilt)
+	if latched {
+		detail += ", shock latched since last read"
+	}
+	return SensorReading{
+		Tampered: latched || shock > s.cfg.ShockThreshold || tilt > s.cfg.TiltThreshold,
+		Value:    shock,
+		Detail:   detail,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RegisterSensor adds a security sensor to the registry. Registered sensors
+// are polled with the built-in sensors and included in TamperState.Sensors.
+func (m *Manager) RegisterSensor(sensor SecuritySensor) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.registerSensorLocked(sensor)
+}
+
+// registerSensorLocked validates and adds a sensor - must be called with lock held
+func (m *Manager) registerSensorLocked(sensor SecuritySensor) error {
+	name := sensor.Name()
+	switch name {
+	case "":
+		return fmt.Errorf("sensor name is required")
+	case SensorCase, SensorMotion, SensorVoltage:
+		return fmt.Errorf("sensor name %s is reserved", name)
+	}
+	for _, existing := range m.sensors {
+		if existing.Name() == name {
+			return fmt.Errorf("sensor %s already registered", name)
+		}
+	}
+
+	m.sensors = append(m.sensors, sensor)
+	return nil
+}
+
+// Sensors returns the names of registered sensors in registration order
+func (m *Manager) Sensors() []string {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	names := make([]string, len(m.sensors))
+	for i, sensor := range m.sensors {
+		names[i] = sensor.Name()
+	}
+	return names
+}
+
+// pollSensorsLocked samples registered sensors and handles tamper transitions - must be called with lock held
+func (m *Manager) pollSensorsLocked(ctx context.Context, now time.Time) {
+	if len(m.sensors) == 0 {
+		return
+	}
+
+	prevStatus := m.state.Sensors
+	next := make(map[string]SensorStatus, len(m.sensors))
+	for _, sensor := range m.sensors {
+		name := sensor.Name()
+		prev, seen := prevStatus[name]
+		status := SensorStatus{Kind: sensor.Kind(), LastChange: prev.LastChange}
+
+		reading, err := sensor.Read(ctx)
+		if err != nil {
+			// Hold the last known tamper state through read faults, until a
+			// sustained fault counts as tamper itself
+			status.Tampered = prev.Tampered
+			status.Loop = prev.Loop
+			status.Fault = err.Error()
+			status.FaultSince = prev.FaultSince
+			if prev.Fault == "" {
+				status.FaultSince = now
+			}
+			if m.sensorFaultTamper > 0 && now.Sub(status.FaultSince) >= m.sensorFaultTamper {
+				status.Tampered = true
+				status.Detail = fmt.Sprintf("no reading for %s", now.Sub(status.FaultSince).Round(time.Second))
+			}
+		} else {
+			status.Tampered = reading.Tampered
+			status.Value = reading.Value
+			status.Detail = reading.Detail
+			status.Loop = reading.Loop
+			status.Data = reading.Data
+		}
+		if !seen || status.Tampered != prev.Tampered || (status.Fault != "") != (prev.Fault != "") {
+			status.LastChange = now
+		}
+		next[name] = status
+	}
+	m.state.Sensors = next
+
+	// Handle transitions once the new statuses are visible in state
+	for _, sensor := range m.sensors {
+		name := sensor.Name()
+		status, prev := next[name], prevStatus[name]
+
+		if status.Fault != "" && prev.Fault == "" {
+			m.logEventLocked(ctx, "sensor_fault", map[string]interface{}{
+				"sensor": name,
+				"kind":   string(status.Kind),
+				"error":  status.Fault,
+			})
+		}
+		if prev.Loop != "" && status.Loop != "" && status.Loop != prev.Loop {
+			m.logEventLocked(ctx, "loop_state_changed", map[string]interface{}{
+				"sensor": name,
+				"from":   string(prev.Loop),
+				"to":     string(status.Loop),
+				"value":  status.Value,
+			})
+		}
+		m.trackSensorLocked(name, status.Tampered, now)
+		if status.Tampered && !prev.Tampered {
+			m.tamperLocked(ctx, name, now)
+		} else if !status.Tampered && prev.Tampered {
+			m.sensorClearedLocked(ctx, name, now)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package secure
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/physic"
+)
+
+// mockRegBus serves register reads from a map keyed by the register address
+type mockRegBus struct {
+	regs   map[byte][]byte
+	writes [][]byte
+}
+
+func (m *mockRegBus) String() string                    { return "mock_i2c" }
+func (m *mockRegBus) SetSpeed(f physic.Frequency) error { return nil }
+func (m *mockRegBus) Tx(addr uint16, w, r []byte) error {
+	if len(r) == 0 {
+		m.writes = append(m.writes, append([]byte(nil), w...))
+		return nil
+	}
+	copy(r, m.regs[w[0]])
+	return nil
+}
+
+// mockAccelerometer returns a fixed sample
+type mockAccelerometer struct {
+	sample Acceleration
+	err    error
+}
+
+func (m *mockAccelerometer) ReadAcceleration() (Acceleration, error) {
+	return m.sample, m.err
+}
+
+// mockLatchingAccelerometer latches a shock until it is read
+type mockLatchingAccelerometer struct {
+	mockAccelerometer
+	threshold float64
+	latched   bool
+}
+
+func (m *mockLatchingAccelerometer) ArmShock(threshold float64) error {
+	m.threshold = threshold
+	return nil
+}
+
+func (m *mockLatchingAccelerometer) ShockLatched() (bool, error) {
+	latched := m.latched
+	m.latched = false
+	return latched, nil
+}
+
+func TestSensorDevices(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ADXL345", func(t *testing.T) {
+		bus := &mockRegBus{regs: map[byte][]byte{
+			0x00: {adxl345DeviceID},
+			0x32: {0x00, 0x00, 0x00, 0xFF, 0x00, 0x01}, // X 0, Y -256, Z 256
+		}}
+		dev := NewADXL345(bus, 0)
+		a, err := dev.ReadAcceleration()
+		if err != nil {
+			t.Fatalf("Failed to read ADXL345: %v", err)
+		}
+		if a.X != 0 || math.Abs(a.Y+0.998) > 0.01 || math.Abs(a.Z-0.998) > 0.01 {
+			t.Errorf("Unexpected ADXL345 sample: %+v", a)
+		}
+		if len(bus.writes) != 3 {
+			t.Errorf("Expected 3 configuration writes, got %d", len(bus.writes))
+		}
+
+		bus.writes = nil
+		if err := dev.ArmShock(0.5); err != nil {
+			t.Fatalf("Failed to arm ADXL345: %v", err)
+		}
+		if len(bus.writes) != 3 || bus.writes[0][0] != 0x24 || bus.writes[0][1] != 8 {
+			t.Errorf("Unexpected ADXL345 arming writes: %x", bus.writes)
+		}
+		if latched, err := dev.ShockLatched(); err != nil || latched {
+			t.Errorf("Expected no latched shock, got %v: %v", latched, err)
+		}
+		bus.regs[0x30] = []byte{0x10}
+		if latched, _ := dev.ShockLatched(); !latched {
+			t.Error("Expected latched shock")
+		}
+
+		bus.regs[0x00] = []byte{0x00}
+		if _, err := NewADXL345(bus, 0).ReadAcceleration(); err == nil {
+			t.Error("Expected device ID error")
+		}
+	})
+
+	t.Run("LIS3DH", func(t *testing.T) {
+		bus := &mockRegBus{regs: map[byte][]byte{
+			0x0F:        {lis3dhWhoAmI},
+			0x28 | 0x80: {0x00, 0x00, 0x00, 0x00, 0x80, 0x3E}, // Z 1000mg
+		}}
+		dev := NewLIS3DH(bus, 0)
+		a, err := dev.ReadAcceleration()
+		if err != nil {
+			t.Fatalf("Failed to read LIS3DH: %v", err)
+		}
+		if math.Abs(a.Z-1) > 0.001 {
+			t.Errorf("Expected 1g on Z, got %+v", a)
+		}
+
+		bus.writes = nil
+		if err := dev.ArmShock(0.5); err != nil {
+			t.Fatalf("Failed to arm LIS3DH: %v", err)
+		}
+		if len(bus.writes) != 5 || bus.writes[2][0] != 0x32 || bus.writes[2][1] != 31 {
+			t.Errorf("Unexpected LIS3DH arming writes: %x", bus.writes)
+		}
+		bus.regs[0x31] = []byte{0x40}
+		if latched, err := dev.ShockLatched(); err != nil || !latched {
+			t.Errorf("Expected latched shock, got %v: %v", latched, err)
+		}
+	})
+
+	t.Run("Shock And Tilt", func(t *testing.T) {
+		accel := &mockAccelerometer{sample: Acceleration{Z: 1}}
+		sensor := NewAccelerometerSensor("accel", accel, ShockTiltConfig{})
+
+		if r, _ := sensor.Read(ctx); r.Tampered {
+			t.Error("Expected no tamper at rest")
+		}
+		accel.sample = Acceleration{X: 0.5, Z: 0.87} // ~30° tilt
+		if r, _ := sensor.Read(ctx); !r.Tampered {
+			t.Error("Expected tilt tamper")
+		}
+		accel.sample = Acceleration{Z: 2}
+		if r, _ := sensor.Read(ctx); !r.Tampered || math.Abs(r.Value-1) > 0.01 {
+			t.Errorf("Expected 1g shock, got %+v", r)
+		}
+
+		// A new baseline accepts the reinstalled orientation
+		sensor.ResetBaseline()
+		accel.sample = Acceleration{X: 0.5, Z: 0.87}
+		if r, _ := sensor.Read(ctx); r.Tampered {
+			t.Error("Expected no tamper after baseline reset")
+		}
+	})
+
+	t.Run("Latched Shock", func(t *testing.T) {
+		accel := &mockLatchingAccelerometer{mockAccelerometer: mockAccelerometer{sample: Acceleration{Z: 1}}}
+		sensor := NewAccelerometerSensor("accel", accel, ShockTiltConfig{ShockThreshold: 0.8})
+
+		if r, _ := sensor.Read(ctx); r.Tampered || accel.threshold != 0.8 {
+			t.Errorf("Expected armed sensor at rest, got %+v", r)
+		}
+		// A knock between reads is reported although the sample is at rest
+		accel.latched = true
+		if r, _ := sensor.Read(ctx); !r.Tampered {
+			t.Error("Expected latched shock tamper")
+		}
+		if r, _ := sensor.Read(ctx); r.Tampered {
+			t.Error("Expected latch cleared after read")
+		}
+	})
+
+	t.Run("Light", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "in_voltage0_raw")
+		sensor := NewLightSensor("light", path, 500)
+		if _, err := sensor.Read(ctx); err == nil {
+			t.Error("Expected error for missing ADC")
+		}
+
+		os.WriteFile(path, []byte("120\n"), 0o600)
+		if r, err := sensor.Read(ctx); err != nil || r.Tampered || r.Value != 120 {
+			t.Errorf("Expected dark reading, got %+v: %v", r, err)
+		}
+		os.WriteFile(path, []byte("900\n"), 0o600)
+		if r, _ := sensor.Read(ctx); !r.Tampered {
+			t.Error("Expected light tamper")
+		}
+	})
+}
+
+func TestSensorRegistry(t *testing.T) {
+	ctx := context.Background()
+	gpioCtrl, _ := newTestGPIO(t)
+	case2, mesh := &mockSensorPin{}, &mockSensorPin{}
+	for name, pin := range map[string]*mockSensorPin{"case2": case2, "mesh": mesh} {
+		if err := gpioCtrl.ConfigurePin(name, pin, gpio.Float); err != nil {
+			t.Fatalf("Failed to configure %s pin: %v", name, err)
+		}
+	}
+
+	accel := &mockAccelerometer{sample: Acceleration{Z: 1}}
+	store := newMemStore()
+	manager, err := New(Config{
+		GPIO:          gpioCtrl,
+		CaseSensor:    "case",
+		MotionSensor:  "motion",
+		VoltageSensor: "voltage",
+		DeviceID:      "test-device",
+		StateStore:    store,
+		Sensors: []SecuritySensor{
+			NewCaseSwitch("rear_panel", gpioCtrl, "case2"),
+			NewMeshLoop("mesh", gpioCtrl, "mesh"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+
+	t.Run("Registration", func(t *testing.T) {
+		if err := manager.RegisterSensor(NewAccelerometerSensor("accel", accel, ShockTiltConfig{})); err != nil {
+			t.Fatalf("Failed to register sensor: %v", err)
+		}
+		if err := manager.RegisterSensor(NewMeshLoop("mesh", gpioCtrl, "mesh")); err == nil {
+			t.Error("Expected duplicate name error")
+		}
+		if err := manager.RegisterSensor(NewCaseSwitch(SensorCase, gpioCtrl, "case2")); err == nil {
+			t.Error("Expected reserved name error")
+		}
+		if names := manager.Sensors(); len(names) != 3 {
+			t.Errorf("Expected 3 sensors, got %v", names)
+		}
+	})
+
+	t.Run("State", func(t *testing.T) {
+		if err := manager.checkSecurity(ctx); err != nil {
+			t.Fatalf("Security check failed: %v", err)
+		}
+		state := manager.GetState()
+		if len(state.Sensors) != 3 || state.Sensors["mesh"].Kind != SensorKindMesh {
+			t.Fatalf("Unexpected sensor state: %+v", state.Sensors)
+		}
+		if state.Mode != ModeArmed || state.tamperPresent() {
+			t.Error("Expected clear armed state")
+		}
+	})
+
+	t.Run("Mesh Cut", func(t *testing.T) {
+		before := manager.GetState()
+		mesh.Out(gpio.High)
+		manager.checkSecurity(ctx)
+
+		state := manager.GetState()
+		if state.Mode != ModeAlarm || state.LatchedBy != "mesh" || !state.Sensors["mesh"].Tampered {
+			t.Fatalf("Expected mesh alarm, got %s by %s", state.Mode, state.LatchedBy)
+		}
+		if before.Sensors["mesh"].Tampered {
+			t.Error("Earlier state copy changed")
+		}
+
+		manager.Acknowledge(ctx)
+		if err := manager.Arm(ctx); err == nil {
+			t.Error("Expected arm to fail with mesh cut")
+		}
+		mesh.Out(gpio.Low)
+		manager.checkSecurity(ctx)
+		if err := manager.Arm(ctx); err != nil {
+			t.Fatalf("Failed to arm: %v", err)
+		}
+	})
+
+	t.Run("Sensor Fault", func(t *testing.T) {
+		accel.err = errors.New("i2c nack")
+		manager.checkSecurity(ctx)
+		manager.checkSecurity(ctx)
+
+		if fault := manager.GetState().Sensors["accel"].Fault; fault == "" {
+			t.Error("Expected accelerometer fault in state")
+		}
+		if store.count("sensor_fault") != 1 {
+			t.Errorf("Expected one fault event, got %d", store.count("sensor_fault"))
+		}
+		if state := manager.GetState(); state.Mode != ModeArmed || state.Sensors["accel"].Tampered {
+			t.Error("Expected fault without tamper when not configured")
+		}
+	})
+}
+
+func TestSensorFaultTamper(t *testing.T) {
+	ctx := context.Background()
+	gpioCtrl, _ := newTestGPIO(t)
+	accel := &mockAccelerometer{sample: Acceleration{Z: 1}}
+	manager, err := New(Config{
+		GPIO:              gpioCtrl,
+		CaseSensor:        "case",
+		MotionSensor:      "motion",
+		VoltageSensor:     "voltage",
+		DeviceID:          "test-device",
+		StateStore:        newMemStore(),
+		Sensors:           []SecuritySensor{NewAccelerometerSensor("accel", accel, ShockTiltConfig{})},
+		SensorFaultTamper: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+
+	now := time.Now()
+	manager.mux.Lock()
+	manager.pollSensorsLocked(ctx, now)
+	accel.err = errors.New("i2c nack")
+	manager.pollSensorsLocked(ctx, now.Add(time.Second))
+	manager.mux.Unlock()
+	if state := manager.GetState(); state.Mode != ModeArmed || state.Sensors["accel"].Tampered {
+		t.Fatal("Expected brief fault to hold the last state")
+	}
+
+	// An unplugged sensor raises tamper once the fault persists
+	manager.mux.Lock()
+	manager.pollSensorsLocked(ctx, now.Add(6*time.Second))
+	manager.mux.Unlock()
+	state := manager.GetState()
+	if state.Mode != ModeAlarm || state.LatchedBy != "accel" || !state.Sensors["accel"].Tampered {
+		t.Errorf("Expected fault tamper alarm, got %s by %s", state.Mode, state.LatchedBy)
+	}
+}
@@ -0,0 +1,229 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// tamperPresent reports whether any sensor currently indicates tampering
+func (s TamperState) tamperPresent() bool {
+	if s.CaseOpen || s.MotionDetected || !s.VoltageNormal {
+		return true
+	}
+	for _, status := range s.Sensors {
+		if status.Tampered {
+			return true
+		}
+	}
+	return false
+}
+
+// activeSensors returns the sensors currently indicating tampering, built-in sensors first
+func (s TamperState) activeSensors() []string {
+	var active []string
+	if s.CaseOpen {
+		active = append(active, SensorCase)
+	}
+	if s.MotionDetected {
+		active = append(active, SensorMotion)
+	}
+	if !s.VoltageNormal {
+		active = append(active, SensorVoltage)
+	}
+	var registered []string
+	for name, status := range s.Sensors {
+		if status.Tampered {
+			registered = append(registered, name)
+		}
+	}
+	sort.Strings(registered)
+	return append(active, registered...)
+}
+
+// logEventLocked records a security event if a store is available - must be called with lock held
+func (m *Manager) logEventLocked(ctx context.Context, eventType string, details map[string]interface{}) {
+	if m.stateStore == nil {
+		return
+	}
+	if err := m.stateStore.LogEvent(ctx, m.deviceID, eventType, details); err != nil {
+		// Log but don't fail on event logging error
+		fmt.Printf("Failed to log %s event: %v\n", eventType, err)
+	}
+}
+
+// persistLocked saves the current state if a store is available - must be called with lock held
+func (m *Manager) persistLocked(ctx context.Context) error {
+	if m.stateStore == nil {
+		return nil
+	}
+	if err := m.stateStore.SaveState(ctx, m.deviceID, m.state); err != nil {
+		return fmt.Errorf("failed to persist security state: %w", err)
+	}
+	return nil
+}
+
+// transitionLocked moves the state machine to a new mode - must be called with lock held
+func (m *Manager) transitionLocked(ctx context.Context, to SecurityMode, reason string) error {
+	from := m.state.Mode
+	if from == to {
+		return nil
+	}
+
+	now := time.Now()
+	m.state.Mode = to
+	m.state.ModeChangedAt = now
+
+	// Arming starts a new zeroization cycle; secrets are reprovisioned before arming
+	if to == ModeArmed {
+		m.state.Zeroized = false
+		m.state.ZeroizedAt = time.Time{}
+		m.zeroizeFired = false
+	}
+	// Leaving maintenance closes any token-authorized window
+	if from == ModeMaintenance {
+		m.state.MaintenanceID = ""
+		m.state.MaintenanceBy = ""
+		m.state.MaintenanceUntil = time.Time{}
+	}
+
+	m.logEventLocked(ctx, "mode_changed", map[string]interface{}{
+		"from":   string(from),
+		"to":     string(to),
+		"reason": reason,
+	})
+	err := m.persistLocked(ctx)
+
+	if m.onModeChange != nil {
+		m.onModeChange(ModeTransition{
+			From:      from,
+			To:        to,
+			Reason:    reason,
+			Timestamp: now,
+			State:     m.state,
+		})
+	}
+	return err
+}
+
+// tamperLocked handles a tamper transition on a sensor - must be called with lock held
+func (m *Manager) tamperLocked(ctx context.Context, sensor string, at time.Time) {
+	eventDetails := map[string]interface{}{
+		"sensor":          sensor,
+		"edge_time":       at,
+		"mode":            string(m.state.Mode),
+		"case_open":       m.state.CaseOpen,
+		"motion_detected": m.state.MotionDetected,
+		"voltage_normal":  m.state.VoltageNormal,
+	}
+	if status, ok := m.state.Sensors[sensor]; ok {
+		eventDetails["kind"] = string(status.Kind)
+		if status.Detail != "" {
+			eventDetails["detail"] = status.Detail
+		}
+		if status.Loop != "" {
+			eventDetails["loop_state"] = string(status.Loop)
+		}
+		for k, v := range status.Data {
+			if _, exists := eventDetails[k]; !exists {
+				eventDetails[k] = v
+			}
+		}
+	}
+
+	// Only an armed or acknowledged system raises an alarm; new tampering
+	// after acknowledgment escalates again. Other modes record the activity.
+	switch m.state.Mode {
+	case ModeArmed, ModeAcknowledged:
+	case ModeMaintenance:
+		eventDetails["active"] = true
+		m.maintenanceDetailsLocked(eventDetails)
+		m.logEventLocked(ctx, "maintenance_activity", eventDetails)
+		return
+	default:
+		m.logEventLocked(ctx, "sensor_activity", eventDetails)
+		return
+	}
+
+	m.state.Latched = true
+	m.state.LatchedAt = at
+	m.state.LatchedBy = sensor
+	m.logEventLocked(ctx, "tamper_detected", eventDetails)
+	m.measureTamperLocked(sensor, at)
+
+	if err := m.transitionLocked(ctx, ModeAlarm, fmt.Sprintf("%s tamper", sensor)); err != nil {
+		// Log but don't fail on state persistence error
+		fmt.Printf("Failed to persist security state: %v\n", err)
+	}
+
+	if m.onTamper != nil {
+		m.onTamper(m.state)
+	}
+}
+
+// Arm enables alarm escalation; sensors must be clear
+func (m *Manager) Arm(ctx context.Context) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	switch m.state.Mode {
+	case ModeArmed:
+		return nil
+	case ModeAlarm:
+		return fmt.Errorf("alarm must be acknowledged before arming")
+	}
+	if m.state.tamperPresent() {
+		return fmt.Errorf("cannot arm while a tamper condition is present")
+	}
+
+	m.state.Latched = false
+	m.state.LatchedAt = time.Time{}
+	m.state.LatchedBy = ""
+	return m.transitionLocked(ctx, ModeArmed, "armed")
+}
+
+// Disarm disables alarm escalation while continuing to record sensor activity
+func (m *Manager) Disarm(ctx context.Context) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.state.Mode == ModeAlarm {
+		return fmt.Errorf("alarm must be acknowledged before disarming")
+	}
+	return m.transitionLocked(ctx, ModeDisarmed, "disarmed")
+}
+
+// Acknowledge confirms a latched alarm. Tampering seen after acknowledgment
+// raises a new alarm until the system is re-armed or disarmed.
+func (m *Manager) Acknowledge(ctx context.Context) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.state.Mode != ModeAlarm {
+		return fmt.Errorf("no alarm to acknowledge")
+	}
+
+	m.logEventLocked(ctx, "tamper_acknowledged", map[string]interface{}{
+		"sensor":    m.state.LatchedBy,
+		"edge_time": m.state.LatchedAt,
+	})
+	m.state.Latched = false
+	m.state.LatchedAt = time.Time{}
+	m.state.LatchedBy = ""
+	return m.transitionLocked(ctx, ModeAcknowledged, "acknowledged")
+}
+
+// ExitMaintenance re-arms the system after servicing; sensors must be clear
+func (m *Manager) ExitMaintenance(ctx context.Context) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.state.Mode != ModeMaintenance {
+		return fmt.Errorf("not in maintenance mode")
+	}
+	if m.state.tamperPresent() {
+		return fmt.Errorf("cannot leave maintenance while a tamper condition is present")
+	}
+	return m.transitionLocked(ctx, ModeArmed, "maintenance finished")
+}
@@ -0,0 +1,208 @@
+package secure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+)
+
+// memStore is an in-memory StateStore for tests
+type memStore struct {
+	mux    sync.Mutex
+	states map[string]TamperState
+	events []Event
+}
+
+func newMemStore() *memStore {
+	return &memStore{states: make(map[string]TamperState)}
+}
+
+func (s *memStore) SaveState(ctx context.Context, deviceID string, state TamperState) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.states[deviceID] = state
+	return nil
+}
+
+func (s *memStore) LoadState(ctx context.Context, deviceID string) (TamperState, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	state, exists := s.states[deviceID]
+	if !exists {
+		return TamperState{}, errors.New("state not found")
+	}
+	return state, nil
+}
+
+func (s *memStore) LogEvent(ctx context.Context, deviceID string, eventType string, details interface{}) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.events = append(s.events, Event{DeviceID: deviceID, Type: eventType, Details: details})
+	return nil
+}
+
+// count returns the number of logged events of a type
+func (s *memStore) count(eventType string) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	n := 0
+	for _, e := range s.events {
+		if e.Type == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSecurityStateMachine(t *testing.T) {
+	ctx := context.Background()
+	gpioCtrl, pins := newTestGPIO(t)
+	store := newMemStore()
+	pub, priv, _ := ed25519.GenerateKey(nil)
+
+	var (
+		tampers     int
+		transitions []ModeTransition
+	)
+	cfg := Config{
+		GPIO:            gpioCtrl,
+		CaseSensor:      "case",
+		MotionSensor:    "motion",
+		VoltageSensor:   "voltage",
+		DeviceID:        "test-device",
+		StateStore:      store,
+		InitialMode:     ModeDisarmed,
+		OnTamper:        func(TamperState) { tampers++ },
+		OnModeChange:    func(tr ModeTransition) { transitions = append(transitions, tr) },
+		MaintenanceKeys: []ed25519.PublicKey{pub},
+	}
+	manager, err := New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create security manager: %v", err)
+	}
+
+	// setCase drives the case pin and runs one supervision poll
+	setCase := func(open bool) {
+		level := gpio.Low
+		if open {
+			level = gpio.High
+		}
+		if err := pins.casePin.Out(level); err != nil {
+			t.Fatalf("Failed to drive case pin: %v", err)
+		}
+		if err := manager.checkSecurity(ctx); err != nil {
+			t.Fatalf("Security check failed: %v", err)
+		}
+	}
+
+	t.Run("Disarmed Activity", func(t *testing.T) {
+		setCase(true)
+		setCase(false)
+		if state := manager.GetState(); state.Mode != ModeDisarmed || state.Latched {
+			t.Errorf("Expected disarmed without alarm, got %s", state.Mode)
+		}
+		if tampers != 0 {
+			t.Error("Tamper notification while disarmed")
+		}
+		if store.count("sensor_activity") != 1 {
+			t.Error("Sensor activity not recorded while disarmed")
+		}
+	})
+
+	t.Run("Arm Requires Clear Sensors", func(t *testing.T) {
+		setCase(true)
+		if err := manager.Arm(ctx); err == nil {
+			t.Error("Expected arm to fail with case open")
+		}
+		setCase(false)
+		if err := manager.Arm(ctx); err != nil {
+			t.Fatalf("Failed to arm: %v", err)
+		}
+	})
+
+	t.Run("Latched Alarm", func(t *testing.T) {
+		setCase(true)
+		setCase(false)
+		setCase(true)
+
+		state := manager.GetState()
+		if state.Mode != ModeAlarm || !state.Latched {
+			t.Fatalf("Expected latched alarm, got %s", state.Mode)
+		}
+		if tampers != 1 {
+			t.Errorf("Expected one tamper notification, got %d", tampers)
+		}
+		if err := manager.Disarm(ctx); err == nil {
+			t.Error("Expected disarm to fail before acknowledgment")
+		}
+	})
+
+	t.Run("Persisted State", func(t *testing.T) {
+		restored, err := New(cfg)
+		if err != nil {
+			t.Fatalf("Failed to create security manager: %v", err)
+		}
+		if state := restored.GetState(); state.Mode != ModeAlarm || state.LatchedBy != SensorCase {
+			t.Errorf("Expected persisted alarm, got %s", state.Mode)
+		}
+	})
+
+	t.Run("Acknowledged Escalation", func(t *testing.T) {
+		if err := manager.Acknowledge(ctx); err != nil {
+			t.Fatalf("Failed to acknowledge: %v", err)
+		}
+		if state := manager.GetState(); state.Latched || state.LatchedBy != "" || !state.LatchedAt.IsZero() {
+			t.Errorf("Latch not cleared by acknowledgment: %+v", state)
+		}
+
+		// New tampering while acknowledged raises a fresh alarm
+		setCase(false)
+		setCase(true)
+		state := manager.GetState()
+		if state.Mode != ModeAlarm || !state.Latched || state.LatchedBy != SensorCase {
+			t.Fatalf("Expected escalation back to alarm, got %s", state.Mode)
+		}
+		if tampers != 2 {
+			t.Errorf("Expected second tamper notification, got %d", tampers)
+		}
+	})
+
+	t.Run("Acknowledge And Maintenance", func(t *testing.T) {
+		if err := manager.Acknowledge(ctx); err != nil {
+			t.Fatalf("Failed to acknowledge: %v", err)
+		}
+		token, err := IssueMaintenanceToken(priv, MaintenanceToken{
+			ID: "wo-1", DeviceID: "test-device", Technician: "tech-7", ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("Failed to issue token: %v", err)
+		}
+		if _, err := manager.StartMaintenance(ctx, token); err != nil {
+			t.Fatalf("Failed to enter maintenance: %v", err)
+		}
+		if err := manager.ExitMaintenance(ctx); err == nil {
+			t.Error("Expected exit to fail with case open")
+		}
+		setCase(false)
+		if err := manager.ExitMaintenance(ctx); err != nil {
+			t.Fatalf("Failed to exit maintenance: %v", err)
+		}
+
+		want := []SecurityMode{
+			ModeArmed, ModeAlarm, ModeAcknowledged, ModeAlarm, ModeAcknowledged, ModeMaintenance, ModeArmed,
+		}
+		if len(transitions) != len(want) {
+			t.Fatalf("Expected %d transitions, got %d", len(want), len(transitions))
+		}
+		for i, mode := range want {
+			if transitions[i].To != mode {
+				t.Errorf("Transition %d: expected %s, got %s", i, mode, transitions[i].To)
+			}
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package secure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// queryableStore is a StateStore that supports event queries
+type queryableStore interface {
+	StateStore
+	EventQuerier
+}
+
+// exerciseStore checks state round-trips and event queries on a store
+func exerciseStore(t *testing.T, store queryableStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	state := TamperState{
+		CaseOpen:      true,
+		VoltageNormal: true,
+		Mode:          ModeAlarm,
+		Latched:       true,
+		LatchedBy:     SensorCase,
+		LatchedAt:     time.Now().Truncate(time.Millisecond),
+	}
+	if err := store.SaveState(ctx, "dev-a", state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+	loaded, err := store.LoadState(ctx, "dev-a")
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if loaded.Mode != state.Mode || !loaded.LatchedAt.Equal(state.LatchedAt) || !loaded.CaseOpen {
+		t.Errorf("Loaded state mismatch: %+v", loaded)
+	}
+	if _, err := store.LoadState(ctx, "dev-missing"); err == nil {
+		t.Error("Expected error loading unknown device")
+	}
+
+	start := time.Now()
+	for i, e := range []struct{ device, kind string }{
+		{"dev-a", "tamper_detected"},
+		{"dev-b", "tamper_detected"},
+		{"dev-a", "mode_changed"},
+		{"dev-a", "tamper_detected"},
+	} {
+		details := map[string]interface{}{"seq": i}
+		if err := store.LogEvent(ctx, e.device, e.kind, details); err != nil {
+			t.Fatalf("Failed to log event: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	middle := time.Now()
+	if err := store.LogEvent(ctx, "dev-a", "tamper_detected", nil); err != nil {
+		t.Fatalf("Failed to log event: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query EventQuery
+		want  int
+	}{
+		{"All", EventQuery{}, 5},
+		{"By Device", EventQuery{DeviceID: "dev-a"}, 4},
+		{"By Type", EventQuery{Type: "tamper_detected"}, 4},
+		{"By Device And Type", EventQuery{DeviceID: "dev-a", Type: "tamper_detected"}, 3},
+		{"Since", EventQuery{Since: middle}, 1},
+		{"Until", EventQuery{Since: start, Until: middle}, 4},
+		{"Limit", EventQuery{DeviceID: "dev-a", Limit: 2}, 2},
+	}
+	for _, tt := range tests {
+		events, err := store.QueryEvents(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("%s: query failed: %v", tt.name, err)
+		}
+		if len(events) != tt.want {
+			t.Errorf("%s: expected %d events, got %d", tt.name, tt.want, len(events))
+		}
+		for i := 1; i < len(events); i++ {
+			if events[i].Timestamp.Before(events[i-1].Timestamp) {
+				t.Errorf("%s: events not in chronological order", tt.name)
+			}
+		}
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, EventLogConfig{})
+	if err != nil {
+		t.Fatalf("Failed to create file store: %v", err)
+	}
+	defer store.Close()
+
+	exerciseStore(t, store)
+
+	if err := store.SaveState(context.Background(), "../escape", TamperState{}); err == nil {
+		t.Error("Expected error for device ID with path separator")
+	}
+
+	// No temporary files may be left behind by atomic writes
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read state directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name()[0] == '.' {
+			t.Errorf("Temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestEventLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	log, err := NewEventLog(EventLogConfig{Path: path, MaxSize: 256, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("Failed to create event log: %v", err)
+	}
+	defer log.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		if err := log.LogEvent(ctx, "dev-a", fmt.Sprintf("event_%02d", i), nil); err != nil {
+			t.Fatalf("Failed to log event: %v", err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Expected log file %s: %v", p, err)
+		}
+		if info.Size() > 256 {
+			t.Errorf("Log file %s exceeds rotation size: %d", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Rotated file beyond MaxFiles was kept")
+	}
+
+	events, err := log.QueryEvents(ctx, EventQuery{})
+	if err != nil {
+		t.Fatalf("Failed to query events: %v", err)
+	}
+	if len(events) == 0 || events[len(events)-1].Type != "event_19" {
+		t.Fatal("Latest event missing after rotation")
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Type <= events[i-1].Type {
+			t.Errorf("Events out of order across rotated files: %s after %s", events[i].Type, events[i-1].Type)
+		}
+	}
+}
+
+func TestBoltStore(t *testing.T) {
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "secure.db"))
+	if err != nil {
+		t.Fatalf("Failed to create bolt store: %v", err)
+	}
+	defer store.Close()
+
+	exerciseStore(t, store)
+}
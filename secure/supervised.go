@@ -0,0 +1,191 @@
+package secure
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+)
+
+const (
+	// Default challenge length in bits
+	defaultChallengeBits = 16
+	// Default settle time between driving a challenge bit and sampling it
+	defaultChallengeSettle = 50 * time.Microsecond
+	// Default relative tolerance around expected end-of-line readings
+	defaultEOLTolerance = 0.1
+)
+
+// ChallengeLoopConfig holds pulse challenge supervision settings
+type ChallengeLoopConfig struct {
+	OutputPin string        // Drives the challenge into the loop
+	InputPin  string        // Receives the challenge through the switch
+	Bits      int           // Challenge length, default 16
+	Settle    time.Duration // Delay before sampling each bit, default 50µs
+}
+
+// ChallengeLoop supervises a switch loop by driving a random bit pattern on an
+// output pin and checking that it returns on the input pin. Shorting the loop
+// to a supply or injecting a fixed signal cannot reproduce a fresh challenge.
+//
+// A digital loop cannot tell an opened switch from a cut wire, so both report
+// CUT. An input stuck high reports SHORT; any other mismatch reports TAMPER.
+type ChallengeLoop struct {
+	name string
+	gpio *gpio.Controller
+	cfg  ChallengeLoopConfig
+}
+
+// NewChallengeLoop creates a pulse challenge supervised loop
+func NewChallengeLoop(name string, ctrl *gpio.Controller, cfg ChallengeLoopConfig) *ChallengeLoop {
+	if cfg.Bits == 0 {
+		cfg.Bits = defaultChallengeBits
+	}
+	if cfg.Settle == 0 {
+		cfg.Settle = defaultChallengeSettle
+	}
+	return &ChallengeLoop{name: name, gpio: ctrl, cfg: cfg}
+}
+
+func (l *ChallengeLoop) Name() string     { return l.name }
+func (l *ChallengeLoop) Kind() SensorKind { return SensorKindSupervised }
+
+// challenge returns a random pattern containing both levels
+func (l *ChallengeLoop) challenge() ([]bool, error) {
+	buf := make([]byte, (l.cfg.Bits+7)/8)
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate challenge: %w", err)
+		}
+		pattern := make([]bool, l.cfg.Bits)
+		ones := 0
+		for i := range pattern {
+			pattern[i] = buf[i/8]&(1<<(i%8)) != 0
+			if pattern[i] {
+				ones++
+			}
+		}
+		// A constant pattern cannot distinguish a stuck input
+		if ones > 0 && ones < len(pattern) {
+			return pattern, nil
+		}
+	}
+}
+
+func (l *ChallengeLoop) Read(ctx context.Context) (SensorReading, error) {
+	pattern, err := l.challenge()
+	if err != nil {
+		return SensorReading{}, err
+	}
+
+	var highs, mismatches int
+	for _, bit := range pattern {
+		if err := l.gpio.SetPinState(l.cfg.OutputPin, bit); err != nil {
+			return SensorReading{}, fmt.Errorf("failed to drive challenge: %w", err)
+		}
+		time.Sleep(l.cfg.Settle)
+		high, err := l.gpio.GetPinState(l.cfg.InputPin)
+		if err != nil {
+			return SensorReading{}, fmt.Errorf("failed to read challenge: %w", err)
+		}
+		if high {
+			highs++
+		}
+		if high != bit {
+			mismatches++
+		}
+	}
+	// Leave the loop idle low between challenges
+	if err := l.gpio.SetPinState(l.cfg.OutputPin, false); err != nil {
+		return SensorReading{}, fmt.Errorf("failed to idle challenge output: %w", err)
+	}
+
+	state := LoopNormal
+	switch {
+	case mismatches == 0:
+	case highs == 0:
+		state = LoopCut
+	case highs == len(pattern):
+		state = LoopShort
+	default:
+		state = LoopTamper
+	}
+	return SensorReading{
+		Tampered: state != LoopNormal,
+		Value:    float64(mismatches),
+		Detail:   fmt.Sprintf("%d of %d challenge bits mismatched", mismatches, len(pattern)),
+		Loop:     state,
+	}, nil
+}
+
+// EOLConfig holds end-of-line resistor supervision settings in raw ADC counts.
+// A dual end-of-line loop reads Normal with the switch closed and Open with
+// the switch open; a shorted loop reads near zero and a cut loop near full scale.
+type EOLConfig struct {
+	ADCPath    string
+	Normal     float64 // Expected reading with the switch closed
+	Open       float64 // Expected reading with the switch open
+	ShortBelow float64 // Readings below this are a short, default Normal/2
+	CutAbove   float64 // Readings above this are a cut, default midway past Open
+	Tolerance  float64 // Relative tolerance around Normal and Open, default 0.1
+}
+
+// EOLLoop supervises a switch loop through its end-of-line resistance
+type EOLLoop struct {
+	name string
+	cfg  EOLConfig
+}
+
+// NewEOLLoop creates an end-of-line resistor supervised loop
+func NewEOLLoop(name string, cfg EOLConfig) (*EOLLoop, error) {
+	if cfg.ADCPath == "" {
+		return nil, fmt.Errorf("ADC path is required")
+	}
+	if cfg.Normal <= 0 || cfg.Open <= cfg.Normal {
+		return nil, fmt.Errorf("open reading must be above a positive normal reading")
+	}
+	if cfg.ShortBelow == 0 {
+		cfg.ShortBelow = cfg.Normal / 2
+	}
+	if cfg.CutAbove == 0 {
+		cfg.CutAbove = cfg.Open + (cfg.Open-cfg.Normal)/2
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = defaultEOLTolerance
+	}
+	return &EOLLoop{name: name, cfg: cfg}, nil
+}
+
+func (l *EOLLoop) Name() string     { return l.name }
+func (l *EOLLoop) Kind() SensorKind { return SensorKindSupervised }
+
+// Classify maps a raw reading to a loop state
+func (l *EOLLoop) Classify(value float64) LoopState {
+	switch {
+	case value < l.cfg.ShortBelow:
+		return LoopShort
+	case value > l.cfg.CutAbove:
+		return LoopCut
+	case math.Abs(value-l.cfg.Normal) <= l.cfg.Tolerance*l.cfg.Normal:
+		return LoopNormal
+	default:
+		// Switch open, or a substitute resistance that does not match the EOL value
+		return LoopTamper
+	}
+}
+
+func (l *EOLLoop) Read(ctx context.Context) (SensorReading, error) {
+	value, err := readADC(l.cfg.ADCPath)
+	if err != nil {
+		return SensorReading{}, err
+	}
+	state := l.Classify(value)
+	return SensorReading{
+		Tampered: state != LoopNormal,
+		Value:    value,
+		Loop:     state,
+	}, nil
+}
@@ -0,0 +1,128 @@
+package secure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"periph.io/x/conn/v3/gpio"
+)
+
+// loopInputPin reads whatever the wiring function returns
+type loopInputPin struct {
+	*mockSensorPin
+	wire func() gpio.Level
+}
+
+func (p *loopInputPin) Read() gpio.Level { return p.wire() }
+
+func TestChallengeLoop(t *testing.T) {
+	ctx := context.Background()
+	gpioCtrl, _ := newTestGPIO(t)
+
+	out := &mockSensorPin{}
+	in := &loopInputPin{mockSensorPin: &mockSensorPin{}}
+	if err := gpioCtrl.ConfigurePin("loop_out", out, gpio.Float); err != nil {
+		t.Fatalf("Failed to configure output pin: %v", err)
+	}
+	if err := gpioCtrl.ConfigurePin("loop_in", in, gpio.Float); err != nil {
+		t.Fatalf("Failed to configure input pin: %v", err)
+	}
+	loop := NewChallengeLoop("lid", gpioCtrl, ChallengeLoopConfig{OutputPin: "loop_out", InputPin: "loop_in"})
+
+	tests := []struct {
+		name string
+		wire func() gpio.Level
+		want LoopState
+	}{
+		{"Intact", out.Read, LoopNormal},
+		{"Cut", func() gpio.Level { return gpio.Low }, LoopCut},
+		{"Shorted To Supply", func() gpio.Level { return gpio.High }, LoopShort},
+		{"Inverted Signal", func() gpio.Level { return !out.Read() }, LoopTamper},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in.wire = tt.wire
+			reading, err := loop.Read(ctx)
+			if err != nil {
+				t.Fatalf("Failed to read loop: %v", err)
+			}
+			if reading.Loop != tt.want || reading.Tampered != (tt.want != LoopNormal) {
+				t.Errorf("Expected %s, got %s (%s)", tt.want, reading.Loop, reading.Detail)
+			}
+		})
+	}
+
+	if out.Read() != gpio.Low {
+		t.Error("Challenge output not left idle low")
+	}
+}
+
+func TestEOLLoop(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "in_voltage1_raw")
+	if _, err := NewEOLLoop("door", EOLConfig{ADCPath: path, Normal: 2000, Open: 1000}); err == nil {
+		t.Error("Expected error for open reading below normal")
+	}
+
+	loop, err := NewEOLLoop("door", EOLConfig{ADCPath: path, Normal: 1000, Open: 2000})
+	if err != nil {
+		t.Fatalf("Failed to create EOL loop: %v", err)
+	}
+
+	tests := []struct {
+		value float64
+		want  LoopState
+	}{
+		{1040, LoopNormal},
+		{2000, LoopTamper},
+		{1500, LoopTamper}, // Substituted resistance
+		{30, LoopShort},
+		{4095, LoopCut},
+	}
+	for _, tt := range tests {
+		os.WriteFile(path, []byte(strconv.Itoa(int(tt.value))), 0o600)
+		reading, err := loop.Read(ctx)
+		if err != nil {
+			t.Fatalf("Failed to read loop: %v", err)
+		}
+		if reading.Loop != tt.want {
+			t.Errorf("Reading %.0f: expected %s, got %s", tt.value, tt.want, reading.Loop)
+		}
+	}
+
+	t.Run("Manager", func(t *testing.T) {
+		gpioCtrl, _ := newTestGPIO(t)
+		store := newMemStore()
+		var latched TamperState
+		manager, err := New(Config{
+			GPIO:          gpioCtrl,
+			CaseSensor:    "case",
+			MotionSensor:  "motion",
+			VoltageSensor: "voltage",
+			DeviceID:      "test-device",
+			StateStore:    store,
+			Sensors:       []SecuritySensor{loop},
+			OnTamper:      func(s TamperState) { latched = s },
+		})
+		if err != nil {
+			t.Fatalf("Failed to create security manager: %v", err)
+		}
+
+		os.WriteFile(path, []byte("1000"), 0o600)
+		manager.checkSecurity(ctx)
+		os.WriteFile(path, []byte("10"), 0o600)
+		manager.checkSecurity(ctx)
+		if latched.LatchedBy != "door" || latched.Sensors["door"].Loop != LoopShort {
+			t.Fatalf("Expected latched short on door loop, got %+v", latched.Sensors["door"])
+		}
+
+		os.WriteFile(path, []byte("4095"), 0o600)
+		manager.checkSecurity(ctx)
+		if store.count("loop_state_changed") != 2 {
+			t.Errorf("Expected loop state changes to be logged, got %d", store.count("loop_state_changed"))
+		}
+	})
+}
@@ -0,0 +1,455 @@
+package secure
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpm2/transport"
+)
+
+const (
+	// Kernel resource manager device
+	defaultTPMPath = "/dev/tpmrm0"
+	// Static OS PCR that cannot be reset without a reboot
+	defaultTamperPCR = 14
+	// Secure boot state PCR
+	secureBootPCR = 7
+	// Bytes in a PCR selection bitmap covering PCRs 0-23
+	pcrSelectSize = 3
+)
+
+// TPMConfig holds TPM 2.0 configuration
+type TPMConfig struct {
+	Path      string              // TPM device, default /dev/tpmrm0
+	Transport transport.TPMCloser // Overrides Path, e.g. for a simulator
+	TamperPCR int                 // PCR extended on tamper events, default 14
+	SealPCRs  []int               // PCRs secrets are sealed to, default secure boot and tamper PCRs
+
+	// Permits a tamper PCR that software can reset from locality 0 (16, 23)
+	AllowResettablePCR bool
+}
+
+// DeviceIdentity identifies a device by its TPM keys
+type DeviceIdentity struct {
+	ID       string           // Stable device ID derived from the endorsement key
+	EKName   []byte           // TPM name of the endorsement key
+	AKName   []byte           // TPM name of the attestation key
+	AKPublic *ecdsa.PublicKey // Verifies quotes signed by the attestation key
+}
+
+// SealedSecret is a secret sealed to the TPM and PCR state
+type SealedSecret struct {
+	Public  []byte `json:"public"`
+	Private []byte `json:"private"`
+	PCRs    []int  `json:"pcrs"`
+}
+
+// TPMQuote is a PCR quote signed by the attestation key
+type TPMQuote struct {
+	Attest    []byte // Marshalled TPMS_ATTEST
+	Signature []byte // Marshalled TPMT_SIGNATURE
+	PCRs      []int
+}
+
+// TPM provides TPM-backed device identity, sealed secrets and tamper measurement
+type TPM struct {
+	mux       sync.Mutex
+	tpm       transport.TPMCloser
+	tamperPCR int
+	sealPCRs  []int
+	identity  DeviceIdentity
+}
+
+// akTemplate is a restricted ECDSA P-256 signing key for quotes
+var akTemplate = tpm2.TPMTPublic{
+	Type:    tpm2.TPMAlgECC,
+	NameAlg: tpm2.TPMAlgSHA256,
+	ObjectAttributes: tpm2.TPMAObject{
+		FixedTPM:            true,
+		FixedParent:         true,
+		SensitiveDataOrigin: true,
+		UserWithAuth:        true,
+		NoDA:                true,
+		Restricted:          true,
+		SignEncrypt:         true,
+	},
+	Parameters: tpm2.NewTPMUPublicParms(
+		tpm2.TPMAlgECC,
+		&tpm2.TPMSECCParms{
+			Scheme: tpm2.TPMTECCScheme{
+				Scheme: tpm2.TPMAlgECDSA,
+				Details: tpm2.NewTPMUAsymScheme(
+					tpm2.TPMAlgECDSA,
+					&tpm2.TPMSSigSchemeECDSA{HashAlg: tpm2.TPMAlgSHA256},
+				),
+			},
+			CurveID: tpm2.TPMECCNistP256,
+		},
+	),
+	Unique: tpm2.NewTPMUPublicID(
+		tpm2.TPMAlgECC,
+		&tpm2.TPMSECCPoint{
+			X: tpm2.TPM2BECCParameter{Buffer: make([]byte, 32)},
+			Y: tpm2.TPM2BECCParameter{Buffer: make([]byte, 32)},
+		},
+	),
+}
+
+// NewTPM opens the TPM and derives the device identity
+func NewTPM(cfg TPMConfig) (*TPM, error) {
+	if cfg.Path == "" {
+		cfg.Path = defaultTPMPath
+	}
+	if cfg.TamperPCR == 0 {
+		cfg.TamperPCR = defaultTamperPCR
+	}
+	if cfg.TamperPCR < 0 || cfg.TamperPCR >= pcrSelectSize*8 {
+		return nil, fmt.Errorf("invalid tamper PCR %d", cfg.TamperPCR)
+	}
+	if resettablePCR(cfg.TamperPCR) && !cfg.AllowResettablePCR {
+		return nil, fmt.Errorf("tamper PCR %d can be reset from locality 0", cfg.TamperPCR)
+	}
+	if len(cfg.SealPCRs) == 0 {
+		cfg.SealPCRs = []int{secureBootPCR, cfg.TamperPCR}
+	}
+
+	tpm := cfg.Transport
+	if tpm == nil {
+		var err error
+		if tpm, err = transport.OpenTPM(cfg.Path); err != nil {
+			return nil, fmt.Errorf("failed to open TPM: %w", err)
+		}
+	}
+
+	t := &TPM{
+		tpm:       tpm,
+		tamperPCR: cfg.TamperPCR,
+		sealPCRs:  cfg.SealPCRs,
+	}
+	if err := t.loadIdentity(); err != nil {
+		tpm.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// resettablePCR reports whether a PCR can be reset from locality 0,
+// which would let software erase a tamper measurement
+func resettablePCR(pcr int) bool {
+	return pcr == 16 || pcr == 23
+}
+
+// createPrimary creates a primary key and returns a flush function
+func (t *TPM) createPrimary(hierarchy tpm2.TPMHandle, template tpm2.TPMTPublic) (*tpm2.CreatePrimaryResponse, func(), error) {
+	rsp, err := tpm2.CreatePrimary{
+		PrimaryHandle: hierarchy,
+		InPublic:      tpm2.New2B(template),
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, nil, err
+	}
+	flush := func() {
+		tpm2.FlushContext{FlushHandle: rsp.ObjectHandle}.Execute(t.tpm)
+	}
+	return rsp, flush, nil
+}
+
+// loadIdentity derives the device identity from the endorsement and attestation keys
+func (t *TPM) loadIdentity() error {
+	ek, flushEK, err := t.createPrimary(tpm2.TPMRHEndorsement, tpm2.ECCEKTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement key: %w", err)
+	}
+	flushEK()
+
+	ak, flushAK, err := t.createPrimary(tpm2.TPMRHEndorsement, akTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to create attestation key: %w", err)
+	}
+	flushAK()
+
+	akPublic, err := ak.OutPublic.Contents()
+	if err != nil {
+		return fmt.Errorf("failed to decode attestation key: %w", err)
+	}
+	point, err := akPublic.Unique.ECC()
+	if err != nil {
+		return fmt.Errorf("failed to decode attestation key: %w", err)
+	}
+
+	// The EK name digest is stable for the life of the TPM
+	digest := sha256.Sum256(ek.Name.Buffer)
+	t.identity = DeviceIdentity{
+		ID:     "tpm-" + hex.EncodeToString(digest[:16]),
+		EKName: ek.Name.Buffer,
+		AKName: ak.Name.Buffer,
+		AKPublic: &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point.X.Buffer),
+			Y:     new(big.Int).SetBytes(point.Y.Buffer),
+		},
+	}
+	return nil
+}
+
+// Identity returns the TPM-derived device identity
+func (t *TPM) Identity() DeviceIdentity {
+	return t.identity
+}
+
+// pcrSelection builds a SHA-256 bank selection bitmap
+func pcrSelection(pcrs []int) (tpm2.TPMLPCRSelection, error) {
+	sel := make([]byte, pcrSelectSize)
+	for _, pcr := range pcrs {
+		if pcr < 0 || pcr >= 8*pcrSelectSize {
+			return tpm2.TPMLPCRSelection{}, fmt.Errorf("invalid PCR index %d", pcr)
+		}
+		sel[pcr/8] |= 1 << (pcr % 8)
+	}
+	return tpm2.TPMLPCRSelection{
+		PCRSelections: []tpm2.TPMSPCRSelection{{
+			Hash:      tpm2.TPMAlgSHA256,
+			PCRSelect: sel,
+		}},
+	}, nil
+}
+
+// ExtendTamperPCR measures a tamper event into the tamper PCR
+func (t *TPM) ExtendTamperPCR(event []byte) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	digest := sha256.Sum256(event)
+	_, err := tpm2.PCRExtend{
+		PCRHandle: tpm2.AuthHandle{
+			Handle: tpm2.TPMHandle(t.tamperPCR),
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		Digests: tpm2.TPMLDigestValues{
+			Digests: []tpm2.TPMTHA{{
+				HashAlg: tpm2.TPMAlgSHA256,
+				Digest:  digest[:],
+			}},
+		},
+	}.Execute(t.tpm)
+	if err != nil {
+		return fmt.Errorf("failed to extend PCR %d: %w", t.tamperPCR, err)
+	}
+	return nil
+}
+
+// ReadPCR returns the SHA-256 bank value of a PCR
+func (t *TPM) ReadPCR(index int) ([]byte, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	sel, err := pcrSelection([]int{index})
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := tpm2.PCRRead{PCRSelectionIn: sel}.Execute(t.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PCR %d: %w", index, err)
+	}
+	if len(rsp.PCRValues.Digests) != 1 {
+		return nil, fmt.Errorf("PCR %d not available in SHA-256 bank", index)
+	}
+	return rsp.PCRValues.Digests[0].Buffer, nil
+}
+
+// pcrPolicy returns a policy callback satisfied by the current PCR values
+func pcrPolicy(sel tpm2.TPMLPCRSelection) tpm2.PolicyCallback {
+	return func(tpm transport.TPM, handle tpm2.TPMISHPolicy, _ tpm2.TPM2BNonce) error {
+		_, err := tpm2.PolicyPCR{PolicySession: handle, Pcrs: sel}.Execute(tpm)
+		return err
+	}
+}
+
+// Seal seals a secret to the storage root key and the current values of the
+// seal PCRs. A later tamper extend changes the tamper PCR, after which the
+// secret can no longer be unsealed.
+func (t *TPM) Seal(secret []byte) (*SealedSecret, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	sel, err := pcrSelection(t.sealPCRs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute the PCR policy digest in a trial session
+	sess, closeSess, err := tpm2.PolicySession(t.tpm, tpm2.TPMAlgSHA256, 16, tpm2.Trial())
+	if err != nil {
+		return nil, fmt.Errorf("failed to start trial session: %w", err)
+	}
+	err = pcrPolicy(sel)(t.tpm, sess.Handle(), tpm2.TPM2BNonce{})
+	var policy *tpm2.PolicyGetDigestResponse
+	if err == nil {
+		policy, err = tpm2.PolicyGetDigest{PolicySession: sess.Handle()}.Execute(t.tpm)
+	}
+	closeSess()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute PCR policy: %w", err)
+	}
+
+	srk, flushSRK, err := t.createPrimary(tpm2.TPMRHOwner, tpm2.ECCSRKTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage root key: %w", err)
+	}
+	defer flushSRK()
+
+	rsp, err := tpm2.Create{
+		ParentHandle: tpm2.AuthHandle{
+			Handle: srk.ObjectHandle,
+			Name:   srk.Name,
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		InSensitive: tpm2.TPM2BSensitiveCreate{
+			Sensitive: &tpm2.TPMSSensitiveCreate{
+				Data: tpm2.NewTPMUSensitiveCreate(&tpm2.TPM2BSensitiveData{Buffer: secret}),
+			},
+		},
+		InPublic: tpm2.New2B(tpm2.TPMTPublic{
+			Type:    tpm2.TPMAlgKeyedHash,
+			NameAlg: tpm2.TPMAlgSHA256,
+			ObjectAttributes: tpm2.TPMAObject{
+				FixedTPM:    true,
+				FixedParent: true,
+				NoDA:        true,
+			},
+			AuthPolicy: policy.PolicyDigest,
+		}),
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal secret: %w", err)
+	}
+
+	return &SealedSecret{
+		Public:  tpm2.Marshal(rsp.OutPublic),
+		Private: tpm2.Marshal(rsp.OutPrivate),
+		PCRs:    append([]int(nil), t.sealPCRs...),
+	}, nil
+}
+
+// Unseal recovers a sealed secret; it fails if the sealed PCRs have changed
+func (t *TPM) Unseal(sealed *SealedSecret) ([]byte, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	public, err := tpm2.Unmarshal[tpm2.TPM2BPublic](sealed.Public)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sealed public area: %w", err)
+	}
+	private, err := tpm2.Unmarshal[tpm2.TPM2BPrivate](sealed.Private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sealed private area: %w", err)
+	}
+	sel, err := pcrSelection(sealed.PCRs)
+	if err != nil {
+		return nil, err
+	}
+
+	srk, flushSRK, err := t.createPrimary(tpm2.TPMRHOwner, tpm2.ECCSRKTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage root key: %w", err)
+	}
+	defer flushSRK()
+
+	loaded, err := tpm2.Load{
+		ParentHandle: tpm2.AuthHandle{
+			Handle: srk.ObjectHandle,
+			Name:   srk.Name,
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		InPrivate: *private,
+		InPublic:  *public,
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sealed secret: %w", err)
+	}
+	defer tpm2.FlushContext{FlushHandle: loaded.ObjectHandle}.Execute(t.tpm)
+
+	rsp, err := tpm2.Unseal{
+		ItemHandle: tpm2.AuthHandle{
+			Handle: loaded.ObjectHandle,
+			Name:   loaded.Name,
+			Auth:   tpm2.Policy(tpm2.TPMAlgSHA256, 16, pcrPolicy(sel)),
+		},
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unseal secret: %w", err)
+	}
+	return rsp.OutData.Buffer, nil
+}
+
+// Quote signs the tamper and seal PCRs with the attestation key for remote attestation
+func (t *TPM) Quote(nonce []byte) (*TPMQuote, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	pcrs := t.sealPCRs
+	sel, err := pcrSelection(pcrs)
+	if err != nil {
+		return nil, err
+	}
+
+	ak, flushAK, err := t.createPrimary(tpm2.TPMRHEndorsement, akTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attestation key: %w", err)
+	}
+	defer flushAK()
+
+	rsp, err := tpm2.Quote{
+		SignHandle: tpm2.AuthHandle{
+			Handle: ak.ObjectHandle,
+			Name:   ak.Name,
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		QualifyingData: tpm2.TPM2BData{Buffer: nonce},
+		InScheme:       tpm2.TPMTSigScheme{Scheme: tpm2.TPMAlgNull},
+		PCRSelect:      sel,
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote PCRs: %w", err)
+	}
+
+	return &TPMQuote{
+		Attest:    rsp.Quoted.Bytes(),
+		Signature: tpm2.Marshal(rsp.Signature),
+		PCRs:      append([]int(nil), pcrs...),
+	}, nil
+}
+
+// measureTamperLocked extends the TPM tamper PCR with a latched tamper event - must be called with lock held
+func (m *Manager) measureTamperLocked(sensor string, at time.Time) {
+	if m.tpm == nil {
+		return
+	}
+	event, err := json.Marshal(map[string]interface{}{
+		"device_id": m.deviceID,
+		"sensor":    sensor,
+		"edge_time": at.UTC(),
+	})
+	if err == nil {
+		err = m.tpm.ExtendTamperPCR(event)
+	}
+	if err != nil {
+		// Log but don't fail on measurement error
+		fmt.Printf("Failed to measure tamper event: %v\n", err)
+	}
+}
+
+// Close releases the TPM
+func (t *TPM) Close() error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.tpm.Close()
+}
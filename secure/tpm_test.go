@@ -0,0 +1,164 @@
+//go:build cgo
+
+// The TPM simulator is a C library and needs cgo
+
+package secure
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpm2/transport/simulator"
+	"periph.io/x/conn/v3/gpio"
+)
+
+func TestTPM(t *testing.T) {
+	sim, err := simulator.OpenSimulator()
+	if err != nil {
+		t.Fatalf("Failed to open TPM simulator: %v", err)
+	}
+	tpm, err := NewTPM(TPMConfig{Transport: sim})
+	if err != nil {
+		t.Fatalf("Failed to create TPM: %v", err)
+	}
+	defer tpm.Close()
+
+	t.Run("Identity", func(t *testing.T) {
+		id := tpm.Identity()
+		if !strings.HasPrefix(id.ID, "tpm-") || len(id.EKName) == 0 || id.AKPublic == nil {
+			t.Fatalf("Incomplete identity: %+v", id)
+		}
+		if err := tpm.loadIdentity(); err != nil {
+			t.Fatalf("Failed to reload identity: %v", err)
+		}
+		if tpm.Identity().ID != id.ID {
+			t.Error("Device identity not stable")
+		}
+	})
+
+	t.Run("Seal Unseal", func(t *testing.T) {
+		sealed, err := tpm.Seal([]byte("api-token"))
+		if err != nil {
+			t.Fatalf("Failed to seal: %v", err)
+		}
+		secret, err := tpm.Unseal(sealed)
+		if err != nil {
+			t.Fatalf("Failed to unseal: %v", err)
+		}
+		if string(secret) != "api-token" {
+			t.Errorf("Unexpected secret %q", secret)
+		}
+	})
+
+	t.Run("Quote", func(t *testing.T) {
+		nonce := []byte("attestation-nonce")
+		quote, err := tpm.Quote(nonce)
+		if err != nil {
+			t.Fatalf("Failed to quote: %v", err)
+		}
+
+		attest, err := tpm2.Unmarshal[tpm2.TPMSAttest](quote.Attest)
+		if err != nil {
+			t.Fatalf("Failed to decode quote: %v", err)
+		}
+		if !bytes.Equal(attest.ExtraData.Buffer, nonce) {
+			t.Error("Quote does not include nonce")
+		}
+
+		sig, err := tpm2.Unmarshal[tpm2.TPMTSignature](quote.Signature)
+		if err != nil {
+			t.Fatalf("Failed to decode signature: %v", err)
+		}
+		ecc, err := sig.Signature.ECDSA()
+		if err != nil {
+			t.Fatalf("Unexpected signature type: %v", err)
+		}
+		digest := sha256.Sum256(quote.Attest)
+		r := new(big.Int).SetBytes(ecc.SignatureR.Buffer)
+		s := new(big.Int).SetBytes(ecc.SignatureS.Buffer)
+		if !ecdsa.Verify(tpm.Identity().AKPublic, digest[:], r, s) {
+			t.Error("Quote signature does not verify with attestation key")
+		}
+	})
+
+	t.Run("Tamper Measurement", func(t *testing.T) {
+		sealed, err := tpm.Seal([]byte("api-token"))
+		if err != nil {
+			t.Fatalf("Failed to seal: %v", err)
+		}
+		before, err := tpm.ReadPCR(defaultTamperPCR)
+		if err != nil {
+			t.Fatalf("Failed to read PCR: %v", err)
+		}
+
+		gpioCtrl, pins := newTestGPIO(t)
+		manager, err := New(Config{
+			GPIO:          gpioCtrl,
+			CaseSensor:    "case",
+			MotionSensor:  "motion",
+			VoltageSensor: "voltage",
+			TPM:           tpm,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create security manager: %v", err)
+		}
+		if manager.deviceID != tpm.Identity().ID {
+			t.Errorf("Expected TPM device ID, got %s", manager.deviceID)
+		}
+
+		pins.casePin.Out(gpio.High)
+		if err := manager.checkSecurity(context.Background()); err != nil {
+			t.Fatalf("Security check failed: %v", err)
+		}
+
+		after, err := tpm.ReadPCR(defaultTamperPCR)
+		if err != nil {
+			t.Fatalf("Failed to read PCR: %v", err)
+		}
+		if bytes.Equal(before, after) {
+			t.Error("Tamper event not measured into PCR")
+		}
+		if _, err := tpm.Unseal(sealed); err == nil {
+			t.Error("Expected unseal to fail after tamper")
+		}
+
+		// Software must not be able to erase the measurement
+		_, err = tpm2.PCRReset{
+			PCRHandle: tpm2.AuthHandle{
+				Handle: tpm2.TPMHandle(defaultTamperPCR),
+				Auth:   tpm2.PasswordAuth(nil),
+			},
+		}.Execute(tpm.tpm)
+		if err == nil {
+			t.Error("Expected tamper PCR reset to fail")
+		}
+		if _, err := tpm.Unseal(sealed); err == nil {
+			t.Error("Expected unseal to fail after attempted PCR reset")
+		}
+	})
+
+	t.Run("Resettable PCR", func(t *testing.T) {
+		for _, pcr := range []int{16, 23} {
+			if _, err := NewTPM(TPMConfig{Transport: tpm.tpm, TamperPCR: pcr}); err == nil {
+				t.Errorf("Expected resettable PCR %d to be rejected", pcr)
+			}
+		}
+		if _, err := NewTPM(TPMConfig{Transport: tpm.tpm, TamperPCR: 24}); err == nil {
+			t.Error("Expected out of range PCR to be rejected")
+		}
+
+		opted, err := NewTPM(TPMConfig{Transport: tpm.tpm, TamperPCR: 23, AllowResettablePCR: true})
+		if err != nil {
+			t.Fatalf("Failed to create TPM with resettable PCR opt-in: %v", err)
+		}
+		if opted.tamperPCR != 23 {
+			t.Errorf("Expected tamper PCR 23, got %d", opted.tamperPCR)
+		}
+	})
+}
@@ -2,17 +2,162 @@ package secure
 
 import (
 	"context"
+	"crypto/ed25519"
 	"time"
 
 	"github.com/wrale/wrale-fleet-metal-hw/gpio"
 )
 
+// Sensor names used in latched tamper state and event details
+const (
+	SensorCase    = "case"
+	SensorMotion  = "motion"
+	SensorVoltage = "voltage"
+)
+
+const (
+	// Supervision poll interval when sensor interrupts are active
+	defaultSupervisionInterval = 1 * time.Second
+	// Poll interval when interrupts are unavailable
+	defaultPollInterval = 100 * time.Millisecond
+	// Debounce applied to sensor interrupts
+	defaultSensorDebounce = 10 * time.Millisecond
+)
+
+// SecurityMode represents the security state machine mode
+type SecurityMode string
+
+const (
+	ModeDisarmed     SecurityMode = "DISARMED"
+	ModeArmed        SecurityMode = "ARMED"
+	ModeAlarm        SecurityMode = "ALARM"
+	ModeAcknowledged SecurityMode = "ACKNOWLEDGED"
+	ModeMaintenance  SecurityMode = "MAINTENANCE"
+)
+
+// ModeTransition describes a security mode change
+type ModeTransition struct {
+	From      SecurityMode
+	To        SecurityMode
+	Reason    string
+	Timestamp time.Time
+	State     TamperState
+}
+
 // TamperState represents the current tamper detection status
 type TamperState struct {
 	CaseOpen       bool
 	MotionDetected bool
 	VoltageNormal  bool
 	LastCheck      time.Time
+
+	// Security state machine mode
+	Mode          SecurityMode
+	ModeChangedAt time.Time
+
+	// Latched alarm transition, held until acknowledged
+	Latched   bool
+	LatchedAt time.Time // Edge timestamp of the latched transition
+	LatchedBy string    // Sensor that caused the latch
+
+	// Secrets wiped by the zeroization policy, cleared when re-armed
+	Zeroized   bool
+	ZeroizedAt time.Time
+
+	// Open token-authorized maintenance window, cleared when maintenance ends
+	MaintenanceID    string
+	MaintenanceBy    string
+	MaintenanceUntil time.Time
+	// Used maintenance token IDs by expiry, kept until each token expires so
+	// a token cannot be replayed after a restart. Replaced rather than modified.
+	UsedTokens map[string]time.Time
+
+	// Registered sensor status by sensor name. The map is replaced rather
+	// than modified, so copies of the state never change underneath callers.
+	Sensors map[string]SensorStatus
+}
+
+// SensorKind identifies the type of a registered security sensor
+type SensorKind string
+
+const (
+	SensorKindCase          SensorKind = "CASE"
+	SensorKindMesh          SensorKind = "MESH"
+	SensorKindLight         SensorKind = "LIGHT"
+	SensorKindAccelerometer SensorKind = "ACCELEROMETER"
+	SensorKindSupervised    SensorKind = "SUPERVISED_LOOP"
+	SensorKindGeofence      SensorKind = "GEOFENCE"
+)
+
+// LoopState is the condition of a supervised tamper loop
+type LoopState string
+
+const (
+	LoopNormal LoopState = "NORMAL"
+	LoopTamper LoopState = "TAMPER" // Switch opened or signal substituted
+	LoopCut    LoopState = "CUT"
+	LoopShort  LoopState = "SHORT"
+)
+
+// SecuritySensor is a pluggable tamper sensor polled by the security manager
+type SecuritySensor interface {
+	// Name uniquely identifies the sensor in state and events
+	Name() string
+	Kind() SensorKind
+	// Read samples the sensor and applies its tamper semantics
+	Read(ctx context.Context) (SensorReading, error)
+}
+
+// SensorReading is a single security sensor sample
+type SensorReading struct {
+	Tampered bool
+	Value    float64 // Kind-specific measurement, e.g. ADC counts or g
+	Detail   string
+	Loop     LoopState // Supervised loops only
+	// Structured measurement data added to tamper event details
+	Data map[string]interface{}
+}
+
+// SensorStatus is the latest status of a registered sensor
+type SensorStatus struct {
+	Kind       SensorKind
+	Tampered   bool
+	Value      float64
+	Detail     string
+	Loop       LoopState
+	Data       map[string]interface{}
+	Fault      string    // Last read error, empty when healthy
+	FaultSince time.Time // When the current read fault began
+	LastChange time.Time // When Tampered or Fault last changed
+}
+
+// ZeroizePolicy wipes secrets when an alarm is not acknowledged in time.
+// The policy fires once per alarm when a trigger sensor has been continuously
+// active for Delay while in ALARM mode; acknowledging the alarm first cancels it.
+type ZeroizePolicy struct {
+	Sensors []string      // Trigger sensors, default case only
+	Delay   time.Duration // How long the sensor must stay active
+	DryRun  bool          // Record what would be wiped without wiping
+	Actions []ZeroizeAction
+
+	// Called with the audit record after the actions run
+	OnZeroize func(ZeroizeAudit)
+}
+
+// ZeroizeRecord describes the outcome of one zeroization action
+type ZeroizeRecord struct {
+	Action  string   `json:"action"`
+	Targets []string `json:"targets,omitempty"` // Items wiped, or that would be in dry run
+	Error   string   `json:"error,omitempty"`
+}
+
+// ZeroizeAudit records a zeroization run
+type ZeroizeAudit struct {
+	Reason      string          `json:"reason"`
+	DryRun      bool            `json:"dry_run"`
+	StartedAt   time.Time       `json:"started_at"`
+	CompletedAt time.Time       `json:"completed_at"`
+	Records     []ZeroizeRecord `json:"records"`
 }
 
 // Config holds the configuration for the security manager
@@ -23,7 +168,31 @@ type Config struct {
 	VoltageSensor string
 	DeviceID      string
 	StateStore    StateStore
-	OnTamper      func(TamperState)
+	OnTamper      func(TamperState)    // Called once when an armed system enters alarm
+	OnModeChange  func(ModeTransition) // Called once per security mode transition
+	InitialMode   SecurityMode         // Mode used when no persisted state exists
+
+	// Polling interval used to supervise interrupt-driven detection while
+	// interrupts are being delivered; the fast poll is used otherwise
+	SupervisionInterval time.Duration
+	// Debounce applied to sensor interrupts
+	SensorDebounce time.Duration
+
+	// Optional secret zeroization on unacknowledged tamper
+	Zeroize *ZeroizePolicy
+
+	// Additional sensors beyond the case, motion and voltage pins
+	Sensors []SecuritySensor
+	// A registered sensor failing to read for this long counts as tampered,
+	// e.g. when it is unplugged; zero holds the last known state instead
+	SensorFaultTamper time.Duration
+
+	// Optional TPM; supplies the device ID when DeviceID is empty and
+	// measures latched tamper events into its tamper PCR
+	TPM *TPM
+
+	// Operator keys trusted to sign maintenance tokens
+	MaintenanceKeys []ed25519.PublicKey
 }
 
 // StateStore defines the interface for persisting security state
@@ -40,8 +209,46 @@ type StateStore interface {
 
 // Event represents a security event
 type Event struct {
-	DeviceID  string
-	Type      string
-	Timestamp time.Time
-	Details   interface{}
+	DeviceID  string      `json:"device_id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Details   interface{} `json:"details,omitempty"`
+
+	// Hash chain fields, set when the event log is signed
+	Seq       uint64 `json:"seq,omitempty"`
+	PrevHash  string `json:"prev_hash,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Signature string `json:"sig,omitempty"`
+}
+
+// EventQuery selects security events; zero fields match everything
+type EventQuery struct {
+	DeviceID string
+	Type     string
+	Since    time.Time // Inclusive lower bound
+	Until    time.Time // Exclusive upper bound
+	Limit    int       // Maximum number of events, oldest first
+}
+
+// matches reports whether an event satisfies the query filters
+func (q EventQuery) matches(e Event) bool {
+	if q.DeviceID != "" && e.DeviceID != q.DeviceID {
+		return false
+	}
+	if q.Type != "" && e.Type != q.Type {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// EventQuerier is implemented by stores that support event queries
+type EventQuerier interface {
+	// QueryEvents returns events matching the query in chronological order
+	QueryEvents(ctx context.Context, q EventQuery) ([]Event, error)
 }
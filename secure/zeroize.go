@@ -0,0 +1,287 @@
+package secure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ZeroizeAction wipes one kind of secret material. Zeroize returns the items
+// it wiped, or in dry run the items it would wipe.
+type ZeroizeAction interface {
+	Name() string
+	Zeroize(ctx context.Context, dryRun bool) ([]string, error)
+}
+
+// deleteFilesAction removes files
+type deleteFilesAction struct {
+	paths []string
+}
+
+// DeleteFiles returns an action that removes the given files
+func DeleteFiles(paths ...string) ZeroizeAction {
+	return &deleteFilesAction{paths: paths}
+}
+
+func (a *deleteFilesAction) Name() string { return "delete_files" }
+
+func (a *deleteFilesAction) Zeroize(ctx context.Context, dryRun bool) ([]string, error) {
+	var wiped []string
+	var errs []error
+	for _, path := range a.paths {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		wiped = append(wiped, path)
+	}
+	return wiped, errors.Join(errs...)
+}
+
+// overwriteFilesAction overwrites files with zeros before removing them
+type overwriteFilesAction struct {
+	paths []string
+}
+
+// OverwriteFiles returns an action that overwrites key files with zeros, syncs
+// them and removes them. Flash wear levelling may retain old blocks, so keys
+// on flash storage should also be encrypted at rest.
+func OverwriteFiles(paths ...string) ZeroizeAction {
+	return &overwriteFilesAction{paths: paths}
+}
+
+func (a *overwriteFilesAction) Name() string { return "overwrite_files" }
+
+func (a *overwriteFilesAction) Zeroize(ctx context.Context, dryRun bool) ([]string, error) {
+	var wiped []string
+	var errs []error
+	for _, path := range a.paths {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		if !dryRun {
+			if err := overwriteFile(path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		wiped = append(wiped, path)
+	}
+	return wiped, errors.Join(errs...)
+}
+
+// scrubDirectoryAction overwrites and removes everything below a directory
+type scrubDirectoryAction struct {
+	dir string
+}
+
+// ScrubDirectory returns an action that overwrites and removes every file
+// below dir, leaving the directory itself in place
+func ScrubDirectory(dir string) ZeroizeAction {
+	return &scrubDirectoryAction{dir: dir}
+}
+
+func (a *scrubDirectoryAction) Name() string { return "scrub_directory" }
+
+func (a *scrubDirectoryAction) Zeroize(ctx context.Context, dryRun bool) ([]string, error) {
+	var wiped []string
+	var errs []error
+	err := filepath.WalkDir(a.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == a.dir {
+				return filepath.SkipDir
+			}
+			errs = append(errs, err)
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if !dryRun {
+				if err := overwriteFile(path); err != nil {
+					errs = append(errs, err)
+					return nil
+				}
+			}
+			wiped = append(wiped, path)
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Remove what remains below the directory: subdirectories and links
+	if !dryRun {
+		entries, err := os.ReadDir(a.dir)
+		if err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(a.dir, entry.Name())); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return wiped, errors.Join(errs...)
+}
+
+// hookAction calls a user function
+type hookAction struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// ZeroizeHook returns an action that calls fn, for secrets held outside the
+// filesystem such as secure elements or in-memory keys. In dry run fn is not called.
+func ZeroizeHook(name string, fn func(ctx context.Context) error) ZeroizeAction {
+	return &hookAction{name: name, fn: fn}
+}
+
+func (a *hookAction) Name() string { return a.name }
+
+func (a *hookAction) Zeroize(ctx context.Context, dryRun bool) ([]string, error) {
+	if !dryRun {
+		if err := a.fn(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return []string{a.name}, nil
+}
+
+// overwriteFile overwrites a file's contents with zeros, syncs and removes it
+func overwriteFile(path string) error {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	zeros := make([]byte, 32*1024)
+	for remaining := info.Size(); remaining > 0; {
+		n := int64(len(zeros))
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := f.Write(zeros[:n]); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to overwrite %s: %w", path, err)
+		}
+		remaining -= n
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// trackSensorLocked records when a sensor became active - must be called with lock held
+func (m *Manager) trackSensorLocked(sensor string, active bool, at time.Time) {
+	if !active {
+		delete(m.activeSince, sensor)
+		return
+	}
+	if _, exists := m.activeSince[sensor]; !exists {
+		m.activeSince[sensor] = at
+	}
+}
+
+// checkZeroizeLocked runs the zeroization policy when its trigger holds - must be called with lock held
+func (m *Manager) checkZeroizeLocked(ctx context.Context, now time.Time) {
+	p := m.zeroize
+	if p == nil || m.zeroizeFired || m.state.Mode != ModeAlarm {
+		return
+	}
+
+	for _, sensor := range p.Sensors {
+		since, active := m.activeSince[sensor]
+		if !active {
+			continue
+		}
+		// Only time spent in alarm counts toward the delay
+		if since.Before(m.state.ModeChangedAt) {
+			since = m.state.ModeChangedAt
+		}
+		if now.Sub(since) >= p.Delay {
+			reason := fmt.Sprintf("%s tamper unacknowledged for %s", sensor, p.Delay)
+			if _, err := m.zeroizeLocked(ctx, reason); err != nil {
+				fmt.Printf("Zeroization incomplete: %v\n", err)
+			}
+			return
+		}
+	}
+}
+
+// zeroizeLocked runs every zeroization action and records an audit - must be called with lock held
+func (m *Manager) zeroizeLocked(ctx context.Context, reason string) (ZeroizeAudit, error) {
+	p := m.zeroize
+	m.zeroizeFired = true
+
+	audit := ZeroizeAudit{
+		Reason:    reason,
+		DryRun:    p.DryRun,
+		StartedAt: time.Now(),
+	}
+
+	// Run every action even if one fails; partial wipes beat none
+	var errs []error
+	for _, action := range p.Actions {
+		targets, err := action.Zeroize(ctx, p.DryRun)
+		record := ZeroizeRecord{Action: action.Name(), Targets: targets}
+		if err != nil {
+			record.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", action.Name(), err))
+		}
+		audit.Records = append(audit.Records, record)
+	}
+	audit.CompletedAt = time.Now()
+
+	eventType := "zeroized"
+	if p.DryRun {
+		eventType = "zeroize_dry_run"
+	} else {
+		m.state.Zeroized = true
+		m.state.ZeroizedAt = audit.CompletedAt
+	}
+	m.logEventLocked(ctx, eventType, map[string]interface{}{
+		"reason":  reason,
+		"records": audit.Records,
+	})
+	if err := m.persistLocked(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if p.OnZeroize != nil {
+		p.OnZeroize(audit)
+	}
+	return audit, errors.Join(errs...)
+}
+
+// Zeroize runs the zeroization actions immediately, honouring the policy's dry-run setting
+func (m *Manager) Zeroize(ctx context.Context, reason string) (ZeroizeAudit, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.zeroize == nil {
+		return ZeroizeAudit{}, fmt.Errorf("no zeroization policy configured")
+	}
+	return m.zeroizeLocked(ctx, reason)
+}
@@ -0,0 +1,167 @@
+package secure
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+)
+
+// secretFiles creates key material for zeroization tests
+type secretFiles struct {
+	token, key, dir string
+}
+
+func newSecretFiles(t *testing.T) secretFiles {
+	t.Helper()
+	root := t.TempDir()
+	files := secretFiles{
+		token: filepath.Join(root, "token"),
+		key:   filepath.Join(root, "device.key"),
+		dir:   filepath.Join(root, "creds"),
+	}
+	for _, path := range []string{files.token, files.key, filepath.Join(files.dir, "sub", "cert.pem")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("secret"), 0o600); err != nil {
+			t.Fatalf("Failed to write secret: %v", err)
+		}
+	}
+	return files
+}
+
+func (f secretFiles) actions(hookCalls *int) []ZeroizeAction {
+	return []ZeroizeAction{
+		DeleteFiles(f.token),
+		OverwriteFiles(f.key),
+		ScrubDirectory(f.dir),
+		ZeroizeHook("secure_element", func(ctx context.Context) error {
+			*hookCalls++
+			return nil
+		}),
+	}
+}
+
+func TestZeroizePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	// newArmedManager creates an armed manager with a zeroization policy
+	newArmedManager := func(t *testing.T, policy *ZeroizePolicy) (*Manager, sensorPins, *memStore) {
+		gpioCtrl, pins := newTestGPIO(t)
+		store := newMemStore()
+		manager, err := New(Config{
+			GPIO:          gpioCtrl,
+			CaseSensor:    "case",
+			MotionSensor:  "motion",
+			VoltageSensor: "voltage",
+			DeviceID:      "test-device",
+			StateStore:    store,
+			Zeroize:       policy,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create security manager: %v", err)
+		}
+		return manager, pins, store
+	}
+
+	t.Run("Wipes After Delay", func(t *testing.T) {
+		files := newSecretFiles(t)
+		hookCalls := 0
+		var audits []ZeroizeAudit
+		manager, pins, store := newArmedManager(t, &ZeroizePolicy{
+			Delay:     20 * time.Millisecond,
+			Actions:   files.actions(&hookCalls),
+			OnZeroize: func(a ZeroizeAudit) { audits = append(audits, a) },
+		})
+
+		pins.casePin.Out(gpio.High)
+		if err := manager.checkSecurity(ctx); err != nil {
+			t.Fatalf("Security check failed: %v", err)
+		}
+		if len(audits) != 0 {
+			t.Fatal("Zeroized before delay elapsed")
+		}
+
+		time.Sleep(30 * time.Millisecond)
+		manager.checkSecurity(ctx)
+		manager.checkSecurity(ctx)
+
+		if len(audits) != 1 {
+			t.Fatalf("Expected one zeroization, got %d", len(audits))
+		}
+		if audits[0].DryRun || len(audits[0].Records) != 4 {
+			t.Errorf("Unexpected audit: %+v", audits[0])
+		}
+		for _, path := range []string{files.token, files.key, filepath.Join(files.dir, "sub")} {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("Expected %s to be wiped", path)
+			}
+		}
+		if _, err := os.Stat(files.dir); err != nil {
+			t.Error("Scrubbed directory itself should remain")
+		}
+		if hookCalls != 1 {
+			t.Errorf("Expected hook to be called once, got %d", hookCalls)
+		}
+		if !manager.GetState().Zeroized || store.count("zeroized") != 1 {
+			t.Error("Zeroization not recorded")
+		}
+	})
+
+	t.Run("Dry Run", func(t *testing.T) {
+		files := newSecretFiles(t)
+		hookCalls := 0
+		var audit ZeroizeAudit
+		manager, pins, store := newArmedManager(t, &ZeroizePolicy{
+			Delay:     time.Millisecond,
+			DryRun:    true,
+			Actions:   files.actions(&hookCalls),
+			OnZeroize: func(a ZeroizeAudit) { audit = a },
+		})
+
+		pins.casePin.Out(gpio.High)
+		manager.checkSecurity(ctx)
+		time.Sleep(5 * time.Millisecond)
+		manager.checkSecurity(ctx)
+
+		if !audit.DryRun || store.count("zeroize_dry_run") != 1 {
+			t.Fatal("Expected dry-run audit")
+		}
+		if targets := audit.Records[2].Targets; len(targets) != 1 || filepath.Base(targets[0]) != "cert.pem" {
+			t.Errorf("Expected scrub to list cert.pem, got %v", targets)
+		}
+		for _, path := range []string{files.token, files.key, filepath.Join(files.dir, "sub", "cert.pem")} {
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("Dry run removed %s", path)
+			}
+		}
+		if hookCalls != 0 || manager.GetState().Zeroized {
+			t.Error("Dry run performed a wipe")
+		}
+	})
+
+	t.Run("Acknowledge Cancels", func(t *testing.T) {
+		files := newSecretFiles(t)
+		hookCalls := 0
+		manager, pins, _ := newArmedManager(t, &ZeroizePolicy{
+			Delay:   20 * time.Millisecond,
+			Actions: files.actions(&hookCalls),
+		})
+
+		pins.casePin.Out(gpio.High)
+		manager.checkSecurity(ctx)
+		if err := manager.Acknowledge(ctx); err != nil {
+			t.Fatalf("Failed to acknowledge: %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+		manager.checkSecurity(ctx)
+
+		if _, err := os.Stat(files.token); err != nil || hookCalls != 0 {
+			t.Error("Acknowledged alarm still zeroized")
+		}
+	})
+}
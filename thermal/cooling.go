@@ -2,6 +2,7 @@ package thermal
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/wrale/wrale-fleet-metal-hw/gpio"
 )
@@ -21,6 +22,12 @@ const (
 func (m *Monitor) updateCooling() {
 	m.mux.Lock()
 	defer m.mux.Unlock()
+	m.updateCoolingLocked()
+}
+
+// updateCoolingLocked adjusts cooling based on temperatures - must be called with lock held
+func (m *Monitor) updateCoolingLocked() {
+	now := time.Now()
 
 	// Determine maximum temperature
 	maxTemp := m.state.CPUTemp
@@ -30,10 +37,11 @@ func (m *Monitor) updateCooling() {
 
 	// Calculate fan speed based on temperature ranges
 	var dutyCycle uint32
+	throttle := false
 	switch {
 	case maxTemp >= cpuTempCritical:
 		dutyCycle = fanSpeedHigh
-		m.setThrottlingLocked(true)
+		throttle = true
 	case maxTemp >= cpuTempWarning:
 		// Linear interpolation between medium and high speed
 		tempRange := cpuTempCritical - cpuTempWarning
@@ -47,7 +55,6 @@ func (m *Monitor) updateCooling() {
 		} else {
 			dutyCycle = fanSpeedMedium + uint32(speedRange*(tempAboveWarning/tempRange))
 		}
-		m.setThrottlingLocked(false)
 	case maxTemp >= (cpuTempWarning / 2):
 		// Linear interpolation between low and medium speed
 		tempRange := cpuTempWarning - (cpuTempWarning / 2)
@@ -61,10 +68,45 @@ func (m *Monitor) updateCooling() {
 		} else {
 			dutyCycle = fanSpeedLow + uint32(speedRange*(tempAboveMin/tempRange))
 		}
-		m.setThrottlingLocked(false)
 	default:
 		dutyCycle = fanSpeedLow
-		m.setThrottlingLocked(false)
+	}
+
+	// Fail-safe cooling while runaway protection is engaged
+	if m.state.Runaway != RunawayNone {
+		dutyCycle = fanSpeedHigh
+		throttle = true
+	}
+	// Heater interlock: heating stops on real cooling demand, fan held at minimum while heating
+	need := coolingNone
+	switch {
+	case throttle:
+		need = coolingUrgent
+	case maxTemp >= cpuTempWarning:
+		need = coolingWarning
+	}
+	m.updateHeaterLocked(need, now)
+	if m.state.HeaterOn {
+		dutyCycle = fanSpeedLow
+	}
+
+	// Ventilate on condensation risk unless the heater is already running
+	if _, vent := m.condensationActionLocked(); vent && !m.state.HeaterOn && dutyCycle < fanSpeedMedium {
+		dutyCycle = fanSpeedMedium
+	}
+
+	m.setThrottlingLocked(throttle)
+	m.updateKernelThrottleLocked(maxTemp, throttle)
+	if m.kernelThrottle != nil {
+		m.state.Throttled = throttle || m.state.ThrottleLevel > 0
+	}
+
+	// Apply the acoustic profile, always overridden at critical temperatures
+	dutyCycle = m.applyFanProfileLocked(dutyCycle, throttle, now)
+
+	// A manual override wins unless full cooling is required
+	if m.fanOverride != nil && !throttle {
+		dutyCycle = *m.fanOverride
 	}
 
 	// Update fan speed if changed
@@ -134,8 +176,14 @@ func (m *Monitor) setThrottlingLocked(enabled bool) {
 	m.state.Throttled = enabled
 }
 
-// Close releases fan control resources
+// Close releases fan control resources, restores kernel throttling and switches off the heater
 func (m *Monitor) Close() error {
+	if err := m.heaterOff(); err != nil {
+		return fmt.Errorf("failed to switch off heater: %w", err)
+	}
+	if err := m.restoreKernelThrottle(); err != nil {
+		return fmt.Errorf("failed to restore kernel throttling: %w", err)
+	}
 	if m.fanPin != "" {
 		if err := m.gpio.DisablePWM(m.fanPin); err != nil {
 			return fmt.Errorf("failed to disable fan PWM: %w", err)
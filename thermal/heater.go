@@ -0,0 +1,141 @@
+package thermal
+
+import (
+	"fmt"
+	"time"
+)
+
+// coolingNeed grades the cooling demand applied to the heater interlock
+type coolingNeed int
+
+const (
+	coolingNone    coolingNeed = iota
+	coolingWarning             // Warning temperatures, heating stops after its minimum on time
+	coolingUrgent              // Critical temperatures or throttling, heating stops immediately
+)
+
+// heaterControl tracks heater relay state
+type heaterControl struct {
+	cfg       HeaterConfig
+	on        bool
+	changedAt time.Time
+}
+
+// applyHeaterDefaults fills unset heater configuration values
+func applyHeaterDefaults(cfg *HeaterConfig) {
+	if len(cfg.Sensors) == 0 {
+		cfg.Sensors = []Sensor{SensorAmbient}
+	}
+	if cfg.OnTemp == 0 && cfg.OffTemp == 0 {
+		cfg.OnTemp = defaultHeaterOnTemp
+		cfg.OffTemp = defaultHeaterOffTemp
+	}
+	if cfg.MinOnTime == 0 {
+		cfg.MinOnTime = defaultHeaterMinOnTime
+	}
+	if cfg.MinOffTime == 0 {
+		cfg.MinOffTime = defaultHeaterMinOffTime
+	}
+}
+
+// initHeater configures heater control and ensures the relay starts off
+func (m *Monitor) initHeater(cfg HeaterConfig) error {
+	applyHeaterDefaults(&cfg)
+	if cfg.OffTemp <= cfg.OnTemp {
+		return fmt.Errorf("heater off temperature must be above on temperature")
+	}
+
+	if err := m.gpio.SetPinState(m.heaterPin, false); err != nil {
+		return fmt.Errorf("failed to set heater off: %w", err)
+	}
+
+	m.heater = &heaterControl{cfg: cfg}
+	return nil
+}
+
+// sensorTempLocked returns the latest reading for a configured sensor - must be called with lock held
+func (m *Monitor) sensorTempLocked(sensor Sensor) (float64, bool) {
+	switch sensor {
+	case SensorCPU:
+		return m.state.CPUTemp, m.cpuTemp != ""
+	case SensorGPU:
+		return m.state.GPUTemp, m.gpuTemp != ""
+	case SensorAmbient:
+		return m.state.AmbientTemp, m.ambientTemp != ""
+	default:
+		return 0, false
+	}
+}
+
+// updateHeaterLocked switches the heater based on low temperatures - must be called with lock held
+func (m *Monitor) updateHeaterLocked(need coolingNeed, now time.Time) {
+	h := m.heater
+	if h == nil {
+		return
+	}
+
+	// Find the coldest configured sensor
+	var (
+		coldest float64
+		found   bool
+	)
+	for _, sensor := range h.cfg.Sensors {
+		temp, ok := m.sensorTempLocked(sensor)
+		if !ok {
+			continue
+		}
+		if !found || temp < coldest {
+			coldest = temp
+			found = true
+		}
+	}
+
+	heat, _ := m.condensationActionLocked()
+	cold := found && coldest < h.cfg.OnTemp
+	warm := !found || coldest > h.cfg.OffTemp
+
+	want := h.on
+	switch {
+	case need == coolingUrgent || m.state.Runaway != RunawayNone:
+		// Interlock: never heat while cooling is urgently required
+		want = false
+	case need == coolingWarning:
+		// Never start heating on cooling demand, stop once the minimum on time has run
+		want = h.on && now.Sub(h.changedAt) < h.cfg.MinOnTime
+	case !h.on && (cold || heat):
+		want = now.Sub(h.changedAt) >= h.cfg.MinOffTime || h.changedAt.IsZero()
+	case h.on && warm && !heat:
+		want = now.Sub(h.changedAt) < h.cfg.MinOnTime
+	}
+
+	if want == h.on {
+		return
+	}
+	if !want && need != coolingNone {
+		m.state.addWarning("Heater disabled by cooling interlock")
+	}
+
+	if err := m.gpio.SetPinState(m.heaterPin, want); err != nil {
+		m.state.addWarning(fmt.Sprintf("Failed to set heater state: %v", err))
+		return
+	}
+	h.on = want
+	h.changedAt = now
+	m.state.HeaterOn = want
+}
+
+// heaterOff de-energizes the heater relay
+func (m *Monitor) heaterOff() error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.heater == nil {
+		return nil
+	}
+	if err := m.gpio.SetPinState(m.heaterPin, false); err != nil {
+		return err
+	}
+	m.heater.on = false
+	m.state.HeaterOn = false
+	return nil
+}
@@ -0,0 +1,119 @@
+package thermal
+
+import (
+	"testing"
+	"time"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/gpio"
+)
+
+func TestHeaterControl(t *testing.T) {
+	gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	heaterPin := &mockThrottlePin{}
+	if err := gpioCtrl.ConfigurePin("test_heater", heaterPin, gpio.Float); err != nil {
+		t.Fatalf("Failed to configure heater pin: %v", err)
+	}
+
+	monitor, err := New(Config{
+		GPIO:            gpioCtrl,
+		AmbientTempPath: "/sys/class/thermal/thermal_zone1/temp",
+		HeaterPin:       "test_heater",
+		HeaterConfig: &HeaterConfig{
+			OnTemp:     0,
+			OffTemp:    4,
+			MinOnTime:  time.Minute,
+			MinOffTime: time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create thermal monitor: %v", err)
+	}
+
+	// update sets the ambient temperature and evaluates the heater at a given time
+	start := time.Now()
+	update := func(ambient float64, at time.Duration, need coolingNeed) ThermalState {
+		monitor.mux.Lock()
+		defer monitor.mux.Unlock()
+		monitor.state.AmbientTemp = ambient
+		monitor.updateHeaterLocked(need, start.Add(at))
+		return monitor.state
+	}
+
+	t.Run("Low Temperature", func(t *testing.T) {
+		if state := update(2, 0, coolingNone); state.HeaterOn {
+			t.Error("Heater on inside hysteresis band")
+		}
+		if state := update(-1, time.Second, coolingNone); !state.HeaterOn {
+			t.Error("Heater not switched on below threshold")
+		}
+		if heaterPin.Read() != gpio.High {
+			t.Error("Heater relay not energized")
+		}
+	})
+
+	t.Run("Minimum On Time", func(t *testing.T) {
+		if state := update(6, 30*time.Second, coolingNone); !state.HeaterOn {
+			t.Error("Heater switched off before minimum on time")
+		}
+		if state := update(6, 2*time.Minute, coolingNone); state.HeaterOn {
+			t.Error("Heater not switched off above threshold")
+		}
+	})
+
+	t.Run("Minimum Off Time", func(t *testing.T) {
+		if state := update(-1, 2*time.Minute+10*time.Second, coolingNone); state.HeaterOn {
+			t.Error("Heater switched on before minimum off time")
+		}
+		if state := update(-1, 4*time.Minute, coolingNone); !state.HeaterOn {
+			t.Error("Heater not switched on after minimum off time")
+		}
+	})
+
+	t.Run("Cooling Interlock", func(t *testing.T) {
+		// Warning temperatures stop heating only after the minimum on time
+		if state := update(-1, 4*time.Minute+time.Second, coolingWarning); !state.HeaterOn {
+			t.Error("Heater switched off before minimum on time at warning temperature")
+		}
+		if state := update(-1, 5*time.Minute+time.Second, coolingWarning); state.HeaterOn {
+			t.Error("Heater left on during cooling demand")
+		}
+		if state := update(-1, 7*time.Minute, coolingWarning); state.HeaterOn {
+			t.Error("Heater switched on during cooling demand")
+		}
+
+		// Fan must stay at minimum while heating, even with a warm CPU
+		update(-1, 10*time.Minute, coolingNone)
+		monitor.mux.Lock()
+		monitor.state.CPUTemp = 50
+		monitor.mux.Unlock()
+		monitor.updateCooling()
+		if state := monitor.GetState(); !state.HeaterOn || state.Throttled {
+			t.Errorf("Unexpected heating state: heater %v, throttled %v", state.HeaterOn, state.Throttled)
+		}
+
+		// Critical CPU temperature overrides heating
+		monitor.mux.Lock()
+		monitor.state.CPUTemp = cpuTempCritical
+		monitor.mux.Unlock()
+		monitor.updateCooling()
+		if state := monitor.GetState(); state.HeaterOn {
+			t.Error("Heater left on at critical CPU temperature")
+		}
+	})
+
+	t.Run("Invalid Thresholds", func(t *testing.T) {
+		_, err := New(Config{
+			GPIO:         gpioCtrl,
+			HeaterPin:    "test_heater",
+			HeaterConfig: &HeaterConfig{OnTemp: 5, OffTemp: 2},
+		})
+		if err == nil {
+			t.Error("Expected error for inverted heater thresholds")
+		}
+	})
+}
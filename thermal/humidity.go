@@ -0,0 +1,97 @@
+package thermal
+
+import (
+	"fmt"
+	"math"
+)
+
+// Magnus formula coefficients, valid from -45°C to 60°C over water
+const (
+	magnusB = 17.62
+	magnusC = 243.12
+)
+
+// DewPoint computes the dew point in Celsius from air temperature and relative humidity
+func DewPoint(temp, relativeHumidity float64) (float64, error) {
+	if relativeHumidity <= 0 || math.IsNaN(relativeHumidity) {
+		return 0, fmt.Errorf("dew point undefined at %.1f%% relative humidity", relativeHumidity)
+	}
+	if relativeHumidity > 100 {
+		relativeHumidity = 100
+	}
+	gamma := math.Log(relativeHumidity/100) + magnusB*temp/(magnusC+temp)
+	return magnusC * gamma / (magnusB - gamma), nil
+}
+
+// applyCondensationDefaults fills unset condensation configuration values
+func applyCondensationDefaults(cfg *CondensationConfig) {
+	if cfg.Margin == nil {
+		margin := defaultCondensationMargin
+		cfg.Margin = &margin
+	}
+}
+
+// readHumidity reads the humidity sensor, if configured. Sensors block for
+// their conversion time, so this is called without the lock held.
+func (m *Monitor) readHumidity() (HumidityReading, error) {
+	if m.humidity == nil {
+		return HumidityReading{}, nil
+	}
+	return m.humidity.ReadHumidity()
+}
+
+// updateHumidityLocked evaluates condensation risk from a humidity reading - must be called with lock held
+func (m *Monitor) updateHumidityLocked(reading HumidityReading, err error) {
+	if m.humidity == nil {
+		return
+	}
+
+	if err != nil {
+		// Humidity sensors are prone to transient failures, keep the last reading
+		m.state.addWarning(fmt.Sprintf("Failed to read humidity: %v", err))
+		return
+	}
+	dewPoint, err := DewPoint(reading.Temperature, reading.RelativeHumidity)
+	if err != nil {
+		m.state.addWarning(fmt.Sprintf("Invalid humidity reading: %v", err))
+		return
+	}
+
+	m.state.Humidity = reading.RelativeHumidity
+	m.state.DewPoint = dewPoint
+
+	// Compare the dew point against the coldest surface, including the sensor's own air temperature
+	coldest := reading.Temperature
+	for _, sensor := range m.condensationSurfaces() {
+		if temp, ok := m.sensorTempLocked(sensor); ok && temp < coldest {
+			coldest = temp
+		}
+	}
+
+	risk := coldest-m.state.DewPoint <= *m.condensation.Margin
+	wasRisk := m.state.Condensation
+	m.state.Condensation = risk
+	if risk {
+		m.state.addWarning(fmt.Sprintf("%s: surface %.1f°C near dew point %.1f°C",
+			WarningCondensation, coldest, m.state.DewPoint))
+		if !wasRisk && m.condensation.OnCondensationRisk != nil {
+			m.condensation.OnCondensationRisk(m.state)
+		}
+	}
+}
+
+// condensationSurfaces returns the sensors treated as condensation surfaces
+func (m *Monitor) condensationSurfaces() []Sensor {
+	if len(m.condensation.SurfaceSensors) > 0 {
+		return m.condensation.SurfaceSensors
+	}
+	return []Sensor{SensorCPU, SensorGPU, SensorAmbient}
+}
+
+// condensationActionLocked reports whether condensation risk requests heating or venting - must be called with lock held
+func (m *Monitor) condensationActionLocked() (heat, vent bool) {
+	if m.humidity == nil || !m.state.Condensation {
+		return false, false
+	}
+	return m.condensation.HeatOnRisk, m.condensation.VentOnRisk
+}
@@ -0,0 +1,303 @@
+package thermal
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/i2c"
+)
+
+// Humidity sensor timing and addressing
+const (
+	SHT3xDefaultAddr  = 0x44
+	BME280DefaultAddr = 0x76
+
+	sht3xMeasureTime  = 15 * time.Millisecond
+	bme280MeasureTime = 10 * time.Millisecond
+	bme280ChipID      = 0x60
+
+	dht22StartTime    = 2 * time.Millisecond
+	dht22MinInterval  = 2 * time.Second
+	dht22EdgeTimeout  = time.Millisecond
+	dht22OneThreshold = 50 * time.Microsecond
+)
+
+// sensirionCRC computes the CRC-8 used by Sensirion sensors (poly 0x31, init 0xFF)
+func sensirionCRC(data []byte) byte {
+	crc := byte(0xFF)
+	for _, b := range data {
+		crc ^= b
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// SHT3x reads a Sensirion SHT3x humidity sensor over I2C
+type SHT3x struct {
+	dev i2c.Dev
+}
+
+// NewSHT3x creates an SHT3x sensor on the given bus, using the default address when addr is zero
+func NewSHT3x(bus i2c.Bus, addr uint16) *SHT3x {
+	if addr == 0 {
+		addr = SHT3xDefaultAddr
+	}
+	return &SHT3x{dev: i2c.Dev{Bus: bus, Addr: addr}}
+}
+
+// ReadHumidity performs a single-shot high repeatability measurement
+func (s *SHT3x) ReadHumidity() (HumidityReading, error) {
+	if err := s.dev.Tx([]byte{0x24, 0x00}, nil); err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to start SHT3x measurement: %w", err)
+	}
+	time.Sleep(sht3xMeasureTime)
+
+	buf := make([]byte, 6)
+	if err := s.dev.Tx(nil, buf); err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to read SHT3x measurement: %w", err)
+	}
+	if sensirionCRC(buf[0:2]) != buf[2] || sensirionCRC(buf[3:5]) != buf[5] {
+		return HumidityReading{}, fmt.Errorf("SHT3x measurement CRC mismatch")
+	}
+
+	rawTemp := uint16(buf[0])<<8 | uint16(buf[1])
+	rawHum := uint16(buf[3])<<8 | uint16(buf[4])
+	return HumidityReading{
+		Temperature:      -45 + 175*float64(rawTemp)/65535,
+		RelativeHumidity: 100 * float64(rawHum) / 65535,
+	}, nil
+}
+
+// bme280Calibration holds the factory trimming parameters used for compensation
+type bme280Calibration struct {
+	t1     uint16
+	t2, t3 int16
+	h1, h3 uint8
+	h2     int16
+	h4, h5 int16
+	h6     int8
+}
+
+// BME280 reads a Bosch BME280 environmental sensor over I2C
+type BME280 struct {
+	mux   sync.Mutex
+	dev   i2c.Dev
+	calib *bme280Calibration
+}
+
+// NewBME280 creates a BME280 sensor on the given bus, using the default address when addr is zero
+func NewBME280(bus i2c.Bus, addr uint16) *BME280 {
+	if addr == 0 {
+		addr = BME280DefaultAddr
+	}
+	return &BME280{dev: i2c.Dev{Bus: bus, Addr: addr}}
+}
+
+// readRegs reads consecutive registers starting at reg
+func (b *BME280) readRegs(reg byte, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if err := b.dev.Tx([]byte{reg}, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// loadCalibration verifies the chip ID and reads the trimming parameters
+func (b *BME280) loadCalibration() error {
+	id, err := b.readRegs(0xD0, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read BME280 chip ID: %w", err)
+	}
+	if id[0] != bme280ChipID {
+		return fmt.Errorf("unexpected BME280 chip ID 0x%02x", id[0])
+	}
+
+	tp, err := b.readRegs(0x88, 26)
+	if err != nil {
+		return fmt.Errorf("failed to read BME280 calibration: %w", err)
+	}
+	h, err := b.readRegs(0xE1, 7)
+	if err != nil {
+		return fmt.Errorf("failed to read BME280 humidity calibration: %w", err)
+	}
+
+	b.calib = &bme280Calibration{
+		t1: uint16(tp[1])<<8 | uint16(tp[0]),
+		t2: int16(uint16(tp[3])<<8 | uint16(tp[2])),
+		t3: int16(uint16(tp[5])<<8 | uint16(tp[4])),
+		h1: tp[25],
+		h2: int16(uint16(h[1])<<8 | uint16(h[0])),
+		h3: h[2],
+		h4: int16(int8(h[3]))<<4 | int16(h[4]&0x0F),
+		h5: int16(int8(h[5]))<<4 | int16(h[4]>>4),
+		h6: int8(h[6]),
+	}
+	return nil
+}
+
+// ReadHumidity triggers a forced-mode measurement and compensates the result
+func (b *BME280) ReadHumidity() (HumidityReading, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if b.calib == nil {
+		if err := b.loadCalibration(); err != nil {
+			return HumidityReading{}, err
+		}
+	}
+
+	// Humidity oversampling must be written before ctrl_meas to take effect
+	if err := b.dev.Tx([]byte{0xF2, 0x01}, nil); err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to configure BME280 humidity: %w", err)
+	}
+	if err := b.dev.Tx([]byte{0xF4, 0x25}, nil); err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to start BME280 measurement: %w", err)
+	}
+	time.Sleep(bme280MeasureTime)
+
+	data, err := b.readRegs(0xF7, 8)
+	if err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to read BME280 measurement: %w", err)
+	}
+	adcT := int32(data[3])<<12 | int32(data[4])<<4 | int32(data[5])>>4
+	adcH := int32(data[6])<<8 | int32(data[7])
+
+	return b.calib.compensate(adcT, adcH), nil
+}
+
+// compensate converts raw readings using the datasheet floating point formulas
+func (c *bme280Calibration) compensate(adcT, adcH int32) HumidityReading {
+	var1 := (float64(adcT)/16384.0 - float64(c.t1)/1024.0) * float64(c.t2)
+	var2 := float64(adcT)/131072.0 - float64(c.t1)/8192.0
+	var2 = var2 * var2 * float64(c.t3)
+	tFine := var1 + var2
+
+	h := tFine - 76800.0
+	h = (float64(adcH) - (float64(c.h4)*64.0 + float64(c.h5)/16384.0*h)) *
+		(float64(c.h2) / 65536.0 * (1.0 + float64(c.h6)/67108864.0*h*(1.0+float64(c.h3)/67108864.0*h)))
+	h *= 1.0 - float64(c.h1)*h/524288.0
+	if h > 100 {
+		h = 100
+	} else if h < 0 {
+		h = 0
+	}
+
+	return HumidityReading{
+		Temperature:      tFine / 5120.0,
+		RelativeHumidity: h,
+	}
+}
+
+// DHT22 reads an AM2302/DHT22 single-wire humidity sensor on a GPIO pin
+type DHT22 struct {
+	mux      sync.Mutex
+	gpio     *gpio.Controller
+	pin      string
+	last     HumidityReading
+	lastRead time.Time
+}
+
+// NewDHT22 creates a DHT22 sensor on a data pin configured on the GPIO controller
+func NewDHT22(gpioCtrl *gpio.Controller, pin string) *DHT22 {
+	return &DHT22{gpio: gpioCtrl, pin: pin}
+}
+
+// ReadHumidity triggers a DHT22 transfer, returning the cached reading within the minimum interval
+func (d *DHT22) ReadHumidity() (HumidityReading, error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	if !d.lastRead.IsZero() && time.Since(d.lastRead) < dht22MinInterval {
+		return d.last, nil
+	}
+
+	// Host start signal: hold the line low, then release it to the pull-up
+	if err := d.gpio.SetPinState(d.pin, false); err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to send DHT22 start signal: %w", err)
+	}
+	time.Sleep(dht22StartTime)
+	if err := d.gpio.SetPinEdge(d.pin, gpio.PullUp, gpio.Both); err != nil {
+		return HumidityReading{}, fmt.Errorf("failed to release DHT22 data line: %w", err)
+	}
+
+	// Capture the width of every high pulse until the line goes quiet
+	var (
+		highs []time.Duration
+		rose  time.Time
+	)
+	for {
+		edge, err := d.gpio.WaitForEdge(d.pin, dht22EdgeTimeout)
+		if err != nil {
+			return HumidityReading{}, fmt.Errorf("failed to read DHT22 data line: %w", err)
+		}
+		if !edge {
+			break
+		}
+		now := time.Now()
+		high, err := d.gpio.GetPinState(d.pin)
+		if err != nil {
+			return HumidityReading{}, fmt.Errorf("failed to read DHT22 data line: %w", err)
+		}
+		if high {
+			rose = now
+		} else if !rose.IsZero() {
+			highs = append(highs, now.Sub(rose))
+		}
+	}
+
+	frame, err := dht22Frame(highs)
+	if err != nil {
+		return HumidityReading{}, err
+	}
+	reading, err := decodeDHT22(frame)
+	if err != nil {
+		return HumidityReading{}, err
+	}
+
+	d.last = reading
+	d.lastRead = time.Now()
+	return reading, nil
+}
+
+// dht22Frame converts the data bit high pulse widths into the 5-byte frame
+func dht22Frame(highs []time.Duration) ([5]byte, error) {
+	var frame [5]byte
+	if len(highs) < 40 {
+		return frame, fmt.Errorf("incomplete DHT22 transfer: %d bits", len(highs))
+	}
+
+	// The sensor's response pulse precedes the 40 data bits
+	bits := highs[len(highs)-40:]
+	for i, width := range bits {
+		frame[i/8] <<= 1
+		if width > dht22OneThreshold {
+			frame[i/8] |= 1
+		}
+	}
+	return frame, nil
+}
+
+// decodeDHT22 validates and decodes a DHT22 frame
+func decodeDHT22(frame [5]byte) (HumidityReading, error) {
+	sum := frame[0] + frame[1] + frame[2] + frame[3]
+	if sum != frame[4] {
+		return HumidityReading{}, fmt.Errorf("DHT22 checksum mismatch")
+	}
+
+	temp := float64(uint16(frame[2]&0x7F)<<8|uint16(frame[3])) / 10
+	if frame[2]&0x80 != 0 {
+		temp = -temp
+	}
+	return HumidityReading{
+		Temperature:      temp,
+		RelativeHumidity: float64(uint16(frame[0])<<8|uint16(frame[1])) / 10,
+	}, nil
+}
@@ -0,0 +1,202 @@
+package thermal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpiotest"
+	"periph.io/x/conn/v3/physic"
+)
+
+// mockHumiditySensor returns a fixed humidity reading
+type mockHumiditySensor struct {
+	reading HumidityReading
+}
+
+func (m *mockHumiditySensor) ReadHumidity() (HumidityReading, error) { return m.reading, nil }
+
+// mockI2CBus replays a canned response for every read
+type mockI2CBus struct {
+	response []byte
+	writes   [][]byte
+}
+
+func (m *mockI2CBus) String() string                    { return "mock_i2c" }
+func (m *mockI2CBus) SetSpeed(f physic.Frequency) error { return nil }
+func (m *mockI2CBus) Tx(addr uint16, w, r []byte) error {
+	if len(w) > 0 {
+		m.writes = append(m.writes, append([]byte(nil), w...))
+	}
+	copy(r, m.response)
+	return nil
+}
+
+func TestHumidity(t *testing.T) {
+	t.Run("Dew Point", func(t *testing.T) {
+		if dp, _ := DewPoint(25, 60); math.Abs(dp-16.7) > 0.1 {
+			t.Errorf("Expected dew point ~16.7°C, got %.2f", dp)
+		}
+		if dp, _ := DewPoint(10, 100); math.Abs(dp-10) > 0.01 {
+			t.Errorf("Expected dew point equal to temperature at saturation, got %.2f", dp)
+		}
+		if _, err := DewPoint(10, 0); err == nil {
+			t.Error("Expected error for zero relative humidity")
+		}
+	})
+
+	t.Run("SHT3x", func(t *testing.T) {
+		if crc := sensirionCRC([]byte{0xBE, 0xEF}); crc != 0x92 {
+			t.Fatalf("Expected CRC 0x92, got 0x%02x", crc)
+		}
+
+		bus := &mockI2CBus{response: []byte{0x66, 0x66, 0, 0x80, 0x00, 0}}
+		bus.response[2] = sensirionCRC(bus.response[0:2])
+		bus.response[5] = sensirionCRC(bus.response[3:5])
+
+		reading, err := NewSHT3x(bus, 0).ReadHumidity()
+		if err != nil {
+			t.Fatalf("Failed to read SHT3x: %v", err)
+		}
+		if math.Abs(reading.Temperature-25) > 0.1 || math.Abs(reading.RelativeHumidity-50) > 0.1 {
+			t.Errorf("Unexpected SHT3x reading: %+v", reading)
+		}
+
+		bus.response[5] ^= 0xFF
+		if _, err := NewSHT3x(bus, 0).ReadHumidity(); err == nil {
+			t.Error("Expected CRC error")
+		}
+	})
+
+	t.Run("DHT22", func(t *testing.T) {
+		// Datasheet example frame: 65.2%RH, 35.1°C
+		frame := [5]byte{0x02, 0x8C, 0x01, 0x5F, 0xEE}
+		highs := []time.Duration{80 * time.Microsecond}
+		for _, b := range frame {
+			for i := 7; i >= 0; i-- {
+				if b&(1<<uint(i)) != 0 {
+					highs = append(highs, 70*time.Microsecond)
+				} else {
+					highs = append(highs, 27*time.Microsecond)
+				}
+			}
+		}
+
+		decoded, err := dht22Frame(highs)
+		if err != nil {
+			t.Fatalf("Failed to decode DHT22 pulses: %v", err)
+		}
+		reading, err := decodeDHT22(decoded)
+		if err != nil {
+			t.Fatalf("Failed to decode DHT22 frame: %v", err)
+		}
+		if math.Abs(reading.RelativeHumidity-65.2) > 0.01 || math.Abs(reading.Temperature-35.1) > 0.01 {
+			t.Errorf("Unexpected DHT22 reading: %+v", reading)
+		}
+
+		if r, _ := decodeDHT22([5]byte{0x02, 0x8C, 0x80, 0x65, 0x73}); r.Temperature != -10.1 {
+			t.Errorf("Expected negative temperature -10.1, got %v", r.Temperature)
+		}
+		if _, err := decodeDHT22([5]byte{1, 2, 3, 4, 0}); err == nil {
+			t.Error("Expected checksum error")
+		}
+
+		// A silent line is reported through the GPIO controller
+		gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		pin := &gpiotest.Pin{N: "dht22", EdgesChan: make(chan gpio.Level, 1)}
+		if err := gpioCtrl.ConfigurePin("dht22", pin, gpio.Float); err != nil {
+			t.Fatalf("Failed to configure pin: %v", err)
+		}
+		if _, err := NewDHT22(gpioCtrl, "dht22").ReadHumidity(); err == nil {
+			t.Error("Expected error without sensor response")
+		}
+		if pin.P != gpio.PullUp {
+			t.Error("DHT22 data line not released to pull-up")
+		}
+	})
+
+	t.Run("Condensation Risk", func(t *testing.T) {
+		const tempPath = "/sys/class/thermal/thermal_zone0/temp"
+		root := t.TempDir()
+		writeFakeSysfs(t, root, tempPath, "12000")
+
+		gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		heaterPin := &mockThrottlePin{}
+		if err := gpioCtrl.ConfigurePin("test_heater", heaterPin, gpio.Float); err != nil {
+			t.Fatalf("Failed to configure heater pin: %v", err)
+		}
+
+		var risks int
+		sensor := &mockHumiditySensor{reading: HumidityReading{Temperature: 20, RelativeHumidity: 70}}
+		monitor, err := New(Config{
+			GPIO:            gpioCtrl,
+			AmbientTempPath: tempPath,
+			SysfsRoot:       root,
+			HeaterPin:       "test_heater",
+			HumiditySensor:  sensor,
+			CondensationConfig: &CondensationConfig{
+				HeatOnRisk:         true,
+				OnCondensationRisk: func(ThermalState) { risks++ },
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+
+		// Dew point of 20°C/70% is ~14.4°C, above the 12°C ambient surface
+		for i := 0; i < 2; i++ {
+			if err := monitor.updateThermalState(); err != nil {
+				t.Fatalf("Failed to update thermal state: %v", err)
+			}
+		}
+		state := monitor.GetState()
+		if !state.Condensation {
+			t.Fatal("Condensation risk not detected")
+		}
+		if risks != 1 {
+			t.Errorf("Expected one condensation callback, got %d", risks)
+		}
+		if !state.HeaterOn {
+			t.Error("Heater not started on condensation risk")
+		}
+
+		// An invalid reading keeps the last dew point
+		sensor.reading.RelativeHumidity = 0
+		if err := monitor.updateThermalState(); err != nil {
+			t.Fatalf("Failed to update thermal state: %v", err)
+		}
+		if state := monitor.GetState(); math.Abs(state.DewPoint-14.4) > 0.1 {
+			t.Errorf("Dew point not kept on invalid reading, got %.2f", state.DewPoint)
+		}
+
+		sensor.reading.RelativeHumidity = 30
+		if err := monitor.updateThermalState(); err != nil {
+			t.Fatalf("Failed to update thermal state: %v", err)
+		}
+		if state := monitor.GetState(); state.Condensation {
+			t.Error("Condensation risk not cleared at low humidity")
+		}
+	})
+
+	t.Run("Zero Margin", func(t *testing.T) {
+		margin := 0.0
+		cfg := CondensationConfig{Margin: &margin}
+		applyCondensationDefaults(&cfg)
+		if *cfg.Margin != 0 {
+			t.Errorf("Expected zero margin kept, got %v", *cfg.Margin)
+		}
+		cfg = CondensationConfig{}
+		applyCondensationDefaults(&cfg)
+		if *cfg.Margin != defaultCondensationMargin {
+			t.Errorf("Expected default margin, got %v", *cfg.Margin)
+		}
+	})
+}
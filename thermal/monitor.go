@@ -18,16 +18,39 @@ type Monitor struct {
 	gpio        *gpio.Controller
 	fanPin      string
 	throttlePin string
+	heaterPin   string
 
 	// Temperature paths
 	cpuTemp     string
 	gpuTemp     string
 	ambientTemp string
+	sysfsRoot   string
 
 	// Configuration
 	monitorInterval time.Duration
 	onWarning       func(ThermalState)
 	onCritical      func(ThermalState)
+
+	// Runaway protection
+	history map[Sensor]*tempHistory
+	runaway *RunawayConfig
+
+	// Kernel throttling
+	kernelThrottle *kernelThrottle
+
+	// Heater control
+	heater *heaterControl
+
+	// Fan acoustic profiles
+	fanProfiles *fanProfiles
+
+	// Fan speed feedback and manual override
+	fanTach     Tachometer
+	fanOverride *uint32
+
+	// Humidity monitoring
+	humidity     HumiditySensor
+	condensation *CondensationConfig
 }
 
 // New creates a new thermal monitor
@@ -40,17 +63,63 @@ func New(cfg Config) (*Monitor, error) {
 	if cfg.MonitorInterval == 0 {
 		cfg.MonitorInterval = defaultMonitorInterval
 	}
+	if cfg.SysfsRoot == "" {
+		cfg.SysfsRoot = defaultSysfsRoot
+	}
 
 	m := &Monitor{
 		gpio:            cfg.GPIO,
 		fanPin:          cfg.FanControlPin,
 		throttlePin:     cfg.ThrottlePin,
+		heaterPin:       cfg.HeaterPin,
 		cpuTemp:         cfg.CPUTempPath,
 		gpuTemp:         cfg.GPUTempPath,
 		ambientTemp:     cfg.AmbientTempPath,
+		sysfsRoot:       cfg.SysfsRoot,
 		monitorInterval: cfg.MonitorInterval,
 		onWarning:       cfg.OnWarning,
 		onCritical:      cfg.OnCritical,
+		history:         make(map[Sensor]*tempHistory),
+		fanTach:         cfg.FanTach,
+		state: ThermalState{
+			Runaway: RunawayNone,
+		},
+	}
+
+	if cfg.RunawayConfig != nil {
+		runaway := *cfg.RunawayConfig
+		applyRunawayDefaults(&runaway)
+		m.runaway = &runaway
+	}
+
+	if err := m.initFanProfiles(cfg); err != nil {
+		return nil, fmt.Errorf("failed to initialize fan profiles: %w", err)
+	}
+
+	if cfg.ThrottleConfig != nil {
+		if err := m.initKernelThrottle(*cfg.ThrottleConfig); err != nil {
+			return nil, fmt.Errorf("failed to initialize kernel throttling: %w", err)
+		}
+	}
+
+	if cfg.HumiditySensor != nil {
+		var condensation CondensationConfig
+		if cfg.CondensationConfig != nil {
+			condensation = *cfg.CondensationConfig
+		}
+		applyCondensationDefaults(&condensation)
+		m.humidity = cfg.HumiditySensor
+		m.condensation = &condensation
+	}
+
+	if m.heaterPin != "" {
+		var heaterCfg HeaterConfig
+		if cfg.HeaterConfig != nil {
+			heaterCfg = *cfg.HeaterConfig
+		}
+		if err := m.initHeater(heaterCfg); err != nil {
+			return nil, fmt.Errorf("failed to initialize heater: %w", err)
+		}
 	}
 
 	if m.fanPin != "" {
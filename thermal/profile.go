@@ -0,0 +1,127 @@
+package thermal
+
+import (
+	"fmt"
+	"time"
+)
+
+// builtinFanProfiles returns the default acoustic profiles
+func builtinFanProfiles() map[string]FanProfile {
+	return map[string]FanProfile{
+		ProfileSilent:      {Name: ProfileSilent, MaxDuty: fanSpeedMedium, MaxRate: 2},
+		ProfileBalanced:    {Name: ProfileBalanced, MaxDuty: 75, MaxRate: 5},
+		ProfilePerformance: {Name: ProfilePerformance, MaxDuty: fanSpeedHigh},
+	}
+}
+
+// fanProfiles tracks acoustic profile selection and ramp state
+type fanProfiles struct {
+	profiles    map[string]FanProfile
+	defaultName string
+	schedule    []FanScheduleEntry
+	lastUpdate  time.Time
+}
+
+// initFanProfiles validates and installs the profile configuration
+func (m *Monitor) initFanProfiles(cfg Config) error {
+	fp := &fanProfiles{
+		profiles:    builtinFanProfiles(),
+		defaultName: cfg.FanProfile,
+		schedule:    cfg.FanSchedule,
+	}
+	if fp.defaultName == "" {
+		fp.defaultName = ProfilePerformance
+	}
+
+	for _, profile := range cfg.FanProfiles {
+		if profile.Name == "" {
+			return fmt.Errorf("fan profile name is required")
+		}
+		if profile.MaxDuty < fanSpeedLow || profile.MaxDuty > fanSpeedHigh {
+			return fmt.Errorf("fan profile %s max duty must be %d-%d", profile.Name, fanSpeedLow, fanSpeedHigh)
+		}
+		fp.profiles[profile.Name] = profile
+	}
+
+	if _, exists := fp.profiles[fp.defaultName]; !exists {
+		return fmt.Errorf("unknown fan profile: %s", fp.defaultName)
+	}
+	for _, entry := range fp.schedule {
+		if _, exists := fp.profiles[entry.Profile]; !exists {
+			return fmt.Errorf("unknown fan profile in schedule: %s", entry.Profile)
+		}
+	}
+
+	m.fanProfiles = fp
+	m.state.FanProfile = fp.defaultName
+	return nil
+}
+
+// SetFanProfile changes the profile used outside scheduled windows
+func (m *Monitor) SetFanProfile(name string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if _, exists := m.fanProfiles.profiles[name]; !exists {
+		return fmt.Errorf("unknown fan profile: %s", name)
+	}
+	m.fanProfiles.defaultName = name
+	return nil
+}
+
+// inWindow reports whether a time-of-day offset falls inside a schedule entry
+func (e FanScheduleEntry) inWindow(offset time.Duration) bool {
+	if e.Start <= e.End {
+		return offset >= e.Start && offset < e.End
+	}
+	// Window wraps past midnight
+	return offset >= e.Start || offset < e.End
+}
+
+// activeProfile returns the profile selected by the schedule at a given time
+func (fp *fanProfiles) activeProfile(now time.Time) FanProfile {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	offset := now.Sub(midnight)
+	for _, entry := range fp.schedule {
+		if entry.inWindow(offset) {
+			return fp.profiles[entry.Profile]
+		}
+	}
+	return fp.profiles[fp.defaultName]
+}
+
+// applyFanProfileLocked caps a requested duty cycle by the active profile - must be called with lock held
+func (m *Monitor) applyFanProfileLocked(dutyCycle uint32, critical bool, now time.Time) uint32 {
+	fp := m.fanProfiles
+	profile := fp.activeProfile(now)
+	m.state.FanProfile = profile.Name
+
+	elapsed := now.Sub(fp.lastUpdate)
+	fp.lastUpdate = now
+
+	// Critical temperatures always get full cooling immediately
+	if critical {
+		return dutyCycle
+	}
+
+	if dutyCycle > profile.MaxDuty {
+		dutyCycle = profile.MaxDuty
+	}
+
+	current := m.state.FanSpeed
+	if profile.MaxRate <= 0 || current == 0 || elapsed <= 0 {
+		return dutyCycle
+	}
+
+	maxStep := uint32(profile.MaxRate * elapsed.Seconds())
+	if maxStep < 1 {
+		maxStep = 1
+	}
+	switch {
+	case dutyCycle > current && dutyCycle-current > maxStep:
+		dutyCycle = current + maxStep
+	case dutyCycle < current && current-dutyCycle > maxStep:
+		dutyCycle = current - maxStep
+	}
+	return dutyCycle
+}
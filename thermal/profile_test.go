@@ -0,0 +1,111 @@
+package thermal
+
+import (
+	"testing"
+	"time"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/gpio"
+)
+
+func TestFanProfiles(t *testing.T) {
+	gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+	if err := gpioCtrl.ConfigurePin("test_fan", &mockFanPin{}, gpio.Float); err != nil {
+		t.Fatalf("Failed to configure fan pin: %v", err)
+	}
+
+	monitor, err := New(Config{
+		GPIO:          gpioCtrl,
+		FanControlPin: "test_fan",
+		FanProfile:    ProfileBalanced,
+		FanProfiles: []FanProfile{
+			{Name: "office", MaxDuty: 40, MaxRate: 1},
+		},
+		FanSchedule: []FanScheduleEntry{
+			{Start: 22 * time.Hour, End: 7 * time.Hour, Profile: ProfileSilent},
+			{Start: 9 * time.Hour, End: 17 * time.Hour, Profile: "office"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create thermal monitor: %v", err)
+	}
+
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+
+	t.Run("Schedule", func(t *testing.T) {
+		tests := []struct {
+			at      time.Duration
+			profile string
+		}{
+			{23 * time.Hour, ProfileSilent},
+			{3 * time.Hour, ProfileSilent},
+			{8 * time.Hour, ProfileBalanced},
+			{12 * time.Hour, "office"},
+			{18 * time.Hour, ProfileBalanced},
+		}
+		for _, tt := range tests {
+			if got := monitor.fanProfiles.activeProfile(day.Add(tt.at)).Name; got != tt.profile {
+				t.Errorf("At %v expected profile %s, got %s", tt.at, tt.profile, got)
+			}
+		}
+	})
+
+	t.Run("Duty Cap And Ramp", func(t *testing.T) {
+		monitor.mux.Lock()
+		defer monitor.mux.Unlock()
+
+		now := day.Add(12 * time.Hour)
+		monitor.fanProfiles.lastUpdate = now.Add(-time.Second)
+		monitor.state.FanSpeed = fanSpeedLow
+
+		// Office profile allows 1%/s, so one second ramps a single step
+		if duty := monitor.applyFanProfileLocked(fanSpeedHigh, false, now); duty != fanSpeedLow+1 {
+			t.Errorf("Expected ramp-limited duty %d, got %d", fanSpeedLow+1, duty)
+		}
+
+		// After a long interval the cap applies instead of the ramp
+		monitor.fanProfiles.lastUpdate = now.Add(-time.Hour)
+		if duty := monitor.applyFanProfileLocked(fanSpeedHigh, false, now); duty != 40 {
+			t.Errorf("Expected capped duty 40, got %d", duty)
+		}
+		if monitor.state.FanProfile != "office" {
+			t.Errorf("Expected active profile office, got %s", monitor.state.FanProfile)
+		}
+	})
+
+	t.Run("Critical Override", func(t *testing.T) {
+		monitor.mux.Lock()
+		defer monitor.mux.Unlock()
+
+		now := day.Add(23 * time.Hour)
+		monitor.fanProfiles.lastUpdate = now.Add(-time.Second)
+		if duty := monitor.applyFanProfileLocked(fanSpeedHigh, true, now); duty != fanSpeedHigh {
+			t.Errorf("Expected full duty at critical temperature, got %d", duty)
+		}
+	})
+
+	t.Run("Manual Profile", func(t *testing.T) {
+		if err := monitor.SetFanProfile(ProfilePerformance); err != nil {
+			t.Fatalf("Failed to set fan profile: %v", err)
+		}
+		if err := monitor.SetFanProfile("missing"); err == nil {
+			t.Error("Expected error for unknown profile")
+		}
+		if got := monitor.fanProfiles.activeProfile(day.Add(8 * time.Hour)).Name; got != ProfilePerformance {
+			t.Errorf("Expected default profile performance, got %s", got)
+		}
+	})
+
+	t.Run("Invalid Schedule", func(t *testing.T) {
+		_, err := New(Config{
+			GPIO:        gpioCtrl,
+			FanSchedule: []FanScheduleEntry{{Profile: "missing"}},
+		})
+		if err == nil {
+			t.Error("Expected error for unknown scheduled profile")
+		}
+	})
+}
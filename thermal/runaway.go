@@ -0,0 +1,281 @@
+package thermal
+
+import (
+	"fmt"
+	"time"
+)
+
+// tempSample is a single timestamped temperature reading
+type tempSample struct {
+	temp float64
+	at   time.Time
+}
+
+// tempHistory keeps a rolling window of samples for one sensor
+type tempHistory struct {
+	samples []tempSample
+	size    int
+}
+
+// add appends a sample, discarding the oldest once the window is full
+func (h *tempHistory) add(temp float64, at time.Time) {
+	h.samples = append(h.samples, tempSample{temp: temp, at: at})
+	if len(h.samples) > h.size {
+		h.samples = h.samples[len(h.samples)-h.size:]
+	}
+}
+
+// rate returns the least-squares slope of the window in °C per second
+func (h *tempHistory) rate() float64 {
+	n := float64(len(h.samples))
+	if n < 2 {
+		return 0
+	}
+
+	start := h.samples[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range h.samples {
+		x := s.at.Sub(start).Seconds()
+		sumX += x
+		sumY += s.temp
+		sumXY += x * s.temp
+		sumXX += x * x
+	}
+
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
+// applyRunawayDefaults fills unset runaway configuration values
+func applyRunawayDefaults(cfg *RunawayConfig) {
+	if cfg.HistorySize == 0 {
+		cfg.HistorySize = defaultHistorySize
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = defaultMinTrendSamples
+	}
+	if cfg.MinSamples < 2 {
+		cfg.MinSamples = 2
+	}
+	if cfg.RiseRateThreshold == 0 {
+		cfg.RiseRateThreshold = defaultRiseRateThreshold
+	}
+	if cfg.EmergencyTemp == 0 {
+		cfg.EmergencyTemp = defaultEmergencyTemp
+	}
+	if cfg.ShutdownTemp == 0 {
+		cfg.ShutdownTemp = defaultShutdownTemp
+	}
+	if cfg.MinTimeToCritical == 0 {
+		cfg.MinTimeToCritical = defaultMinTimeToCritical
+	}
+	if cfg.Hysteresis == 0 {
+		cfg.Hysteresis = defaultRunawayHysteresis
+	}
+}
+
+// criticalTemp returns the critical threshold for a sensor
+func criticalTemp(sensor Sensor) float64 {
+	switch sensor {
+	case SensorGPU:
+		return gpuTempCritical
+	case SensorAmbient:
+		return ambientCritical
+	default:
+		return cpuTempCritical
+	}
+}
+
+// recordSampleLocked adds a reading to a sensor's history - must be called with lock held
+func (m *Monitor) recordSampleLocked(sensor Sensor, temp float64, at time.Time) {
+	h, exists := m.history[sensor]
+	if !exists {
+		size := defaultHistorySize
+		if m.runaway != nil {
+			size = m.runaway.HistorySize
+		}
+		h = &tempHistory{size: size}
+		m.history[sensor] = h
+	}
+	h.add(temp, at)
+}
+
+// trendLocked computes the trend for a sensor - must be called with lock held
+func (m *Monitor) trendLocked(sensor Sensor) (TempTrend, bool) {
+	h, exists := m.history[sensor]
+	if !exists || len(h.samples) == 0 {
+		return TempTrend{}, false
+	}
+
+	trend := TempTrend{
+		Sensor:  sensor,
+		Current: h.samples[len(h.samples)-1].temp,
+		Rate:    h.rate(),
+		Samples: len(h.samples),
+	}
+
+	// Predict time to critical by extrapolating the current rate
+	critical := criticalTemp(sensor)
+	if trend.Rate > 0 && trend.Current < critical {
+		seconds := (critical - trend.Current) / trend.Rate
+		trend.TimeToCritical = time.Duration(seconds * float64(time.Second))
+	}
+
+	return trend, true
+}
+
+// GetTrend returns the current temperature trend for a sensor
+func (m *Monitor) GetTrend(sensor Sensor) (TempTrend, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	trend, ok := m.trendLocked(sensor)
+	if !ok {
+		return TempTrend{}, fmt.Errorf("no temperature history for sensor %s", sensor)
+	}
+	return trend, nil
+}
+
+// checkRunawayLocked evaluates trends and escalates runaway protection - must be called with lock held.
+// The returned function runs the configured callbacks and must be invoked after the lock is released.
+func (m *Monitor) checkRunawayLocked() func() {
+	if m.runaway == nil {
+		return nil
+	}
+
+	// Without active cooling any sustained rise is treated as runaway
+	fanAtMax := m.fanPin == "" || m.state.FanSpeed >= fanSpeedHigh
+	previous := m.state.Runaway
+
+	// Once engaged, throttling holds until the rise slows well below the threshold
+	riseRate := m.runaway.RiseRateThreshold
+	if runawayRank(previous) > 0 {
+		riseRate *= runawayReleaseRatio
+	}
+
+	var (
+		runaway  bool
+		worst    TempTrend
+		maxTemp  float64
+		haveTemp bool
+	)
+	for sensor := range m.history {
+		trend, ok := m.trendLocked(sensor)
+		if !ok {
+			continue
+		}
+		if !haveTemp || trend.Current > maxTemp {
+			maxTemp = trend.Current
+			haveTemp = true
+		}
+		if trend.Samples < m.runaway.MinSamples {
+			continue
+		}
+		if fanAtMax && trend.Rate >= riseRate {
+			if !runaway || trend.Rate > worst.Rate {
+				worst = trend
+			}
+			runaway = true
+		}
+	}
+
+	// Determine escalation level
+	level := RunawayNone
+	details := ""
+	if runaway {
+		level = RunawayThrottle
+		details = fmt.Sprintf("%s rising %.2f°C/s at maximum cooling", worst.Sensor, worst.Rate)
+		if maxTemp >= m.runaway.EmergencyTemp ||
+			(worst.TimeToCritical > 0 && worst.TimeToCritical <= m.runaway.MinTimeToCritical) {
+			level = RunawayShedLoad
+		}
+	}
+	if haveTemp && maxTemp >= m.runaway.ShutdownTemp {
+		level = RunawayShutdown
+		details = fmt.Sprintf("temperature %.1f°C at or above shutdown threshold %.1f°C",
+			maxTemp, m.runaway.ShutdownTemp)
+	}
+
+	// Step down only once temperatures fall the hysteresis margin below a threshold
+	if runawayRank(level) < runawayRank(previous) && haveTemp {
+		switch {
+		case previous == RunawayShutdown && maxTemp >= m.runaway.ShutdownTemp-m.runaway.Hysteresis:
+			level = RunawayShutdown
+		case runawayRank(previous) >= runawayRank(RunawayShedLoad) &&
+			maxTemp >= m.runaway.EmergencyTemp-m.runaway.Hysteresis:
+			level = RunawayShedLoad
+		}
+		if runawayRank(level) >= runawayRank(RunawayShedLoad) {
+			details = fmt.Sprintf("temperature %.1f°C within %.1f°C of threshold",
+				maxTemp, m.runaway.Hysteresis)
+		}
+	}
+
+	if level != RunawayNone {
+		m.state.addWarning(WarningRunaway)
+	}
+	if level == previous {
+		return nil
+	}
+	m.state.Runaway = level
+
+	// Decide escalation actions only when escalating
+	var shed, shutdown bool
+	if runawayRank(level) > runawayRank(previous) {
+		m.setThrottlingLocked(true)
+		shed = level == RunawayShedLoad || (level == RunawayShutdown && previous != RunawayShedLoad)
+		shutdown = level == RunawayShutdown
+	}
+
+	// Callbacks see a snapshot so they may safely call back into the monitor
+	state := m.state
+	state.Warnings = append([]string(nil), m.state.Warnings...)
+	event := RunawayEvent{
+		Timestamp: time.Now(),
+		Level:     level,
+		Previous:  previous,
+		Trend:     worst,
+		Details:   details,
+	}
+	cfg := m.runaway
+
+	return func() {
+		if shed {
+			m.shedLoads(cfg.LoadShedders, state)
+		}
+		if shutdown && cfg.OnShutdown != nil {
+			cfg.OnShutdown(state)
+		}
+		if cfg.OnRunaway != nil {
+			cfg.OnRunaway(event)
+		}
+	}
+}
+
+// runawayRank orders escalation levels by severity
+func runawayRank(level RunawayLevel) int {
+	switch level {
+	case RunawayThrottle:
+		return 1
+	case RunawayShedLoad:
+		return 2
+	case RunawayShutdown:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// shedLoads invokes the configured load shedders - must be called without lock held
+func (m *Monitor) shedLoads(shedders []func(ThermalState) error, state ThermalState) {
+	for i, shed := range shedders {
+		if err := shed(state); err != nil {
+			m.mux.Lock()
+			m.state.addWarning(fmt.Sprintf("Load shedder %d failed: %v", i, err))
+			m.mux.Unlock()
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package thermal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/gpio"
+)
+
+func TestRunawayProtection(t *testing.T) {
+	gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	throttlePin := &mockThrottlePin{}
+	if err := gpioCtrl.ConfigurePin("test_throttle", throttlePin, gpio.Float); err != nil {
+		t.Fatalf("Failed to configure throttle pin: %v", err)
+	}
+
+	var (
+		events   []RunawayEvent
+		shed     int
+		shutdown int
+	)
+
+	monitor, err := New(Config{
+		GPIO:        gpioCtrl,
+		ThrottlePin: "test_throttle",
+		RunawayConfig: &RunawayConfig{
+			MinSamples:        3,
+			RiseRateThreshold: 0.5,
+			LoadShedders: []func(ThermalState) error{
+				func(ThermalState) error { shed++; return nil },
+			},
+			OnShutdown: func(ThermalState) { shutdown++ },
+			OnRunaway:  func(e RunawayEvent) { events = append(events, e) },
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create thermal monitor: %v", err)
+	}
+
+	// feed records CPU samples one second apart and evaluates runaway
+	start := time.Now()
+	feed := func(temps ...float64) {
+		monitor.mux.Lock()
+		for _, temp := range temps {
+			monitor.recordSampleLocked(SensorCPU, temp, start)
+			monitor.state.CPUTemp = temp
+			start = start.Add(time.Second)
+		}
+		actions := monitor.checkRunawayLocked()
+		monitor.mux.Unlock()
+		if actions != nil {
+			actions()
+		}
+	}
+
+	t.Run("Stable Temperature", func(t *testing.T) {
+		feed(60, 60, 60, 60)
+		if state := monitor.GetState(); state.Runaway != RunawayNone {
+			t.Errorf("Expected no runaway at stable temperature, got %s", state.Runaway)
+		}
+	})
+
+	t.Run("Trend Analysis", func(t *testing.T) {
+		feed(61, 62, 63)
+		trend, err := monitor.GetTrend(SensorCPU)
+		if err != nil {
+			t.Fatalf("Failed to get trend: %v", err)
+		}
+		if trend.Rate <= 0 {
+			t.Errorf("Expected positive rate of rise, got %v", trend.Rate)
+		}
+		if trend.TimeToCritical <= 0 {
+			t.Error("Expected predicted time to critical while rising")
+		}
+		if _, err := monitor.GetTrend(SensorGPU); err == nil {
+			t.Error("Expected error for sensor without history")
+		}
+	})
+
+	t.Run("Runaway Throttle", func(t *testing.T) {
+		monitor.mux.Lock()
+		monitor.history = make(map[Sensor]*tempHistory)
+		monitor.state.Runaway = RunawayNone
+		monitor.mux.Unlock()
+		events = nil
+
+		feed(50, 50.6, 51.2, 51.8)
+		state := monitor.GetState()
+		if state.Runaway != RunawayThrottle {
+			t.Fatalf("Expected runaway throttle, got %s", state.Runaway)
+		}
+		if !state.Throttled {
+			t.Error("Throttle pin not forced during runaway")
+		}
+		if shed != 0 {
+			t.Error("Loads shed before emergency threshold")
+		}
+	})
+
+	t.Run("Runaway Escalation", func(t *testing.T) {
+		feed(78, 86)
+		if state := monitor.GetState(); state.Runaway != RunawayShedLoad {
+			t.Fatalf("Expected load shedding, got %s", state.Runaway)
+		}
+		if shed != 1 {
+			t.Errorf("Expected loads shed once, got %d", shed)
+		}
+
+		feed(91)
+		if state := monitor.GetState(); state.Runaway != RunawayShutdown {
+			t.Fatalf("Expected shutdown request, got %s", state.Runaway)
+		}
+		if shutdown != 1 {
+			t.Errorf("Expected one shutdown request, got %d", shutdown)
+		}
+		if shed != 1 {
+			t.Errorf("Loads shed again after shutdown escalation, got %d", shed)
+		}
+		if len(events) != 3 {
+			t.Errorf("Expected 3 runaway events, got %d", len(events))
+		}
+	})
+
+	t.Run("Hysteresis", func(t *testing.T) {
+		events = nil
+
+		// Just below shutdown holds until the hysteresis margin is cleared
+		feed(88)
+		if state := monitor.GetState(); state.Runaway != RunawayShutdown {
+			t.Errorf("Expected shutdown held at 88°C, got %s", state.Runaway)
+		}
+		feed(86)
+		if state := monitor.GetState(); state.Runaway != RunawayShedLoad {
+			t.Errorf("Expected step down to load shedding, got %s", state.Runaway)
+		}
+		feed(84, 85, 84)
+		if state := monitor.GetState(); state.Runaway != RunawayShedLoad {
+			t.Errorf("Expected load shedding held near emergency threshold, got %s", state.Runaway)
+		}
+
+		monitor.mux.Lock()
+		monitor.history = make(map[Sensor]*tempHistory)
+		monitor.mux.Unlock()
+		feed(70, 70, 70, 70)
+		if state := monitor.GetState(); state.Runaway != RunawayNone {
+			t.Errorf("Expected runaway cleared once cool and stable, got %s", state.Runaway)
+		}
+		if len(events) != 2 {
+			t.Errorf("Expected 2 de-escalation events without flapping, got %d", len(events))
+		}
+		if shed != 1 || shutdown != 1 {
+			t.Errorf("Escalation actions repeated while stepping down: %d shed, %d shutdown", shed, shutdown)
+		}
+	})
+
+	t.Run("Callbacks Outside Lock", func(t *testing.T) {
+		var seen []RunawayLevel
+		reentrant, err := New(Config{
+			GPIO:        gpioCtrl,
+			ThrottlePin: "test_throttle",
+			RunawayConfig: &RunawayConfig{
+				MinSamples:        3,
+				RiseRateThreshold: 0.5,
+				LoadShedders: []func(ThermalState) error{
+					func(ThermalState) error { return errors.New("busy") },
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+		reentrant.runaway.OnRunaway = func(RunawayEvent) {
+			seen = append(seen, reentrant.GetState().Runaway)
+		}
+
+		at := time.Now()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			reentrant.mux.Lock()
+			for _, temp := range []float64{80, 82, 84, 86} {
+				reentrant.recordSampleLocked(SensorCPU, temp, at)
+				reentrant.state.CPUTemp = temp
+				at = at.Add(time.Second)
+			}
+			actions := reentrant.checkRunawayLocked()
+			reentrant.mux.Unlock()
+			if actions != nil {
+				actions()
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Runaway callback deadlocked calling back into the monitor")
+		}
+		if len(seen) != 1 || seen[0] != RunawayShedLoad {
+			t.Errorf("Expected callback to observe load shedding, got %v", seen)
+		}
+
+		found := false
+		for _, warning := range reentrant.GetState().Warnings {
+			if warning == "Load shedder 0 failed: busy" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("Failed load shedder not reported in warnings")
+		}
+	})
+
+	t.Run("Fan Gate", func(t *testing.T) {
+		if err := gpioCtrl.ConfigurePin("test_fan", &mockFanPin{}, gpio.Float); err != nil {
+			t.Fatalf("Failed to configure fan pin: %v", err)
+		}
+		fanned, err := New(Config{
+			GPIO:          gpioCtrl,
+			FanControlPin: "test_fan",
+			RunawayConfig: &RunawayConfig{MinSamples: 3, RiseRateThreshold: 0.5},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+		if err := fanned.InitializeFanControl(); err != nil {
+			t.Fatalf("Failed to initialize fan control: %v", err)
+		}
+
+		at := time.Now()
+		rise := func(temps ...float64) RunawayLevel {
+			fanned.mux.Lock()
+			defer fanned.mux.Unlock()
+			for _, temp := range temps {
+				fanned.recordSampleLocked(SensorCPU, temp, at)
+				fanned.state.CPUTemp = temp
+				at = at.Add(time.Second)
+			}
+			_ = fanned.checkRunawayLocked()
+			return fanned.state.Runaway
+		}
+
+		// A fast rise with headroom left on the fan is not runaway
+		if level := rise(50, 50.6, 51.2, 51.8); level != RunawayNone {
+			t.Errorf("Expected no runaway below maximum fan speed, got %s", level)
+		}
+
+		if err := fanned.SetFanSpeed(fanSpeedHigh); err != nil {
+			t.Fatalf("Failed to set fan speed: %v", err)
+		}
+		if level := rise(52.4, 53); level != RunawayThrottle {
+			t.Errorf("Expected runaway throttle at maximum fan speed, got %s", level)
+		}
+	})
+}
@@ -11,11 +11,22 @@ import (
 
 // updateThermalState reads current temperatures and updates cooling
 func (m *Monitor) updateThermalState() error {
+	humidity, humidityErr := m.readHumidity()
+
+	// Runaway callbacks run once the lock is released
+	var runawayActions func()
+	defer func() {
+		if runawayActions != nil {
+			runawayActions()
+		}
+	}()
+
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
 	// Read temperatures
 	var warnings []string
+	now := time.Now()
 
 	// Read CPU temperature
 	if m.cpuTemp != "" {
@@ -24,6 +35,7 @@ func (m *Monitor) updateThermalState() error {
 			return fmt.Errorf("failed to read CPU temperature: %w", err)
 		}
 		m.state.CPUTemp = cpuTemp
+		m.recordSampleLocked(SensorCPU, cpuTemp, now)
 
 		// Check CPU temperature thresholds
 		if cpuTemp >= cpuTempCritical {
@@ -46,6 +58,7 @@ func (m *Monitor) updateThermalState() error {
 			return fmt.Errorf("failed to read GPU temperature: %w", err)
 		}
 		m.state.GPUTemp = gpuTemp
+		m.recordSampleLocked(SensorGPU, gpuTemp, now)
 
 		// Check GPU temperature thresholds
 		if gpuTemp >= gpuTempCritical {
@@ -68,6 +81,7 @@ func (m *Monitor) updateThermalState() error {
 			return fmt.Errorf("failed to read ambient temperature: %w", err)
 		}
 		m.state.AmbientTemp = ambientTemp
+		m.recordSampleLocked(SensorAmbient, ambientTemp, now)
 
 		// Check ambient temperature thresholds
 		if ambientTemp >= ambientCritical {
@@ -86,10 +100,16 @@ func (m *Monitor) updateThermalState() error {
 	// Update warnings
 	m.state.Warnings = warnings
 
+	// Evaluate enclosure humidity and condensation risk
+	m.updateHumidityLocked(humidity, humidityErr)
+
+	// Evaluate runaway protection before cooling so escalation is applied immediately
+	runawayActions = m.checkRunawayLocked()
+
 	// Determine required cooling
-	m.updateCooling()
+	m.updateCoolingLocked()
 
-	m.state.UpdatedAt = time.Now()
+	m.state.UpdatedAt = now
 	return nil
 }
 
@@ -106,7 +126,7 @@ func (m *Monitor) readTemp(path string) (float64, error) {
 		return 0, fmt.Errorf("invalid temperature sensor path: must be in /sys/class/thermal/thermal_zoneX/temp")
 	}
 
-	data, err := os.ReadFile(path)
+	data, err := os.ReadFile(filepath.Join(m.sysfsRoot, path))
 	if err != nil {
 		return 0, fmt.Errorf("failed to read temperature file: %w", err)
 	}
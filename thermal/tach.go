@@ -0,0 +1,134 @@
+package thermal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wrale/wrale-fleet-metal-hw/gpio"
+)
+
+const (
+	// Standard PC fans emit two tach pulses per revolution
+	defaultPulsesPerRev = 2
+	// Default pulse counting window
+	defaultTachWindow = 1 * time.Second
+)
+
+// Tachometer measures fan speed
+type Tachometer interface {
+	// ReadRPM returns the current fan speed in revolutions per minute
+	ReadRPM() (float64, error)
+}
+
+// HwmonTach reads fan speed from a hwmon fanN_input file
+type HwmonTach struct {
+	path string
+}
+
+// NewHwmonTach creates a tachometer for a hwmon fan input, e.g.
+// /sys/class/hwmon/hwmon0/fan1_input
+func NewHwmonTach(path string) *HwmonTach {
+	return &HwmonTach{path: path}
+}
+
+// ReadRPM reads the fan speed reported by the kernel driver
+func (t *HwmonTach) ReadRPM() (float64, error) {
+	data, err := os.ReadFile(filepath.Clean(t.path))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read fan tach: %w", err)
+	}
+	rpm, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse fan tach value: %w", err)
+	}
+	return rpm, nil
+}
+
+// PulseTach counts tach pulses on a GPIO input
+type PulseTach struct {
+	mux          sync.Mutex
+	gpio         *gpio.Controller
+	pin          string
+	pulsesPerRev int
+	window       time.Duration
+	configured   bool
+}
+
+// NewPulseTach creates a pulse counting tachometer on a configured GPIO pin.
+// Zero pulsesPerRev and window default to two pulses per revolution and a
+// one second window.
+func NewPulseTach(gpioCtrl *gpio.Controller, pin string, pulsesPerRev int, window time.Duration) *PulseTach {
+	if pulsesPerRev == 0 {
+		pulsesPerRev = defaultPulsesPerRev
+	}
+	if window == 0 {
+		window = defaultTachWindow
+	}
+	return &PulseTach{gpio: gpioCtrl, pin: pin, pulsesPerRev: pulsesPerRev, window: window}
+}
+
+// ReadRPM counts falling edges for one window
+func (t *PulseTach) ReadRPM() (float64, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	// Tach outputs are open collector and need a pull-up
+	if !t.configured {
+		if err := t.gpio.SetPinEdge(t.pin, gpio.PullUp, gpio.Falling); err != nil {
+			return 0, fmt.Errorf("failed to configure tach pin: %w", err)
+		}
+		t.configured = true
+	}
+
+	pulses := 0
+	deadline := time.Now().Add(t.window)
+	for remaining := t.window; remaining > 0; remaining = time.Until(deadline) {
+		edge, err := t.gpio.WaitForEdge(t.pin, remaining)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count tach pulses: %w", err)
+		}
+		if edge {
+			pulses++
+		}
+	}
+	return float64(pulses) / float64(t.pulsesPerRev) / t.window.Minutes(), nil
+}
+
+// FanRPM reads the fan tachometer
+func (m *Monitor) FanRPM() (float64, error) {
+	if m.fanTach == nil {
+		return 0, fmt.Errorf("no fan tachometer configured")
+	}
+	return m.fanTach.ReadRPM()
+}
+
+// OverrideFan holds the fan at a fixed duty cycle, bypassing automatic
+// control and acoustic profiles until ReleaseFan. Critical temperatures and
+// runaway protection still force full cooling.
+func (m *Monitor) OverrideFan(dutyCycle uint32) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if dutyCycle > fanSpeedHigh {
+		return fmt.Errorf("duty cycle must be 0-100")
+	}
+	m.fanOverride = &dutyCycle
+	m.state.FanOverride = true
+	m.updateCoolingLocked()
+	return nil
+}
+
+// ReleaseFan returns the fan to automatic control
+func (m *Monitor) ReleaseFan() {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.fanOverride = nil
+	m.state.FanOverride = false
+	m.updateCoolingLocked()
+}
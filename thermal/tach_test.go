@@ -0,0 +1,86 @@
+package thermal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpiotest"
+)
+
+func TestFanTach(t *testing.T) {
+	t.Run("Hwmon", func(t *testing.T) {
+		root := t.TempDir()
+		writeFakeSysfs(t, root, "fan1_input", "2400\n")
+		rpm, err := NewHwmonTach(filepath.Join(root, "fan1_input")).ReadRPM()
+		if err != nil {
+			t.Fatalf("Failed to read tach: %v", err)
+		}
+		if rpm != 2400 {
+			t.Errorf("Expected 2400 RPM, got %v", rpm)
+		}
+	})
+
+	t.Run("Pulse Counting", func(t *testing.T) {
+		gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		pin := &gpiotest.Pin{N: "tach", EdgesChan: make(chan gpio.Level, 100)}
+		if err := gpioCtrl.ConfigurePin("tach", pin, gpio.Float); err != nil {
+			t.Fatalf("Failed to configure pin: %v", err)
+		}
+		go func() {
+			// Configuring the pin flushes buffered edges, so pulse after it
+			time.Sleep(10 * time.Millisecond)
+			for i := 0; i < 20; i++ {
+				pin.EdgesChan <- gpio.Low
+			}
+		}()
+		// 20 pulses at 2 per revolution in 100ms is 6000 RPM
+		rpm, err := NewPulseTach(gpioCtrl, "tach", 0, 100*time.Millisecond).ReadRPM()
+		if err != nil {
+			t.Fatalf("Failed to read tach: %v", err)
+		}
+		if rpm != 6000 {
+			t.Errorf("Expected 6000 RPM, got %v", rpm)
+		}
+		if pin.P != gpio.PullUp {
+			t.Error("Tach input not pulled up")
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+		if err != nil {
+			t.Fatalf("Failed to create GPIO controller: %v", err)
+		}
+		monitor, err := New(Config{
+			GPIO:          gpioCtrl,
+			FanControlPin: "test_fan",
+			FanProfile:    "capped",
+			FanProfiles:   []FanProfile{{Name: "capped", MaxDuty: 50}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+		if _, err := monitor.FanRPM(); err == nil {
+			t.Error("Expected error without tachometer")
+		}
+
+		monitor.state.CPUTemp = 35.0
+		if err := monitor.OverrideFan(100); err != nil {
+			t.Fatalf("Failed to override fan: %v", err)
+		}
+		if state := monitor.GetState(); state.FanSpeed != 100 || !state.FanOverride {
+			t.Errorf("Expected override past profile cap, got %d", state.FanSpeed)
+		}
+
+		monitor.ReleaseFan()
+		if state := monitor.GetState(); state.FanSpeed != fanSpeedLow || state.FanOverride {
+			t.Errorf("Expected automatic control after release, got %d", state.FanSpeed)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package thermal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kernel sysfs locations relative to the sysfs root
+const (
+	cpufreqDir       = "sys/devices/system/cpu/cpufreq"
+	coolingDeviceDir = "sys/class/thermal"
+)
+
+// throttleTarget is a single kernel knob driven by stepwise throttling
+type throttleTarget struct {
+	path     string // Writable control file
+	original int64  // Value restored when unthrottled
+	min      int64  // Value at maximum throttle level
+	max      int64  // Value when unthrottled
+}
+
+// valueForLevel returns the value to write for a throttle level
+func (t *throttleTarget) valueForLevel(level, levels int) int64 {
+	if level <= 0 {
+		return t.original
+	}
+	if level >= levels {
+		return t.min
+	}
+	return t.max - (t.max-t.min)*int64(level)/int64(levels)
+}
+
+// kernelThrottle tracks kernel throttling state
+type kernelThrottle struct {
+	cfg     ThrottleConfig
+	targets []*throttleTarget
+	level   int
+}
+
+// applyThrottleDefaults fills unset throttle configuration values
+func applyThrottleDefaults(cfg *ThrottleConfig) {
+	if cfg.Method == "" {
+		cfg.Method = ThrottleCPUFreq
+	}
+	if len(cfg.LevelTemps) == 0 {
+		cfg.LevelTemps = append([]float64(nil), defaultThrottleLevelTemps...)
+	}
+	if cfg.Hysteresis == 0 {
+		cfg.Hysteresis = defaultThrottleHysteresis
+	}
+}
+
+// initKernelThrottle discovers throttle targets and records their original values
+func (m *Monitor) initKernelThrottle(cfg ThrottleConfig) error {
+	applyThrottleDefaults(&cfg)
+	if !sort.Float64sAreSorted(cfg.LevelTemps) {
+		return fmt.Errorf("throttle level temperatures must be ascending")
+	}
+
+	kt := &kernelThrottle{cfg: cfg}
+	switch cfg.Method {
+	case ThrottleCPUFreq:
+		policies := cfg.CPUFreqPolicies
+		if len(policies) == 0 {
+			matches, err := filepath.Glob(filepath.Join(m.sysfsRoot, cpufreqDir, "policy*"))
+			if err != nil {
+				return fmt.Errorf("failed to list cpufreq policies: %w", err)
+			}
+			for _, match := range matches {
+				policies = append(policies, filepath.Base(match))
+			}
+		}
+		for _, policy := range policies {
+			dir := filepath.Join(m.sysfsRoot, cpufreqDir, filepath.Base(policy))
+			target, err := newThrottleTarget(
+				filepath.Join(dir, "scaling_max_freq"),
+				filepath.Join(dir, "cpuinfo_min_freq"),
+				filepath.Join(dir, "cpuinfo_max_freq"),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to initialize cpufreq %s: %w", policy, err)
+			}
+			kt.targets = append(kt.targets, target)
+		}
+	case ThrottleCoolingDevice:
+		devices := cfg.CoolingDevices
+		if len(devices) == 0 {
+			matches, err := filepath.Glob(filepath.Join(m.sysfsRoot, coolingDeviceDir, "cooling_device*"))
+			if err != nil {
+				return fmt.Errorf("failed to list cooling devices: %w", err)
+			}
+			for _, match := range matches {
+				n, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(match), "cooling_device"))
+				if err == nil {
+					devices = append(devices, n)
+				}
+			}
+		}
+		for _, n := range devices {
+			dir := filepath.Join(m.sysfsRoot, coolingDeviceDir, fmt.Sprintf("cooling_device%d", n))
+			target, err := newThrottleTarget(
+				filepath.Join(dir, "cur_state"),
+				"",
+				filepath.Join(dir, "max_state"),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to initialize cooling device %d: %w", n, err)
+			}
+			// Cooling device states count upward, zero being no cooling
+			target.min, target.max = target.max, 0
+			kt.targets = append(kt.targets, target)
+		}
+	default:
+		return fmt.Errorf("unsupported throttle method: %s", cfg.Method)
+	}
+
+	if len(kt.targets) == 0 {
+		return fmt.Errorf("no %s throttle targets found", cfg.Method)
+	}
+
+	m.kernelThrottle = kt
+	return nil
+}
+
+// newThrottleTarget reads the current and limit values for a control file
+func newThrottleTarget(control, minPath, maxPath string) (*throttleTarget, error) {
+	original, err := readSysfsInt(control)
+	if err != nil {
+		return nil, err
+	}
+	maxValue, err := readSysfsInt(maxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var minValue int64
+	if minPath != "" {
+		if minValue, err = readSysfsInt(minPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &throttleTarget{
+		path:     control,
+		original: original,
+		min:      minValue,
+		max:      maxValue,
+	}, nil
+}
+
+// throttleLevelFor returns the level whose threshold the temperature has reached
+func (kt *kernelThrottle) throttleLevelFor(temp float64) int {
+	level := 0
+	for i, threshold := range kt.cfg.LevelTemps {
+		if temp >= threshold {
+			level = i + 1
+		}
+	}
+	return level
+}
+
+// updateKernelThrottleLocked steps kernel throttling toward the required level - must be called with lock held
+func (m *Monitor) updateKernelThrottleLocked(maxTemp float64, force bool) {
+	kt := m.kernelThrottle
+	if kt == nil {
+		return
+	}
+
+	levels := len(kt.cfg.LevelTemps)
+	target := kt.throttleLevelFor(maxTemp)
+	if force {
+		target = levels
+	}
+
+	// Step one level per update, holding the current level within hysteresis
+	next := kt.level
+	switch {
+	case target > kt.level:
+		next = kt.level + 1
+	case target < kt.level:
+		if maxTemp < kt.cfg.LevelTemps[kt.level-1]-kt.cfg.Hysteresis {
+			next = kt.level - 1
+		}
+	}
+	if next == kt.level {
+		return
+	}
+
+	if err := m.applyKernelThrottleLocked(next); err != nil {
+		m.state.addWarning(fmt.Sprintf("Failed to set kernel throttle level: %v", err))
+	}
+}
+
+// applyKernelThrottleLocked writes a throttle level to every target - must be called with lock held
+func (m *Monitor) applyKernelThrottleLocked(level int) error {
+	kt := m.kernelThrottle
+	levels := len(kt.cfg.LevelTemps)
+
+	for _, target := range kt.targets {
+		if err := writeSysfsInt(target.path, target.valueForLevel(level, levels)); err != nil {
+			return err
+		}
+	}
+
+	kt.level = level
+	m.state.ThrottleLevel = level
+	return nil
+}
+
+// restoreKernelThrottle returns all throttle targets to their original values
+func (m *Monitor) restoreKernelThrottle() error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.kernelThrottle == nil || m.kernelThrottle.level == 0 {
+		return nil
+	}
+	return m.applyKernelThrottleLocked(0)
+}
+
+// readSysfsInt reads an integer value from a sysfs attribute
+func readSysfsInt(path string) (int64, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return value, nil
+}
+
+// writeSysfsInt writes an integer value to a sysfs attribute
+func writeSysfsInt(path string, value int64) error {
+	if err := os.WriteFile(filepath.Clean(path), []byte(strconv.FormatInt(value, 10)), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package thermal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	hw_gpio "github.com/wrale/wrale-fleet-metal-hw/gpio"
+)
+
+// writeFakeSysfs creates a file under a fake sysfs root
+func writeFakeSysfs(t *testing.T, root, path, value string) {
+	t.Helper()
+	full := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatalf("Failed to create %s: %v", filepath.Dir(full), err)
+	}
+	if err := os.WriteFile(full, []byte(value), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", full, err)
+	}
+}
+
+// readFakeSysfs reads a file under a fake sysfs root
+func readFakeSysfs(t *testing.T, root, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestKernelThrottle(t *testing.T) {
+	const tempPath = "/sys/class/thermal/thermal_zone0/temp"
+
+	gpioCtrl, err := hw_gpio.New(hw_gpio.WithSimulation())
+	if err != nil {
+		t.Fatalf("Failed to create GPIO controller: %v", err)
+	}
+
+	t.Run("CPU Frequency", func(t *testing.T) {
+		root := t.TempDir()
+		policy := "sys/devices/system/cpu/cpufreq/policy0/"
+		writeFakeSysfs(t, root, tempPath, "50000")
+		writeFakeSysfs(t, root, policy+"cpuinfo_min_freq", "600000")
+		writeFakeSysfs(t, root, policy+"cpuinfo_max_freq", "1800000")
+		writeFakeSysfs(t, root, policy+"scaling_max_freq", "1500000")
+
+		monitor, err := New(Config{
+			GPIO:        gpioCtrl,
+			CPUTempPath: tempPath,
+			SysfsRoot:   root,
+			ThrottleConfig: &ThrottleConfig{
+				Method:     ThrottleCPUFreq,
+				LevelTemps: []float64{60, 70, 80},
+				Hysteresis: 2,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+
+		// step sets the temperature and runs one monitor update
+		step := func(milliC string) {
+			writeFakeSysfs(t, root, tempPath, milliC)
+			if err := monitor.updateThermalState(); err != nil {
+				t.Fatalf("Failed to update thermal state: %v", err)
+			}
+		}
+
+		step("75000")
+		if got := readFakeSysfs(t, root, policy+"scaling_max_freq"); got != "1400000" {
+			t.Errorf("Expected first throttle step 1400000, got %s", got)
+		}
+		step("75000")
+		if got := readFakeSysfs(t, root, policy+"scaling_max_freq"); got != "1000000" {
+			t.Errorf("Expected second throttle step 1000000, got %s", got)
+		}
+		if state := monitor.GetState(); state.ThrottleLevel != 2 || !state.Throttled {
+			t.Errorf("Expected throttle level 2, got %d (throttled %v)", state.ThrottleLevel, state.Throttled)
+		}
+
+		// Within hysteresis the level must hold
+		step("69000")
+		if state := monitor.GetState(); state.ThrottleLevel != 2 {
+			t.Errorf("Expected throttle level held within hysteresis, got %d", state.ThrottleLevel)
+		}
+
+		step("50000")
+		step("50000")
+		if got := readFakeSysfs(t, root, policy+"scaling_max_freq"); got != "1500000" {
+			t.Errorf("Expected original frequency restored, got %s", got)
+		}
+		if state := monitor.GetState(); state.ThrottleLevel != 0 || state.Throttled {
+			t.Errorf("Expected throttling released, got level %d", state.ThrottleLevel)
+		}
+	})
+
+	t.Run("Cooling Device", func(t *testing.T) {
+		root := t.TempDir()
+		device := "sys/class/thermal/cooling_device0/"
+		writeFakeSysfs(t, root, tempPath, "50000")
+		writeFakeSysfs(t, root, device+"max_state", "4")
+		writeFakeSysfs(t, root, device+"cur_state", "0")
+
+		monitor, err := New(Config{
+			GPIO:        gpioCtrl,
+			CPUTempPath: tempPath,
+			SysfsRoot:   root,
+			ThrottleConfig: &ThrottleConfig{
+				Method:     ThrottleCoolingDevice,
+				LevelTemps: []float64{60, 70},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create thermal monitor: %v", err)
+		}
+
+		writeFakeSysfs(t, root, tempPath, "72000")
+		for i := 0; i < 2; i++ {
+			if err := monitor.updateThermalState(); err != nil {
+				t.Fatalf("Failed to update thermal state: %v", err)
+			}
+		}
+		if got := readFakeSysfs(t, root, device+"cur_state"); got != "4" {
+			t.Errorf("Expected maximum cooling state 4, got %s", got)
+		}
+
+		if err := monitor.Close(); err != nil {
+			t.Fatalf("Failed to close monitor: %v", err)
+		}
+		if got := readFakeSysfs(t, root, device+"cur_state"); got != "0" {
+			t.Errorf("Expected cooling state restored on close, got %s", got)
+		}
+	})
+
+	t.Run("Missing Targets", func(t *testing.T) {
+		_, err := New(Config{
+			GPIO:           gpioCtrl,
+			SysfsRoot:      t.TempDir(),
+			ThrottleConfig: &ThrottleConfig{},
+		})
+		if err == nil {
+			t.Error("Expected error when no throttle targets exist")
+		}
+	})
+}
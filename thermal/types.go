@@ -20,17 +20,105 @@ const (
 
 	// Default monitoring interval
 	defaultMonitorInterval = 1 * time.Second
+
+	// Runaway protection defaults
+	defaultHistorySize       = 60
+	defaultMinTrendSamples   = 5
+	defaultRiseRateThreshold = 0.1  // °C per second while fans are at maximum
+	defaultEmergencyTemp     = 85.0 // Load shedding threshold
+	defaultShutdownTemp      = 90.0 // Shutdown request threshold
+	defaultMinTimeToCritical = 30 * time.Second
+	defaultRunawayHysteresis = 3.0 // °C below a threshold before stepping down
+	runawayReleaseRatio      = 0.5 // Fraction of the rise rate that keeps throttling engaged
+
+	// Kernel throttling defaults
+	defaultSysfsRoot          = "/"
+	defaultThrottleHysteresis = 3.0
+
+	// Heater control defaults
+	defaultHeaterOnTemp     = 2.0 // Heater switches on below this temperature
+	defaultHeaterOffTemp    = 5.0 // Heater switches off above this temperature
+	defaultHeaterMinOnTime  = 1 * time.Minute
+	defaultHeaterMinOffTime = 1 * time.Minute
+
+	// Condensation protection defaults
+	defaultCondensationMargin = 2.0 // °C between surface and dew point
+)
+
+// Default kernel throttle level thresholds, one per level
+var defaultThrottleLevelTemps = []float64{cpuTempWarning, 75.0, cpuTempCritical}
+
+// Sensor identifies a temperature sensor tracked by the monitor
+type Sensor string
+
+const (
+	SensorCPU     Sensor = "CPU"
+	SensorGPU     Sensor = "GPU"
+	SensorAmbient Sensor = "AMBIENT"
+)
+
+// RunawayLevel represents the escalation stage of thermal runaway protection
+type RunawayLevel string
+
+const (
+	RunawayNone     RunawayLevel = "NONE"
+	RunawayThrottle RunawayLevel = "THROTTLE"
+	RunawayShedLoad RunawayLevel = "SHED_LOAD"
+	RunawayShutdown RunawayLevel = "SHUTDOWN"
+)
+
+// Built-in fan acoustic profile names
+const (
+	ProfileSilent      = "silent"
+	ProfileBalanced    = "balanced"
+	ProfilePerformance = "performance"
+)
+
+// FanProfile caps fan duty and rate-of-change for acoustic comfort
+type FanProfile struct {
+	Name    string
+	MaxDuty uint32  // Maximum duty cycle percentage
+	MaxRate float64 // Maximum duty change in percent per second, zero for unlimited
+}
+
+// FanScheduleEntry selects a fan profile for a time-of-day window
+type FanScheduleEntry struct {
+	Start   time.Duration // Offset from local midnight when the window opens
+	End     time.Duration // Offset from local midnight when the window closes, may wrap past midnight
+	Profile string        // Name of the profile active during the window
+}
+
+// ThrottleMethod selects how the kernel is asked to throttle
+type ThrottleMethod string
+
+const (
+	ThrottleCPUFreq       ThrottleMethod = "CPUFREQ"
+	ThrottleCoolingDevice ThrottleMethod = "COOLING_DEVICE"
+)
+
+// Warnings that mirror structured ThermalState fields
+const (
+	WarningRunaway      = "Thermal runaway protection active" // Set while Runaway is engaged
+	WarningCondensation = "Condensation risk"                 // Prefix used while Condensation is set
 )
 
 // ThermalState represents current thermal conditions
 type ThermalState struct {
-	CPUTemp     float64   // CPU temperature in Celsius
-	GPUTemp     float64   // GPU temperature in Celsius
-	AmbientTemp float64   // Ambient temperature in Celsius
-	FanSpeed    uint32    // Current fan speed percentage
-	Throttled   bool      // Whether system is throttled
-	Warnings    []string  // Active thermal warnings
-	UpdatedAt   time.Time // Last update timestamp
+	CPUTemp       float64      // CPU temperature in Celsius
+	GPUTemp       float64      // GPU temperature in Celsius
+	AmbientTemp   float64      // Ambient temperature in Celsius
+	FanSpeed      uint32       // Current fan speed percentage
+	Throttled     bool         // Whether system is throttled
+	ThrottleLevel int          // Current kernel throttle level, zero when unthrottled
+	HeaterOn      bool         // Whether the enclosure heater is energized
+	FanProfile    string       // Active fan acoustic profile
+	FanOverride   bool         // Whether the fan is held at a manual duty cycle
+	Humidity      float64      // Enclosure relative humidity in percent
+	DewPoint      float64      // Enclosure dew point in Celsius
+	Condensation  bool         // Whether a surface is near or below the dew point
+	Runaway       RunawayLevel // Current runaway escalation level
+	Warnings      []string     // Active thermal warnings
+	UpdatedAt     time.Time    // Last update timestamp
 }
 
 // addWarning adds a warning message to the thermal state
@@ -47,6 +135,130 @@ type Config struct {
 	AmbientTempPath string             // sysfs path to ambient temperature sensor
 	FanControlPin   string             // GPIO pin for fan control
 	ThrottlePin     string             // GPIO pin for throttling control
+	HeaterPin       string             // GPIO pin for heater relay control
 	OnWarning       func(ThermalState) // Callback for warning conditions
 	OnCritical      func(ThermalState) // Callback for critical conditions
+
+	// Root of the sysfs tree, overridable for testing against a fake tree
+	SysfsRoot string
+
+	// Thermal runaway protection configuration
+	RunawayConfig *RunawayConfig
+
+	// Kernel throttling configuration
+	ThrottleConfig *ThrottleConfig
+
+	// Heater control configuration, used when HeaterPin is set
+	HeaterConfig *HeaterConfig
+
+	// Fan acoustic profiles: default profile, custom profiles and time-of-day schedule
+	FanProfile  string
+	FanProfiles []FanProfile
+	FanSchedule []FanScheduleEntry
+
+	// Optional fan tachometer for speed feedback
+	FanTach Tachometer
+
+	// Enclosure humidity sensor and condensation protection
+	HumiditySensor     HumiditySensor
+	CondensationConfig *CondensationConfig
+}
+
+// HeaterConfig holds cold-climate heater control configuration
+type HeaterConfig struct {
+	// Sensors whose coldest reading drives the heater, ambient when empty
+	Sensors []Sensor
+	// Temperature below which the heater switches on
+	OnTemp float64
+	// Temperature above which the heater switches off
+	OffTemp float64
+	// Minimum time the heater stays on once switched on
+	MinOnTime time.Duration
+	// Minimum time the heater stays off once switched off
+	MinOffTime time.Duration
+}
+
+// ThrottleConfig holds kernel throttling configuration
+type ThrottleConfig struct {
+	// Throttling mechanism to drive
+	Method ThrottleMethod
+	// cpufreq policies to limit (e.g. "policy0"), all policies when empty
+	CPUFreqPolicies []string
+	// Cooling device indices to drive (N in cooling_deviceN), all devices when empty
+	CoolingDevices []int
+	// Ascending temperature thresholds, one per throttle level
+	LevelTemps []float64
+	// Degrees below a level's threshold before stepping down
+	Hysteresis float64
+}
+
+// RunawayConfig holds thermal runaway protection configuration
+type RunawayConfig struct {
+	// Number of samples to keep per sensor for trend analysis
+	HistorySize int
+	// Minimum samples required before a trend is evaluated
+	MinSamples int
+	// Rate of rise in °C/s treated as runaway while fans are at maximum
+	RiseRateThreshold float64
+	// Temperature at which loads are shed during runaway
+	EmergencyTemp float64
+	// Temperature at which shutdown is requested
+	ShutdownTemp float64
+	// Predicted time-to-critical below which loads are shed
+	MinTimeToCritical time.Duration
+	// Degrees below the emergency and shutdown thresholds before stepping down
+	Hysteresis float64
+	// Load shedding callbacks, invoked in order when shedding loads.
+	// All runaway callbacks run after the monitor lock is released and
+	// receive a snapshot of the state, so they may query the Monitor.
+	LoadShedders []func(ThermalState) error
+	// Callback requesting an orderly system shutdown
+	OnShutdown func(ThermalState)
+	// Callback for runaway escalation changes
+	OnRunaway func(RunawayEvent)
+}
+
+// CondensationConfig holds condensation protection configuration
+type CondensationConfig struct {
+	// Surface sensors compared against the dew point, all configured sensors when empty
+	SurfaceSensors []Sensor
+	// Minimum spread in °C between the coldest surface and the dew point,
+	// 2°C when nil. Zero flags risk only once a surface reaches the dew point.
+	Margin *float64
+	// Run the heater while condensation risk persists
+	HeatOnRisk bool
+	// Run the fan at medium speed while condensation risk persists
+	VentOnRisk bool
+	// Callback when condensation risk is first detected
+	OnCondensationRisk func(ThermalState)
+}
+
+// HumidityReading is a single humidity sensor measurement
+type HumidityReading struct {
+	Temperature      float64 // Air temperature in Celsius
+	RelativeHumidity float64 // Relative humidity in percent
+}
+
+// HumiditySensor provides enclosure humidity measurements
+type HumiditySensor interface {
+	// ReadHumidity performs a measurement
+	ReadHumidity() (HumidityReading, error)
+}
+
+// TempTrend describes the recent behavior of a temperature sensor
+type TempTrend struct {
+	Sensor         Sensor
+	Current        float64       // Latest reading in Celsius
+	Rate           float64       // Rate of change in °C per second
+	TimeToCritical time.Duration // Predicted time until critical, zero if not rising
+	Samples        int           // Number of samples in the trend window
+}
+
+// RunawayEvent represents a thermal runaway escalation change
+type RunawayEvent struct {
+	Timestamp time.Time
+	Level     RunawayLevel
+	Previous  RunawayLevel
+	Trend     TempTrend
+	Details   string
 }